@@ -0,0 +1,61 @@
+package m3u8
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// attribute is a name and value pair from a tag's attribute list,
+// as specified in RFC 8216 section 4.2.
+type attribute struct {
+	name  string
+	value item
+}
+
+// parseAttributeList reads the attribute list of a tag from items
+// up to the end of the line.
+func parseAttributeList(items chan item) ([]attribute, error) {
+	var attrs []attribute
+	for {
+		it := <-items
+		switch it.typ {
+		case itemError:
+			return nil, errors.New(it.val)
+		case itemNewline, itemEOF:
+			return attrs, nil
+		case itemAttrName:
+		default:
+			return nil, fmt.Errorf("expected attribute name, got %s", it)
+		}
+		name := it.val
+		it = <-items
+		if it.typ != itemEquals {
+			return nil, fmt.Errorf("parse %s: expected =, got %s", name, it)
+		}
+		value := <-items
+		if value.typ == itemError {
+			return nil, errors.New(value.val)
+		}
+		attrs = append(attrs, attribute{name, value})
+		it = <-items
+		switch it.typ {
+		case itemComma:
+			continue
+		case itemNewline, itemEOF:
+			return attrs, nil
+		case itemError:
+			return nil, errors.New(it.val)
+		default:
+			return nil, fmt.Errorf("after %s: expected comma or newline, got %s", name, it)
+		}
+	}
+}
+
+// unquote returns the contents of the quoted-string s.
+func unquote(s string) (string, error) {
+	if len(s) < 2 || !strings.HasPrefix(s, `"`) || !strings.HasSuffix(s, `"`) {
+		return "", fmt.Errorf("%s is not a quoted string", s)
+	}
+	return s[1 : len(s)-1], nil
+}
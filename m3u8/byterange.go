@@ -0,0 +1,78 @@
+package m3u8
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ByteRangeOverlapError describes two segments whose byte ranges of
+// the same resource intersect, which usually indicates a packaging
+// bug causing players to decode some media twice.
+type ByteRangeOverlapError struct {
+	URI string
+	// Segments holds the indices in Playlist.Segments of the
+	// overlapping segments, in playlist order.
+	Segments [2]int
+	// Ranges holds the resolved byte ranges of the segments.
+	Ranges [2]ByteRange
+}
+
+func (e *ByteRangeOverlapError) Error() string {
+	return fmt.Sprintf("segments %d and %d of %s: byte ranges %s and %s overlap", e.Segments[0], e.Segments[1], e.URI, e.Ranges[0], e.Ranges[1])
+}
+
+// end returns the offset of the byte following the range.
+func (r ByteRange) end() int {
+	return r[1] + r[0]
+}
+
+// CheckByteRanges returns a *ByteRangeOverlapError for segments of p
+// whose byte ranges of the same URI overlap. Ranges need not be
+// contiguous nor in order, as in an I-frame playlist; only ranges
+// sharing bytes are reported. Each segment is reported at most once,
+// paired with the segment whose range, of those starting no later
+// in the resource, ends last.
+func (p *Playlist) CheckByteRanges() []error {
+	byURI := make(map[string][]int)
+	var uris []string
+	for i, seg := range p.Segments {
+		if seg.Range[0] == 0 {
+			continue
+		}
+		if _, ok := byURI[seg.URI]; !ok {
+			uris = append(uris, seg.URI)
+		}
+		byURI[seg.URI] = append(byURI[seg.URI], i)
+	}
+
+	var errs []error
+	for _, uri := range uris {
+		indices := byURI[uri]
+		sort.SliceStable(indices, func(i, j int) bool {
+			return p.Segments[indices[i]].Range[1] < p.Segments[indices[j]].Range[1]
+		})
+		// last is the range ending furthest into the resource so far.
+		last := indices[0]
+		for _, i := range indices[1:] {
+			r, prev := p.Segments[i].Range, p.Segments[last].Range
+			if r[1] < prev.end() {
+				a, b := last, i
+				if a > b {
+					a, b = b, a
+				}
+				errs = append(errs, &ByteRangeOverlapError{
+					URI:      uri,
+					Segments: [2]int{a, b},
+					Ranges:   [2]ByteRange{p.Segments[a].Range, p.Segments[b].Range},
+				})
+			}
+			if r.end() > prev.end() {
+				last = i
+			}
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].(*ByteRangeOverlapError).Segments[1] < errs[j].(*ByteRangeOverlapError).Segments[1]
+	})
+	return errs
+}
@@ -0,0 +1,69 @@
+package m3u8
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckByteRanges(t *testing.T) {
+	const plist = `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-TARGETDURATION:6
+#EXTINF:6.000,
+#EXT-X-BYTERANGE:1000@0
+media.ts
+#EXTINF:6.000,
+#EXT-X-BYTERANGE:1000@800
+media.ts
+#EXTINF:6.000,
+#EXT-X-BYTERANGE:500
+media.ts
+#EXTINF:6.000,
+#EXT-X-BYTERANGE:1000@0
+other.ts
+`
+	var warnings []error
+	dec := Decoder{OnWarning: func(err error) { warnings = append(warnings, err) }}
+	p, err := dec.Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("got %d warnings, want 1: %v", len(warnings), warnings)
+	}
+	errs := p.CheckByteRanges()
+	if len(errs) != 1 {
+		t.Fatalf("got %d overlaps, want 1: %v", len(errs), errs)
+	}
+	var overlap *ByteRangeOverlapError
+	if !errors.As(errs[0], &overlap) {
+		t.Fatalf("got error %T, want %T", errs[0], overlap)
+	}
+	want := ByteRangeOverlapError{
+		URI:      "media.ts",
+		Segments: [2]int{0, 1},
+		Ranges:   [2]ByteRange{{1000, 0}, {1000, 800}},
+	}
+	if *overlap != want {
+		t.Errorf("got %+v, want %+v", *overlap, want)
+	}
+
+	// contiguous ranges listed out of order, as in I-frame playlists,
+	// and ranges with gaps between them do not overlap.
+	p = &Playlist{Segments: []Segment{
+		{URI: "media.ts", Range: ByteRange{100, 200}},
+		{URI: "media.ts", Range: ByteRange{100, 0}},
+		{URI: "media.ts", Range: ByteRange{100, 100}},
+		{URI: "media.ts", Range: ByteRange{100, 500}},
+	}}
+	if errs := p.CheckByteRanges(); len(errs) != 0 {
+		t.Errorf("got overlaps %v, want none", errs)
+	}
+
+	// a long range contains later, shorter ones.
+	p.Segments = append(p.Segments, Segment{URI: "media.ts", Range: ByteRange{1000, 0}})
+	if errs := p.CheckByteRanges(); len(errs) != 4 {
+		t.Errorf("got %d overlaps, want 4: %v", len(errs), errs)
+	}
+}
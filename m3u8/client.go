@@ -0,0 +1,270 @@
+package m3u8
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Fetcher fetches the resource at a URL, such as a playlist.
+// Implementations may add authentication, sign URLs, or serve
+// canned responses in tests.
+type Fetcher interface {
+	// Fetch returns the body of the resource at url and any
+	// metadata, such as Content-Type, from the response.
+	// The caller must close the body. Fetch returns ErrNotModified
+	// if the resource is unchanged since it was last fetched.
+	Fetch(ctx context.Context, url string) (io.ReadCloser, http.Header, error)
+}
+
+// ErrNotModified is returned by a Fetcher if a resource has not
+// changed since it was last fetched.
+var ErrNotModified = errors.New("not modified")
+
+// HTTPFetcher is a Fetcher using HTTP. Responses are requested
+// gzip-compressed, and conditional requests are made using the cache
+// validators (ETag and Last-Modified headers) of previous responses
+// to avoid refetching unchanged resources.
+type HTTPFetcher struct {
+	Client *http.Client // http.DefaultClient if nil.
+	// Timeout, if non-zero, limits the duration of each attempt,
+	// including reading the body.
+	Timeout time.Duration
+	// Retries is the number of times a failed request is retried.
+	// Requests are retried after network errors and server errors
+	// (5xx status codes), waiting twice as long as the last time
+	// before each retry, starting from 100 milliseconds.
+	Retries int
+
+	mu         sync.Mutex
+	validators map[string]validators
+}
+
+type validators struct {
+	etag         string
+	lastModified string
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, http.Header, error) {
+	wait := 100 * time.Millisecond
+	for i := 0; ; i++ {
+		body, header, err := f.fetch(ctx, url)
+		var retry *retryableError
+		if !errors.As(err, &retry) || i >= f.Retries {
+			return body, header, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// retryableError is a temporary failure worth retrying.
+type retryableError struct{ err error }
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+func (f *HTTPFetcher) fetch(ctx context.Context, url string) (io.ReadCloser, http.Header, error) {
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	cancel := func() {}
+	if f.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	// Setting Accept-Encoding ourselves disables transparent
+	// decompression by http.Transport, so we handle it below.
+	req.Header.Set("Accept-Encoding", "gzip")
+	f.mu.Lock()
+	prev, ok := f.validators[url]
+	f.mu.Unlock()
+	if ok {
+		if prev.etag != "" {
+			req.Header.Set("If-None-Match", prev.etag)
+		}
+		if prev.lastModified != "" {
+			req.Header.Set("If-Modified-Since", prev.lastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, nil, &retryableError{err}
+	}
+	if resp.StatusCode == http.StatusNotModified && ok {
+		resp.Body.Close()
+		cancel()
+		return nil, resp.Header, ErrNotModified
+	} else if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		err := fmt.Errorf("non-OK response status: %s", resp.Status)
+		if resp.StatusCode >= 500 {
+			return nil, nil, &retryableError{err}
+		}
+		return nil, nil, err
+	}
+
+	body := &body{ReadCloser: resp.Body, cancel: cancel}
+	switch resp.Header.Get("Content-Encoding") {
+	case "":
+	case "gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			body.Close()
+			return nil, nil, fmt.Errorf("decompress: %w", err)
+		}
+		body.Reader = zr
+	default:
+		body.Close()
+		return nil, nil, fmt.Errorf("unsupported content encoding %q", resp.Header.Get("Content-Encoding"))
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.validators == nil {
+		f.validators = make(map[string]validators)
+	}
+	f.validators[url] = validators{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+	return body, resp.Header, nil
+}
+
+// Forget discards the cache validators recorded for url, so that
+// the next request for it is unconditional. Client calls Forget
+// when a response could not be decoded, or when a resource is
+// reported unchanged but it has no copy of it.
+func (f *HTTPFetcher) Forget(url string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.validators, url)
+}
+
+// body is a response body, possibly decompressed by Reader,
+// whose request context is cancelled on Close.
+type body struct {
+	io.ReadCloser
+	io.Reader
+	cancel func()
+}
+
+func (b *body) Read(p []byte) (int, error) {
+	if b.Reader != nil {
+		return b.Reader.Read(p)
+	}
+	return b.ReadCloser.Read(p)
+}
+
+func (b *body) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// Client reloads playlists, such as to follow a live media playlist.
+type Client struct {
+	// Fetcher fetches playlists. If nil, an HTTPFetcher using
+	// HTTPClient is used.
+	Fetcher Fetcher
+	// HTTPClient is the client of the default HTTPFetcher, and is
+	// ignored if Fetcher is set. http.DefaultClient if nil.
+	HTTPClient *http.Client
+
+	mu    sync.Mutex
+	http  *HTTPFetcher
+	cache map[string]*Playlist
+}
+
+// Reload fetches and decodes the playlist at url.
+// If the playlist has not changed since it was last fetched by c,
+// Reload returns the previously fetched playlist and modified is false.
+// Reload is equivalent to ReloadContext with a background context.
+func (c *Client) Reload(url string) (p *Playlist, modified bool, err error) {
+	return c.ReloadContext(context.Background(), url)
+}
+
+// ReloadContext is like Reload but fetches the playlist using ctx.
+func (c *Client) ReloadContext(ctx context.Context, url string) (p *Playlist, modified bool, err error) {
+	return c.reload(ctx, url, url)
+}
+
+// ReloadNext is like ReloadContext, but if prev, the playlist last
+// fetched from url, indicates in its ServerControl that the server can
+// block playlist reloads, ReloadNext requests the playlist once the
+// segment or part following the last of prev is available, using the
+// _HLS_msn and _HLS_part query parameters of Low-Latency HLS.
+// The server holds the request until then, so ctx should allow for
+// several target durations. Otherwise ReloadNext is equivalent to
+// ReloadContext, and callers should wait between reloads as usual.
+func (c *Client) ReloadNext(ctx context.Context, url string, prev *Playlist) (p *Playlist, modified bool, err error) {
+	if prev == nil || prev.ServerControl == nil || !prev.ServerControl.CanBlockReload {
+		return c.ReloadContext(ctx, url)
+	}
+	next, err := blockingReloadURL(url, prev)
+	if err != nil {
+		return nil, false, fmt.Errorf("blocking reload: %w", err)
+	}
+	return c.reload(ctx, url, next)
+}
+
+// reload fetches the playlist from fetchURL, caching it under url.
+func (c *Client) reload(ctx context.Context, url, fetchURL string) (p *Playlist, modified bool, err error) {
+	c.mu.Lock()
+	fetcher := c.Fetcher
+	if fetcher == nil {
+		if c.http == nil {
+			c.http = &HTTPFetcher{Client: c.HTTPClient}
+		}
+		fetcher = c.http
+	}
+	prev := c.cache[url]
+	c.mu.Unlock()
+
+	// A fetcher recording cache validators must forget them if we
+	// have no playlist to reuse, or every later request would be
+	// answered as not modified.
+	forgetter, _ := fetcher.(interface{ Forget(url string) })
+	body, _, err := fetcher.Fetch(ctx, fetchURL)
+	if errors.Is(err, ErrNotModified) && prev == nil && forgetter != nil {
+		forgetter.Forget(fetchURL)
+		body, _, err = fetcher.Fetch(ctx, fetchURL)
+	}
+	if errors.Is(err, ErrNotModified) && prev != nil {
+		return prev, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	defer body.Close()
+	p, err = Decode(body)
+	if err != nil {
+		if forgetter != nil {
+			forgetter.Forget(fetchURL)
+		}
+		return nil, false, fmt.Errorf("decode playlist: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cache == nil {
+		c.cache = make(map[string]*Playlist)
+	}
+	c.cache[url] = p
+	return p, true, nil
+}
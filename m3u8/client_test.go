@@ -0,0 +1,176 @@
+package m3u8
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReloadGzipETag(t *testing.T) {
+	const etag = `"abc123"`
+	var requests, conditional int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if req.Header.Get("If-None-Match") == etag {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if req.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("client did not accept gzip encoding")
+		}
+		w.Header().Set("Content-Type", MimeType)
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("ETag", etag)
+		zw := gzip.NewWriter(w)
+		zw.Write([]byte(liveSnapshot))
+		zw.Close()
+	}))
+	defer srv.Close()
+
+	client := &Client{HTTPClient: srv.Client()}
+	p, modified, err := client.Reload(srv.URL + "/live.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified {
+		t.Errorf("first reload reported unmodified playlist")
+	}
+	if len(p.Segments) != 3 || p.Sequence != 100 {
+		t.Fatalf("unexpected playlist from gzip response: %+v", p)
+	}
+
+	again, modified, err := client.Reload(srv.URL + "/live.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modified {
+		t.Errorf("reload after 304 reported modified playlist")
+	}
+	if again != p {
+		t.Errorf("reload after 304 did not return previous playlist")
+	}
+	if requests != 2 || conditional != 1 {
+		t.Errorf("got %d requests, %d conditional; want 2, 1", requests, conditional)
+	}
+}
+
+func TestReloadAfterDecodeError(t *testing.T) {
+	const etag = `"v1"`
+	var requests, conditional int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.Header().Set("ETag", etag)
+		if requests == 1 {
+			// truncated before the first segment's URI.
+			w.Write([]byte("#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXTINF:6."))
+			return
+		}
+		if req.Header.Get("If-None-Match") == etag {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(liveSnapshot))
+	}))
+	defer srv.Close()
+	url := srv.URL + "/live.m3u8"
+
+	fetcher := &HTTPFetcher{Client: srv.Client()}
+	client := &Client{Fetcher: fetcher}
+	if _, _, err := client.Reload(url); err == nil {
+		t.Fatal("nil error reloading malformed playlist")
+	}
+	p, modified, err := client.Reload(url)
+	if err != nil {
+		t.Fatalf("reload after decode error: %v", err)
+	}
+	if !modified || len(p.Segments) != 3 {
+		t.Errorf("reload after decode error returned unexpected playlist: %+v", p)
+	}
+	if conditional != 0 {
+		t.Errorf("got %d conditional requests after decode error, want 0", conditional)
+	}
+
+	// A client with no cached copy retries a 304 unconditionally.
+	other := &Client{Fetcher: fetcher}
+	p, modified, err = other.Reload(url)
+	if err != nil {
+		t.Fatalf("reload answered not modified with nothing cached: %v", err)
+	}
+	if !modified || len(p.Segments) != 3 {
+		t.Errorf("unexpected playlist after retrying 304: %+v", p)
+	}
+	if requests != 4 || conditional != 1 {
+		t.Errorf("got %d requests, %d conditional; want 4, 1", requests, conditional)
+	}
+}
+
+type fakeFetcher struct {
+	playlists map[string]string
+	fetched   []string
+}
+
+func (f *fakeFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, http.Header, error) {
+	f.fetched = append(f.fetched, url)
+	s, ok := f.playlists[url]
+	if !ok {
+		return nil, nil, ErrNotModified
+	}
+	delete(f.playlists, url)
+	return io.NopCloser(strings.NewReader(s)), nil, nil
+}
+
+func TestReloadFetcher(t *testing.T) {
+	const url = "mem://live.m3u8"
+	fetcher := &fakeFetcher{playlists: map[string]string{url: liveSnapshot}}
+	client := &Client{Fetcher: fetcher}
+	p, modified, err := client.Reload(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified || len(p.Segments) != 3 {
+		t.Fatalf("unexpected playlist from fetcher: %+v", p)
+	}
+	again, modified, err := client.Reload(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modified || again != p {
+		t.Errorf("reload of unmodified playlist did not return previous playlist")
+	}
+	if len(fetcher.fetched) != 2 {
+		t.Errorf("got %d fetches, want 2", len(fetcher.fetched))
+	}
+
+	if _, _, err := client.Reload("mem://missing.m3u8"); err == nil {
+		t.Errorf("no error reloading unmodified playlist never fetched")
+	}
+}
+
+func TestHTTPFetcherRetry(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(liveSnapshot))
+	}))
+	defer srv.Close()
+
+	fetcher := &HTTPFetcher{Client: srv.Client(), Retries: 2}
+	body, _, err := fetcher.Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+	if requests != 3 {
+		t.Errorf("got %d requests, want 3", requests)
+	}
+}
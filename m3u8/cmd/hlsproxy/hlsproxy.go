@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -19,6 +20,10 @@ func init() {
 	log.SetPrefix("hlssrv: ")
 }
 
+// fetcher downloads the source playlist. Replace it to add
+// authentication or sign URLs; see m3u8.Fetcher.
+var fetcher m3u8.Fetcher = &m3u8.HTTPFetcher{Timeout: 30 * time.Second, Retries: 2}
+
 func servePlaylist(p *m3u8.Playlist) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		if req.Method != http.MethodGet {
@@ -61,15 +66,12 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	resp, err := http.Get(link.String())
+	body, _, err := fetcher.Fetch(context.Background(), link.String())
 	if err != nil {
-		log.Fatal("get playlist:", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		log.Fatal("get playlist: remote status:", resp.Status)
+		log.Fatal("get playlist: ", err)
 	}
-	source, err := m3u8.Decode(resp.Body)
+	defer body.Close()
+	source, err := m3u8.Decode(body)
 	if err != nil {
 		log.Fatal("parse playlist:", err)
 	}
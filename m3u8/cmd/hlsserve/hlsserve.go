@@ -44,7 +44,7 @@ const maxTSBytes int = 7 * mpegts.PacketSize
 const segmentDuration = 3 * time.Second
 
 var cacheDir string
-var sequence int
+var sequence int64
 
 func removeOld(dir string, maxAge time.Duration) error {
 	ents, err := os.ReadDir(dir)
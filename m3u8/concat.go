@@ -0,0 +1,161 @@
+package m3u8
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/url"
+)
+
+// Concat returns a media playlist playing each of playlists in turn,
+// such as to stitch advertisements before and after the main content
+// of a VOD presentation. Each playlist after the first starts with
+// a discontinuity. The URIs of each playlist are resolved against
+// the corresponding entry of bases, typically the URL the playlist was
+// fetched from, so that segments from different origins may be mixed.
+// bases may be nil, or hold nil entries, to leave URIs unchanged.
+//
+// Media initialization sections (EXT-X-MAP) and keys are restated at
+// each boundary as needed, so that the map and keys of one playlist
+// do not apply to the next. The result takes its media sequence
+// numbers from the first playlist; segments of later playlists
+// encrypted with implicit initialisation vectors are given explicit
+// ones, as their sequence numbers change. The target duration is the
+// longest of the playlists', and the version the highest, raised if
+// needed by the explicit vectors. Every playlist but the last must
+// have ended. The playlists are not modified.
+func Concat(playlists []*Playlist, bases []*url.URL) (*Playlist, error) {
+	if len(playlists) == 0 {
+		return nil, fmt.Errorf("no playlists")
+	}
+	if bases != nil && len(bases) != len(playlists) {
+		return nil, fmt.Errorf("%d bases for %d playlists", len(bases), len(playlists))
+	}
+	first := playlists[0]
+	out := &Playlist{
+		Start:                 first.Start,
+		Sequence:              first.Sequence,
+		DiscontinuitySequence: first.DiscontinuitySequence,
+		Type:                  first.Type,
+		IFramesOnly:           first.IFramesOnly,
+		IndependentSegments:   true,
+	}
+	// keys and m are in effect at the end of out.
+	var keys []Key
+	var m *Map
+	for i, p := range playlists {
+		if p.Kind() == KindMaster {
+			return nil, fmt.Errorf("playlist %d: master playlist", i)
+		} else if i < len(playlists)-1 && !p.End {
+			return nil, fmt.Errorf("playlist %d: not ended", i)
+		} else if p.IFramesOnly != first.IFramesOnly {
+			return nil, fmt.Errorf("playlist %d: i-frames only mismatch", i)
+		}
+		if p.Version > out.Version {
+			out.Version = p.Version
+		}
+		if p.TargetDuration > out.TargetDuration {
+			out.TargetDuration = p.TargetDuration
+		}
+		if p.Type != out.Type {
+			out.Type = PlaylistNone
+		}
+		if !p.IndependentSegments {
+			out.IndependentSegments = false
+		}
+		var base *url.URL
+		if bases != nil {
+			base = bases[i]
+		}
+		segs, err := copySegments(p.Segments, base)
+		if err != nil {
+			return nil, fmt.Errorf("playlist %d: %w", i, err)
+		}
+
+		// inKeys are in effect at the current segment of p.
+		var inKeys []Key
+		for j := range segs {
+			seg := &segs[j]
+			if len(seg.Keys) > 0 {
+				inKeys = seg.Keys
+			}
+			if j == 0 && len(out.Segments) > 0 {
+				seg.Discontinuity = true
+				if seg.Map == nil && m != nil {
+					return nil, fmt.Errorf("playlist %d: no map to follow map %s of previous playlist", i, m.URI)
+				}
+				if len(seg.Keys) == 0 && encrypting(keys) {
+					seg.Keys = []Key{{Method: EncryptMethodNone}}
+				}
+			}
+			seq := out.Sequence + int64(len(out.Segments))
+			if orig := p.Sequence + int64(j); seq != orig && encrypting(inKeys) && inKeys[0].IV == nil {
+				seg.Keys = explicitIVs(inKeys, orig)
+			}
+			if len(seg.Keys) > 0 {
+				keys = seg.Keys
+			}
+			if seg.Map != nil {
+				m = seg.Map
+			}
+			seg.SequenceNumber = seq
+			out.Segments = append(out.Segments, *seg)
+		}
+		if i == len(playlists)-1 {
+			out.End = p.End
+		}
+	}
+	if v := out.RequiredVersion(); out.Version > 0 && v > out.Version {
+		out.Version = v
+	}
+	out.ResolveKeys()
+	return out, nil
+}
+
+// copySegments returns a copy of segs, sharing no memory which may be
+// modified, with URIs resolved against base if not nil.
+// Recorded lines are dropped, as they no longer match the segments.
+func copySegments(segs []Segment, base *url.URL) ([]Segment, error) {
+	cp := make([]Segment, len(segs))
+	for i, seg := range segs {
+		seg.Keys = append([]Key(nil), seg.Keys...)
+		seg.Parts = append([]Part(nil), seg.Parts...)
+		if seg.Map != nil {
+			m := *seg.Map
+			seg.Map = &m
+		}
+		seg.lines = nil
+		seg.raw = nil
+		seg.keys = nil
+		seg.iv = nil
+		cp[i] = seg
+	}
+	if base != nil {
+		p := &Playlist{Segments: cp}
+		if err := p.ResolveURIs(base); err != nil {
+			return nil, err
+		}
+	}
+	return cp, nil
+}
+
+// encrypting reports whether keys, those in effect at a segment,
+// encrypt it.
+func encrypting(keys []Key) bool {
+	return len(keys) > 0 && keys[0].Method != EncryptMethodNone
+}
+
+// explicitIVs returns a copy of keys where any implicit initialisation
+// vector, derived from the media sequence number, is set explicitly
+// to that of seq.
+func explicitIVs(keys []Key, seq int64) []Key {
+	cp := make([]Key, len(keys))
+	for i, k := range keys {
+		if k.Method != EncryptMethodNone && k.IV == nil {
+			var iv [16]byte
+			binary.BigEndian.PutUint64(iv[8:], uint64(seq))
+			k.IV = &iv
+		}
+		cp[i] = k
+	}
+	return cp
+}
@@ -0,0 +1,107 @@
+package m3u8
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const preroll = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:5
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXTINF:5.000,
+ad/0.ts
+#EXTINF:5.000,
+ad/1.ts
+#EXT-X-ENDLIST
+`
+
+const content = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:10
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-MEDIA-SEQUENCE:20
+#EXT-X-KEY:METHOD=AES-128,URI="key.bin"
+#EXTINF:10.000,
+main/20.ts
+#EXTINF:10.000,
+main/21.ts
+#EXT-X-ENDLIST
+`
+
+func TestConcat(t *testing.T) {
+	ad, err := Decode(strings.NewReader(preroll))
+	if err != nil {
+		t.Fatal(err)
+	}
+	main, err := Decode(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	adBase, _ := url.Parse("https://ads.example.com/break/index.m3u8")
+	mainBase, _ := url.Parse("https://cdn.example.com/movie/index.m3u8")
+	p, err := Concat([]*Playlist{ad, main}, []*url.URL{adBase, mainBase})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Segments) != 4 {
+		t.Fatalf("got %d segments, want 4", len(p.Segments))
+	}
+	for i, seg := range p.Segments {
+		if want := i == 2; seg.Discontinuity != want {
+			t.Errorf("segment %d: discontinuity %t, want %t", i, seg.Discontinuity, want)
+		}
+		if seg.SequenceNumber != int64(i) {
+			t.Errorf("segment %d: sequence number %d", i, seg.SequenceNumber)
+		}
+	}
+	if p.TargetDuration != 10*time.Second || !p.End || p.Type != PlaylistVOD {
+		t.Errorf("got target duration %s, end %t, type %s", p.TargetDuration, p.End, p.Type)
+	}
+	if uri := p.Segments[1].URI; uri != "https://ads.example.com/break/ad/1.ts" {
+		t.Errorf("got ad segment uri %s", uri)
+	}
+	if uri := p.Segments[2].URI; uri != "https://cdn.example.com/movie/main/20.ts" {
+		t.Errorf("got content segment uri %s", uri)
+	}
+	// the content's implicit IVs come from its own sequence numbers.
+	for i, seg := range main.Segments {
+		if got, want := p.Segments[2+i].EffectiveIV(), seg.EffectiveIV(); !bytes.Equal(got, want) {
+			t.Errorf("segment %d: iv %x, want %x", 2+i, got, want)
+		}
+	}
+	if main.Segments[0].URI != "main/20.ts" || main.Segments[0].Discontinuity {
+		t.Errorf("input playlist modified")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "ad/1.ts\n#EXT-X-DISCONTINUITY\n") {
+		t.Errorf("no discontinuity at the seam")
+		t.Log("got:", buf.String())
+	}
+
+	// a post-roll after encrypted content must not inherit its key.
+	post, err := Decode(strings.NewReader(preroll))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = Concat([]*Playlist{main, post}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := p.Segments[2].EffectiveKeys(); len(keys) != 1 || keys[0].Method != EncryptMethodNone {
+		t.Errorf("post-roll has keys %v, want method NONE", keys)
+	}
+
+	live := *main
+	live.End = false
+	if _, err := Concat([]*Playlist{&live, post}, nil); err == nil {
+		t.Errorf("nil error concatenating after unended playlist")
+	}
+}
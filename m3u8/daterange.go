@@ -0,0 +1,288 @@
+package m3u8
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/untangledco/streaming/scte35"
+)
+
+func parseDateRange(items chan item) (*DateRange, error) {
+	attrs, err := parseAttributeList(items)
+	if err != nil {
+		return nil, err
+	}
+	var dr DateRange
+	for _, attr := range attrs {
+		switch attr.name {
+		case "ID", "CLASS":
+			s, err := unquote(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", attr.name, err)
+			}
+			if attr.name == "ID" {
+				dr.ID = s
+			} else {
+				dr.Class = s
+			}
+		case "START-DATE", "END-DATE":
+			s, err := unquote(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", attr.name, err)
+			}
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", attr.name, err)
+			}
+			if attr.name == "START-DATE" {
+				dr.Start = t
+			} else {
+				dr.End = t
+			}
+		case "DURATION", "PLANNED-DURATION":
+			seconds, err := strconv.ParseFloat(attr.value.val, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", attr.name, err)
+			} else if seconds < 0 {
+				return nil, fmt.Errorf("parse %s: negative duration %s", attr.name, attr.value.val)
+			}
+			d := time.Duration(seconds * float64(time.Second))
+			if attr.name == "DURATION" {
+				dr.Duration = d
+			} else {
+				dr.Planned = d
+			}
+		case "SCTE35-CMD", "SCTE35-OUT", "SCTE35-IN":
+			splice, err := parseSplice(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", attr.name, err)
+			}
+			switch attr.name {
+			case "SCTE35-CMD":
+				dr.CueCommand = splice
+			case "SCTE35-OUT":
+				dr.CueOut = splice
+			case "SCTE35-IN":
+				dr.CueIn = splice
+			}
+		case "END-ON-NEXT":
+			if attr.value.val != "YES" {
+				return nil, fmt.Errorf("parse %s: value must be YES, got %s", attr.name, attr.value.val)
+			}
+			dr.EndOnNext = true
+		default:
+			if !strings.HasPrefix(attr.name, "X-") {
+				return nil, fmt.Errorf("unknown attribute %s", attr.name)
+			}
+			v, err := parseClientAttribute(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", attr.name, err)
+			}
+			if dr.Custom == nil {
+				dr.Custom = make(map[string]any)
+			}
+			dr.Custom[attr.name] = v
+		}
+	}
+	return &dr, nil
+}
+
+// parseClientAttribute parses the value of an X-<client-attribute>
+// date range attribute. The type of the returned value is inferred from
+// the grammar described in RFC 8216 section 4.3.2.7: a string for a
+// quoted-string, a []byte for a hexadecimal-sequence, or a float64 for
+// a decimal-floating-point.
+func parseClientAttribute(s string) (any, error) {
+	if strings.HasPrefix(s, `"`) {
+		return unquote(s)
+	} else if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		b, err := hex.DecodeString(s[2:])
+		if err != nil {
+			return nil, fmt.Errorf("parse hexadecimal sequence: %w", err)
+		}
+		return b, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("not a quoted string, hexadecimal sequence or number: %s", s)
+	}
+	return f, nil
+}
+
+// formatClientAttributes returns the client-defined attributes in
+// custom formatted as attribute-value pairs, sorted by name.
+// Values must be of the types returned by parseClientAttribute.
+func formatClientAttributes(custom map[string]any) ([]string, error) {
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	attrs := make([]string, len(names))
+	for i, name := range names {
+		if !strings.HasPrefix(name, "X-") {
+			return nil, fmt.Errorf("client attribute %s: missing X- prefix", name)
+		}
+		var value string
+		switch v := custom[name].(type) {
+		case string:
+			value = strconv.Quote(v)
+		case []byte:
+			value = "0x" + hex.EncodeToString(v)
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("client attribute %s: unsupported type %T", name, v)
+		}
+		attrs[i] = name + "=" + value
+	}
+	return attrs, nil
+}
+
+func parseSplice(s string) (*scte35.Splice, error) {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return nil, fmt.Errorf("missing 0x prefix")
+	}
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, err
+	}
+	return scte35.Decode(b)
+}
+
+// resolveEndOnNext sets the ImpliedEnd of each date range with
+// EndOnNext set to the start of the following date range with the
+// same Class, as specified in RFC 8216 section 4.3.2.7.1.
+// Ranges with no Class are left unresolved, as END-ON-NEXT requires
+// one and they would otherwise end at any unrelated range without.
+func resolveEndOnNext(segments []Segment) {
+	for i := range segments {
+		dr := segments[i].DateRange
+		if dr == nil || !dr.EndOnNext || !dr.End.IsZero() || dr.Class == "" {
+			continue
+		}
+		for _, seg := range segments[i+1:] {
+			if seg.DateRange != nil && seg.DateRange.Class == dr.Class {
+				dr.ImpliedEnd = seg.DateRange.Start
+				break
+			}
+		}
+	}
+}
+
+// DateRanges returns the date ranges of p's segments in playlist order.
+func (p *Playlist) DateRanges() []*DateRange {
+	var ranges []*DateRange
+	for i := range p.Segments {
+		if p.Segments[i].DateRange != nil {
+			ranges = append(ranges, p.Segments[i].DateRange)
+		}
+	}
+	return ranges
+}
+
+// EndDate returns the end of the date range: its End, its Start plus
+// Duration, or its ImpliedEnd, in that order of preference, so that
+// the end of a range with only a DURATION attribute is known.
+// The boolean is false if the end is unknown.
+func (dr *DateRange) EndDate() (time.Time, bool) {
+	switch {
+	case !dr.End.IsZero():
+		return dr.End, true
+	case dr.Duration > 0:
+		return dr.Start.Add(dr.Duration), true
+	case !dr.ImpliedEnd.IsZero():
+		return dr.ImpliedEnd, true
+	}
+	return time.Time{}, false
+}
+
+// OverlapError describes two date ranges of the same class whose
+// intervals intersect, which usually indicates a packaging bug.
+type OverlapError struct {
+	Class string
+	IDs   [2]string
+}
+
+func (e *OverlapError) Error() string {
+	return fmt.Sprintf("date ranges %s and %s of class %q overlap", e.IDs[0], e.IDs[1], e.Class)
+}
+
+// EndDateTolerance is the largest difference between the END-DATE
+// of a date range and its START-DATE plus DURATION accepted by
+// CheckDateRanges, allowing for dates written to the millisecond.
+const EndDateTolerance = time.Millisecond
+
+// EndDateError describes a date range whose END-DATE is not its
+// START-DATE plus its DURATION, as RFC 8216 section 4.3.2.7 requires.
+type EndDateError struct {
+	ID string
+	// End is from the END-DATE attribute, and DurationEnd the
+	// START-DATE plus DURATION.
+	End         time.Time
+	DurationEnd time.Time
+}
+
+func (e *EndDateError) Error() string {
+	return fmt.Sprintf("date range %s: end date %s differs from start plus duration %s by %s",
+		e.ID, e.End.Format(time.RFC3339Nano), e.DurationEnd.Format(time.RFC3339Nano), e.End.Sub(e.DurationEnd))
+}
+
+// CheckDateRanges returns an *EndDateError for each date range in p
+// with inconsistent END-DATE and DURATION attributes, differing by
+// more than EndDateTolerance, and an *OverlapError for each pair of date
+// ranges in p with the same Class whose intervals intersect.
+// A range ending exactly when another starts, as with EndOnNext,
+// does not overlap it. A range of unknown end is treated as lasting
+// an instant, so overlaps only the ranges its start falls within.
+// Ranges with the same ID are the same range, as specified in
+// RFC 8216 section 4.3.2.7, and so never overlap. Ranges with no
+// Class are not checked.
+func (p *Playlist) CheckDateRanges() []error {
+	ranges := p.DateRanges()
+	var errs []error
+	for _, dr := range ranges {
+		if dr.End.IsZero() || dr.Duration == 0 {
+			continue
+		}
+		end := dr.Start.Add(dr.Duration)
+		if d := dr.End.Sub(end); d > EndDateTolerance || d < -EndDateTolerance {
+			errs = append(errs, &EndDateError{ID: dr.ID, End: dr.End, DurationEnd: end})
+		}
+	}
+	for i, a := range ranges {
+		if a.Class == "" {
+			continue
+		}
+		for _, b := range ranges[i+1:] {
+			if a.Class != b.Class || a.ID == b.ID {
+				continue
+			}
+			if overlaps(a, b) {
+				errs = append(errs, &OverlapError{Class: a.Class, IDs: [2]string{a.ID, b.ID}})
+			}
+		}
+	}
+	return errs
+}
+
+func overlaps(a, b *DateRange) bool {
+	aend, ok := a.EndDate()
+	if !ok {
+		aend = a.Start
+	}
+	bend, ok := b.EndDate()
+	if !ok {
+		bend = b.Start
+	}
+	if aend.Equal(a.Start) {
+		return !a.Start.Before(b.Start) && a.Start.Before(bend)
+	} else if bend.Equal(b.Start) {
+		return !b.Start.Before(a.Start) && b.Start.Before(aend)
+	}
+	return a.Start.Before(bend) && b.Start.Before(aend)
+}
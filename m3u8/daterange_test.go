@@ -0,0 +1,181 @@
+package m3u8
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const endOnNextPlaylist = `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXT-X-DATERANGE:ID="break1",CLASS="com.example.ad",START-DATE="2024-07-16T01:00:00Z",END-ON-NEXT=YES
+#EXTINF:6.000,
+001.ts
+#EXT-X-DATERANGE:ID="other",CLASS="com.example.blackout",START-DATE="2024-07-16T01:00:03Z",END-DATE="2024-07-16T01:00:09Z"
+#EXTINF:6.000,
+002.ts
+#EXT-X-DATERANGE:ID="break2",CLASS="com.example.ad",START-DATE="2024-07-16T01:00:12.5Z",END-ON-NEXT=YES
+#EXTINF:6.000,
+003.ts
+`
+
+func TestEndOnNext(t *testing.T) {
+	p, err := Decode(strings.NewReader(endOnNextPlaylist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Segments) != 3 {
+		t.Fatalf("got %d segments, want 3", len(p.Segments))
+	}
+	first := p.Segments[0].DateRange
+	second := p.Segments[2].DateRange
+	if !first.EndOnNext || !second.EndOnNext {
+		t.Fatalf("end-on-next not parsed")
+	}
+	if !first.ImpliedEnd.Equal(second.Start) {
+		t.Errorf("first range implied end %s, want start of second %s", first.ImpliedEnd, second.Start)
+	}
+	if !second.ImpliedEnd.IsZero() {
+		t.Errorf("last range has implied end %s, want zero time", second.ImpliedEnd)
+	}
+	other := p.Segments[1].DateRange
+	want := time.Date(2024, time.July, 16, 1, 0, 9, 0, time.UTC)
+	if !other.End.Equal(want) || !other.ImpliedEnd.IsZero() {
+		t.Errorf("explicit end date changed: end %s, implied end %s", other.End, other.ImpliedEnd)
+	}
+
+	// END-ON-NEXT requires a class; ranges without one are not
+	// ended by the next unrelated range which also lacks one.
+	classless := strings.ReplaceAll(endOnNextPlaylist, `CLASS="com.example.ad",`, "")
+	classless = strings.Replace(classless, `CLASS="com.example.blackout",`, "", 1)
+	p, err = Decode(strings.NewReader(classless))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := p.Segments[0].DateRange; !first.ImpliedEnd.IsZero() {
+		t.Errorf("range without class has implied end %s, want zero time", first.ImpliedEnd)
+	}
+}
+
+func TestDateRangeClientAttributes(t *testing.T) {
+	const plist = `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXT-X-DATERANGE:ID="ad",START-DATE="2024-07-16T01:00:00Z",X-COM-EXAMPLE-AD-ID="XYZ123",X-COM-EXAMPLE-CUE=0x2aFF,X-COM-EXAMPLE-RATING=4.5
+#EXTINF:6.000,
+001.ts
+`
+	p, err := Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom := p.Segments[0].DateRange.Custom
+	t.Log("got:", custom)
+	if s, ok := custom["X-COM-EXAMPLE-AD-ID"].(string); !ok || s != "XYZ123" {
+		t.Errorf("quoted attribute: got %#v, want string %q", custom["X-COM-EXAMPLE-AD-ID"], "XYZ123")
+	}
+	if b, ok := custom["X-COM-EXAMPLE-CUE"].([]byte); !ok || string(b) != "\x2a\xff" {
+		t.Errorf("hex attribute: got %#v, want bytes 2aff", custom["X-COM-EXAMPLE-CUE"])
+	}
+	if f, ok := custom["X-COM-EXAMPLE-RATING"].(float64); !ok || f != 4.5 {
+		t.Errorf("numeric attribute: got %#v, want float64 4.5", custom["X-COM-EXAMPLE-RATING"])
+	}
+
+	bad := strings.Replace(plist, "0x2aFF", "0x2aF", 1)
+	if _, err := Decode(strings.NewReader(bad)); err == nil {
+		t.Errorf("no error decoding odd-length hexadecimal sequence")
+	}
+}
+
+func TestCheckDateRanges(t *testing.T) {
+	const plist = `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXT-X-DATERANGE:ID="ad1",CLASS="com.example.ad",START-DATE="2024-07-16T01:00:00Z",DURATION=30
+#EXTINF:6.000,
+001.ts
+#EXT-X-DATERANGE:ID="ad2",CLASS="com.example.ad",START-DATE="2024-07-16T01:00:20Z",END-DATE="2024-07-16T01:00:50Z"
+#EXTINF:6.000,
+002.ts
+#EXT-X-DATERANGE:ID="blackout",CLASS="com.example.blackout",START-DATE="2024-07-16T01:00:10Z",END-ON-NEXT=YES
+#EXTINF:6.000,
+003.ts
+#EXT-X-DATERANGE:ID="ad3",CLASS="com.example.ad",START-DATE="2024-07-16T01:01:00Z",DURATION=15
+#EXTINF:6.000,
+004.ts
+#EXT-X-DATERANGE:ID="blackout2",CLASS="com.example.blackout",START-DATE="2024-07-16T01:01:00Z",DURATION=10
+#EXTINF:6.000,
+005.ts
+`
+	var warnings []error
+	dec := Decoder{OnWarning: func(err error) { warnings = append(warnings, err) }}
+	p, err := dec.Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.DateRanges()) != 5 {
+		t.Fatalf("got %d date ranges, want 5", len(p.DateRanges()))
+	}
+	errs := p.CheckDateRanges()
+	if len(errs) != 1 {
+		t.Fatalf("got %d overlaps, want 1: %v", len(errs), errs)
+	}
+	var overlap *OverlapError
+	if !errors.As(errs[0], &overlap) {
+		t.Fatalf("got error %T, want %T", errs[0], overlap)
+	}
+	want := OverlapError{Class: "com.example.ad", IDs: [2]string{"ad1", "ad2"}}
+	if *overlap != want {
+		t.Errorf("got overlap %+v, want %+v", *overlap, want)
+	}
+	if len(warnings) != 1 || warnings[0].Error() != errs[0].Error() {
+		t.Errorf("decoder warnings %v, want %v", warnings, errs)
+	}
+}
+
+func TestDateRangeEndDate(t *testing.T) {
+	const plist = `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXT-X-DATERANGE:ID="duration",START-DATE="2024-07-16T01:00:00Z",DURATION=30.5
+#EXTINF:6.000,
+001.ts
+#EXT-X-DATERANGE:ID="both",START-DATE="2024-07-16T01:01:00Z",END-DATE="2024-07-16T01:01:10.0004Z",DURATION=10
+#EXTINF:6.000,
+002.ts
+#EXT-X-DATERANGE:ID="inconsistent",START-DATE="2024-07-16T01:02:00Z",END-DATE="2024-07-16T01:02:20Z",DURATION=15
+#EXTINF:6.000,
+003.ts
+#EXT-X-DATERANGE:ID="open",START-DATE="2024-07-16T01:03:00Z"
+#EXTINF:6.000,
+004.ts
+`
+	var warnings []error
+	dec := Decoder{OnWarning: func(err error) { warnings = append(warnings, err) }}
+	p, err := dec.Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ranges := p.DateRanges()
+	start := time.Date(2024, 7, 16, 1, 0, 0, 0, time.UTC)
+	end, ok := ranges[0].EndDate()
+	if want := start.Add(30500 * time.Millisecond); !ok || !end.Equal(want) {
+		t.Errorf("duration-only end date = %s, %t; want %s", end, ok, want)
+	}
+	end, ok = ranges[2].EndDate()
+	if want := start.Add(2*time.Minute + 20*time.Second); !ok || !end.Equal(want) {
+		t.Errorf("end date preferred over duration: got %s, want %s", end, want)
+	}
+	if _, ok := ranges[3].EndDate(); ok {
+		t.Errorf("open-ended range has an end date")
+	}
+
+	if len(warnings) != 1 {
+		t.Fatalf("got %d warnings, want 1: %v", len(warnings), warnings)
+	}
+	var edErr *EndDateError
+	if !errors.As(warnings[0], &edErr) {
+		t.Fatalf("got warning %T, want %T", warnings[0], edErr)
+	}
+	if edErr.ID != "inconsistent" || edErr.End.Sub(edErr.DurationEnd) != 5*time.Second {
+		t.Errorf("unexpected error %v", edErr)
+	}
+}
@@ -0,0 +1,84 @@
+package m3u8
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotPlaylist is returned by DetectKind if its input does not
+// begin with the EXTM3U tag.
+var ErrNotPlaylist = errors.New("not a playlist")
+
+// masterTags are the tags which may only appear in a master playlist.
+var masterTags = map[string]bool{
+	tagVariant:                  true,
+	"#EXT-X-I-FRAME-STREAM-INF": true,
+	tagRendition:                true,
+	tagSessionData:              true,
+	"#EXT-X-SESSION-KEY":        true,
+	tagContentSteering:          true,
+}
+
+// mediaTags are the tags, besides segmentTags, which may only appear
+// in a media playlist.
+var mediaTags = map[string]bool{
+	tagTargetDuration:        true,
+	tagMediaSequence:         true,
+	tagDiscontinuitySequence: true,
+	tagEndList:               true,
+	tagPlaylistType:          true,
+	tagIFramesOnly:           true,
+	tagServerControl:         true,
+}
+
+// DetectKind reports whether r holds a media or master playlist
+// without decoding it. After checking the first non-blank line is the
+// EXTM3U tag, lines are read only until one found exclusively in one
+// kind of playlist, such as EXT-X-STREAM-INF or EXTINF. Input with no
+// such lines is a media playlist, consistent with Playlist.Kind.
+//
+// The returned reader replays the bytes consumed from r followed by
+// the remainder of r, so the playlist may then be decoded in full.
+// If the input is not a playlist, the error wraps ErrNotPlaylist.
+func DetectKind(r io.Reader) (Kind, io.Reader, error) {
+	consumed := &bytes.Buffer{}
+	replay := func() io.Reader { return io.MultiReader(consumed, r) }
+	sc := bufio.NewScanner(io.TeeReader(r, consumed))
+
+	var head bool
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if !head {
+			if line != tagHead {
+				return KindMedia, replay(), fmt.Errorf("%w: first line %q", ErrNotPlaylist, line)
+			}
+			head = true
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			// Only media playlists list segment URIs before any
+			// variant tag; a variant's URI follows its tag.
+			return KindMedia, replay(), nil
+		}
+		tag, _, _ := strings.Cut(line, ":")
+		if masterTags[tag] {
+			return KindMaster, replay(), nil
+		} else if segmentTags[tag] || mediaTags[tag] {
+			return KindMedia, replay(), nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return KindMedia, replay(), err
+	}
+	if !head {
+		return KindMedia, replay(), fmt.Errorf("%w: no %s tag", ErrNotPlaylist, tagHead)
+	}
+	return KindMedia, replay(), nil
+}
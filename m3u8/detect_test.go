@@ -0,0 +1,70 @@
+package m3u8
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestDetectKind(t *testing.T) {
+	var cases = []struct {
+		name string
+		want Kind
+	}{
+		{"master.m3u8", KindMaster},
+		{"media.m3u8", KindMedia},
+		{"bbb.m3u8", KindMedia},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := os.ReadFile("testdata/" + tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			kind, r, err := DetectKind(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kind != tt.want {
+				t.Errorf("got kind %s, want %s", kind, tt.want)
+			}
+			replayed, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(replayed, b) {
+				t.Errorf("replayed input differs from original")
+			}
+			p, err := Decode(bytes.NewReader(replayed))
+			if err != nil {
+				t.Fatalf("decode replayed input: %v", err)
+			}
+			if p.Kind() != kind {
+				t.Errorf("detected kind %s, decoded playlist has kind %s", kind, p.Kind())
+			}
+		})
+	}
+}
+
+func TestDetectNotPlaylist(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"\n\n",
+		"<html><body>hello</body></html>\n",
+		"#EXT-X-VERSION:3\n#EXTM3U\n",
+	} {
+		_, r, err := DetectKind(bytes.NewReader([]byte(s)))
+		if !errors.Is(err, ErrNotPlaylist) {
+			t.Errorf("%q: got error %v, want %v", s, err, ErrNotPlaylist)
+		}
+		replayed, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(replayed) != s {
+			t.Errorf("%q: replayed %q", s, replayed)
+		}
+	}
+}
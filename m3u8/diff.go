@@ -0,0 +1,43 @@
+package m3u8
+
+// PlaylistDiff describes the changes between two successive loads
+// of a media playlist. Segments are identified by their media
+// sequence number: the playlist's Sequence plus the segment's index.
+type PlaylistDiff struct {
+	// Media sequence numbers of segments only in the new playlist.
+	Added []int64
+	// Media sequence numbers of segments which slid out of the
+	// playlist window.
+	Removed []int64
+	// TargetDuration is true if the target duration changed.
+	TargetDuration bool
+	// Ended is true if the new playlist has EXT-X-ENDLIST but
+	// the old playlist did not.
+	Ended bool
+}
+
+// Changed reports whether the playlist changed at all.
+func (d PlaylistDiff) Changed() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || d.TargetDuration || d.Ended
+}
+
+// Diff reports the changes from the media playlist old to new,
+// such as from reloading a live playlist.
+func Diff(old, new *Playlist) PlaylistDiff {
+	var d PlaylistDiff
+	oldFirst, oldEnd := old.Sequence, old.Sequence+int64(len(old.Segments))
+	newFirst, newEnd := new.Sequence, new.Sequence+int64(len(new.Segments))
+	for n := oldFirst; n < oldEnd; n++ {
+		if n < newFirst || n >= newEnd {
+			d.Removed = append(d.Removed, n)
+		}
+	}
+	for n := newFirst; n < newEnd; n++ {
+		if n < oldFirst || n >= oldEnd {
+			d.Added = append(d.Added, n)
+		}
+	}
+	d.TargetDuration = old.TargetDuration != new.TargetDuration
+	d.Ended = !old.End && new.End
+	return d
+}
@@ -0,0 +1,61 @@
+package m3u8
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const liveSnapshot = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-DISCONTINUITY-SEQUENCE:4
+#EXTINF:6.000,
+100.ts
+#EXTINF:6.000,
+101.ts
+#EXTINF:6.000,
+102.ts
+`
+
+const liveSnapshotNext = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:102
+#EXT-X-DISCONTINUITY-SEQUENCE:5
+#EXTINF:6.000,
+102.ts
+#EXT-X-DISCONTINUITY
+#EXTINF:6.000,
+ad0.ts
+#EXTINF:6.000,
+ad1.ts
+#EXT-X-ENDLIST
+`
+
+func TestDiff(t *testing.T) {
+	old, err := Decode(strings.NewReader(liveSnapshot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, err := Decode(strings.NewReader(liveSnapshotNext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if new.DiscontinuitySequence != 5 || !new.Segments[1].Discontinuity {
+		t.Fatalf("discontinuity not parsed")
+	}
+	want := PlaylistDiff{
+		Added:   []int64{103, 104},
+		Removed: []int64{100, 101},
+		Ended:   true,
+	}
+	got := Diff(old, new)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+	if Diff(old, old).Changed() {
+		t.Errorf("playlist reported changed compared with itself")
+	}
+}
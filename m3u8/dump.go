@@ -0,0 +1,194 @@
+package m3u8
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dump returns a human-readable, indented description of the
+// playlist for debugging, such as while diagnosing why a playlist
+// was not decoded as expected. Unlike Encode, Dump shows fields
+// resolved while decoding, such as each segment's sequence number
+// and the keys in effect. Its output is not a valid playlist and
+// its format may change.
+func (p *Playlist) Dump() string {
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "%s playlist\n", p.Kind())
+	dumpField(buf, 1, "version", p.Version)
+	if p.IndependentSegments {
+		dumpField(buf, 1, "independent segments", true)
+	}
+	if p.Start != nil {
+		dumpField(buf, 1, "start offset", p.Start.Offset)
+		dumpField(buf, 1, "start precise", p.Start.Precise)
+	}
+	if p.Kind() == KindMaster {
+		dumpMaster(buf, p)
+		return buf.String()
+	}
+
+	dumpField(buf, 1, "target duration", p.TargetDuration)
+	dumpField(buf, 1, "sequence", p.Sequence)
+	dumpField(buf, 1, "discontinuity sequence", p.DiscontinuitySequence)
+	if p.Type != 0 {
+		dumpField(buf, 1, "type", p.Type)
+	}
+	if p.IFramesOnly {
+		dumpField(buf, 1, "i-frames only", true)
+	}
+	if sc := p.ServerControl; sc != nil {
+		dumpField(buf, 1, "server control", strings.TrimPrefix(sc.String(), tagServerControl+":"))
+	}
+	dumpField(buf, 1, "end", p.End)
+	dumpField(buf, 1, "segments", len(p.Segments))
+	for i := range p.Segments {
+		dumpSegment(buf, i, &p.Segments[i])
+	}
+	return buf.String()
+}
+
+// dumpField writes a line labelling value, indented by depth.
+func dumpField(buf *strings.Builder, depth int, label string, value any) {
+	fmt.Fprintf(buf, "%s%s: %v\n", strings.Repeat("  ", depth), label, value)
+}
+
+func dumpSegment(buf *strings.Builder, i int, seg *Segment) {
+	fmt.Fprintf(buf, "  segment %d\n", i)
+	dumpField(buf, 2, "uri", seg.URI)
+	dumpField(buf, 2, "sequence number", seg.SequenceNumber)
+	dumpField(buf, 2, "duration", seg.Duration)
+	if seg.Title != "" {
+		dumpField(buf, 2, "title", fmt.Sprintf("%q", seg.Title))
+	}
+	if seg.Range != [2]int{0, 0} {
+		dumpField(buf, 2, "byte range", seg.Range)
+	}
+	var flags []string
+	if seg.Discontinuity {
+		flags = append(flags, "discontinuity")
+	}
+	if seg.Gap {
+		flags = append(flags, "gap")
+	}
+	if len(flags) > 0 {
+		dumpField(buf, 2, "flags", strings.Join(flags, ", "))
+	}
+	if !seg.DateTime.IsZero() {
+		dumpField(buf, 2, "date time", seg.DateTime.Format(time.RFC3339Nano))
+	}
+	for _, k := range seg.Keys {
+		dumpField(buf, 2, "key", dumpKey(k))
+	}
+	for _, k := range seg.EffectiveKeys() {
+		dumpField(buf, 2, "effective key", dumpKey(k))
+	}
+	if iv := seg.EffectiveIV(); iv != nil {
+		dumpField(buf, 2, "effective iv", "0x"+hex.EncodeToString(iv))
+	}
+	if seg.Map != nil {
+		m := seg.Map.URI
+		if seg.Map.ByteRange != [2]int{0, 0} {
+			m += " range " + seg.Map.ByteRange.String()
+		}
+		dumpField(buf, 2, "map", m)
+		if k := seg.Map.EffectiveKey(); k != nil {
+			dumpField(buf, 3, "effective key", dumpKey(*k))
+		}
+	}
+	if dr := seg.DateRange; dr != nil {
+		dumpField(buf, 2, "date range", dr.ID)
+		if dr.Class != "" {
+			dumpField(buf, 3, "class", dr.Class)
+		}
+		dumpField(buf, 3, "start", dr.Start.Format(time.RFC3339Nano))
+		if end, ok := dr.EndDate(); ok {
+			dumpField(buf, 3, "end", end.Format(time.RFC3339Nano))
+		}
+	}
+	for j, part := range seg.Parts {
+		flags := ""
+		if part.Independent {
+			flags += " independent"
+		}
+		if part.Gap {
+			flags += " gap"
+		}
+		dumpField(buf, 2, fmt.Sprintf("part %d", j), fmt.Sprintf("%s %s%s", part.URI, part.Duration, flags))
+	}
+	tags := make([]string, 0, len(seg.Custom))
+	for tag := range seg.Custom {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		dumpField(buf, 2, tag, strings.Join(seg.Custom[tag], ", "))
+	}
+}
+
+func dumpKey(k Key) string {
+	s := k.Method.String()
+	if k.URI != "" {
+		s += " " + k.URI
+	}
+	if k.Format != "" {
+		s += " format " + k.Format
+	}
+	return s
+}
+
+func dumpMaster(buf *strings.Builder, p *Playlist) {
+	for i, r := range p.Media {
+		fmt.Fprintf(buf, "  rendition %d\n", i)
+		dumpField(buf, 2, "type", r.Type)
+		dumpField(buf, 2, "group", r.Group)
+		dumpField(buf, 2, "name", r.Name)
+		if r.Language != "" {
+			dumpField(buf, 2, "language", r.Language)
+		}
+		if r.URI != "" {
+			dumpField(buf, 2, "uri", r.URI)
+		}
+		dumpField(buf, 2, "default", r.Default)
+	}
+	for i, v := range p.Variants {
+		fmt.Fprintf(buf, "  variant %d\n", i)
+		dumpField(buf, 2, "uri", v.URI)
+		dumpField(buf, 2, "bandwidth", v.Bandwidth)
+		if len(v.Codecs) > 0 {
+			dumpField(buf, 2, "codecs", strings.Join(v.Codecs, ", "))
+		}
+		if v.Resolution != [2]int{0, 0} {
+			dumpField(buf, 2, "resolution", fmt.Sprintf("%dx%d", v.Resolution[0], v.Resolution[1]))
+		}
+		groups := []struct {
+			label string
+			id    string
+			none  bool
+			rends []Rendition
+		}{
+			{"audio", v.Audio, false, v.AudioRenditions()},
+			{"video", v.Video, false, v.VideoRenditions()},
+			{"subtitles", v.Subtitles, false, v.SubtitleRenditions()},
+			{"closed captions", v.ClosedCaptions, v.NoClosedCaptions, v.ClosedCaptionRenditions()},
+		}
+		for _, g := range groups {
+			if g.none {
+				dumpField(buf, 2, g.label, "NONE")
+			} else if g.id != "" {
+				dumpField(buf, 2, g.label, fmt.Sprintf("%q (%d renditions)", g.id, len(g.rends)))
+			}
+		}
+	}
+	for _, sd := range p.SessionData {
+		dumpField(buf, 1, "session data", sd.ID)
+	}
+	if p.SessionKey != nil {
+		dumpField(buf, 1, "session key", dumpKey(*p.SessionKey))
+	}
+	if p.Steering != nil {
+		dumpField(buf, 1, "content steering", p.Steering.ServerURI)
+	}
+}
@@ -0,0 +1,79 @@
+package m3u8
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	const media = `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:10
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-KEY:METHOD=AES-128,URI="key.bin"
+#EXT-X-MAP:URI="init.mp4"
+#EXTINF:9.009,Opening
+0.ts
+#EXT-X-DISCONTINUITY
+#EXTINF:10.000
+1.ts
+#EXT-X-ENDLIST
+`
+	p, err := Decode(strings.NewReader(media))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump := p.Dump()
+	for _, want := range []string{
+		"media playlist\n",
+		"  target duration: 10s\n",
+		"  sequence: 100\n",
+		"  end: true\n",
+		"  segment 0\n",
+		"    uri: 0.ts\n",
+		"    duration: 9.009s\n",
+		`    title: "Opening"` + "\n",
+		"    effective key: AES-128 key.bin\n",
+		"    effective iv: 0x00000000000000000000000000000065\n",
+		"    map: init.mp4\n",
+		"      effective key: AES-128 key.bin\n",
+		"    sequence number: 101\n",
+		"    flags: discontinuity\n",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("dump missing %q", want)
+		}
+	}
+	if t.Failed() {
+		t.Log("got:", dump)
+	}
+
+	f, err := os.Open("testdata/tos.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	p, err = Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump = p.Dump()
+	for _, want := range []string{
+		"master playlist\n",
+		"  rendition 0\n",
+		"    type: AUDIO\n",
+		"  variant 0\n",
+		"    bandwidth: 6725464\n",
+		`    audio: "audio" (1 renditions)` + "\n",
+		`    subtitles: "default-text-group" (2 renditions)` + "\n",
+		"    closed captions: NONE\n",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("dump missing %q", want)
+		}
+	}
+	if t.Failed() {
+		t.Log("got:", dump)
+	}
+}
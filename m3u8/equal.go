@@ -0,0 +1,174 @@
+package m3u8
+
+import (
+	"reflect"
+	"time"
+)
+
+// DurationTolerance is the largest difference between two durations
+// for them to be considered equal by Segment.Equal and Playlist.Equal.
+// Durations are written as decimal seconds, so a duration decoded
+// from a playlist may differ from the one encoded by up to a
+// microsecond.
+const DurationTolerance = time.Microsecond
+
+func durationsEqual(a, b time.Duration) bool {
+	d := a - b
+	if d < 0 {
+		d = -d
+	}
+	return d <= DurationTolerance
+}
+
+// Equal reports whether seg and other hold the same segment.
+// All exported fields are compared, including the segment's Keys, Map,
+// DateRange and Parts. Durations are compared within DurationTolerance
+// and times with time.Time.Equal. Nil and empty slices and maps are
+// considered equal. State resolved by Decode or Playlist.ResolveKeys,
+// such as the keys in effect, is ignored.
+func (seg Segment) Equal(other Segment) bool {
+	if seg.URI != other.URI || seg.Title != other.Title || seg.Range != other.Range {
+		return false
+	}
+	if seg.Discontinuity != other.Discontinuity || seg.Gap != other.Gap || seg.SequenceNumber != other.SequenceNumber {
+		return false
+	}
+	if !durationsEqual(seg.Duration, other.Duration) || !seg.DateTime.Equal(other.DateTime) {
+		return false
+	}
+	if len(seg.Keys) != len(other.Keys) {
+		return false
+	}
+	for i := range seg.Keys {
+		if !keysEqual(&seg.Keys[i], &other.Keys[i]) {
+			return false
+		}
+	}
+	if len(seg.Parts) != len(other.Parts) {
+		return false
+	}
+	for i := range seg.Parts {
+		a, b := seg.Parts[i], other.Parts[i]
+		if !durationsEqual(a.Duration, b.Duration) {
+			return false
+		}
+		a.Duration, b.Duration = 0, 0
+		if a != b {
+			return false
+		}
+	}
+	if !mapsEqual(seg.Map, other.Map) || !dateRangesEqual(seg.DateRange, other.DateRange) {
+		return false
+	}
+	return len(seg.Custom) == 0 && len(other.Custom) == 0 || reflect.DeepEqual(seg.Custom, other.Custom)
+}
+
+func keysEqual(a, b *Key) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Method != b.Method || a.URI != b.URI || a.Format != b.Format {
+		return false
+	}
+	if len(a.FormatVersions) != len(b.FormatVersions) {
+		return false
+	}
+	for i := range a.FormatVersions {
+		if a.FormatVersions[i] != b.FormatVersions[i] {
+			return false
+		}
+	}
+	if a.IV == nil || b.IV == nil {
+		return a.IV == b.IV
+	}
+	return *a.IV == *b.IV
+}
+
+func mapsEqual(a, b *Map) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.URI == b.URI && a.ByteRange == b.ByteRange
+}
+
+func dateRangesEqual(a, b *DateRange) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.ID != b.ID || a.Class != b.Class || a.EndOnNext != b.EndOnNext {
+		return false
+	}
+	if !a.Start.Equal(b.Start) || !a.End.Equal(b.End) || !a.ImpliedEnd.Equal(b.ImpliedEnd) {
+		return false
+	}
+	if !durationsEqual(a.Duration, b.Duration) || !durationsEqual(a.Planned, b.Planned) {
+		return false
+	}
+	if !reflect.DeepEqual(a.CueCommand, b.CueCommand) || !reflect.DeepEqual(a.CueOut, b.CueOut) || !reflect.DeepEqual(a.CueIn, b.CueIn) {
+		return false
+	}
+	return len(a.Custom) == 0 && len(b.Custom) == 0 || reflect.DeepEqual(a.Custom, b.Custom)
+}
+
+// Equal reports whether p and q hold the same playlist.
+// Segments are compared with Segment.Equal, and the target durations
+// of media playlists within DurationTolerance. The renditions, variants
+// and other tags of master playlists must be deeply equal.
+// Comments kept by Decoder.PreserveOrder and renditions resolved by
+// ResolveRenditions are ignored.
+func (p *Playlist) Equal(q *Playlist) bool {
+	if p == nil || q == nil {
+		return p == q
+	}
+	if p.Version != q.Version || p.IndependentSegments != q.IndependentSegments {
+		return false
+	}
+	if p.Start == nil || q.Start == nil {
+		if p.Start != q.Start {
+			return false
+		}
+	} else if *p.Start != *q.Start {
+		return false
+	}
+	if !durationsEqual(p.TargetDuration, q.TargetDuration) || p.Sequence != q.Sequence || p.DiscontinuitySequence != q.DiscontinuitySequence {
+		return false
+	}
+	if p.End != q.End || p.Type != q.Type || p.IFramesOnly != q.IFramesOnly {
+		return false
+	}
+	if !reflect.DeepEqual(p.ServerControl, q.ServerControl) {
+		return false
+	}
+	if len(p.Segments) != len(q.Segments) {
+		return false
+	}
+	for i := range p.Segments {
+		if !p.Segments[i].Equal(q.Segments[i]) {
+			return false
+		}
+	}
+	if !keysEqual(p.SessionKey, q.SessionKey) || !reflect.DeepEqual(p.Steering, q.Steering) {
+		return false
+	}
+	if len(p.Media) != 0 || len(q.Media) != 0 {
+		if !reflect.DeepEqual(p.Media, q.Media) {
+			return false
+		}
+	}
+	if len(p.Variants) != len(q.Variants) {
+		return false
+	}
+	for i := range p.Variants {
+		a, b := p.Variants[i], q.Variants[i]
+		// Ignore renditions resolved by ResolveRenditions.
+		a.audio, a.video, a.subtitles, a.captions = nil, nil, nil, nil
+		b.audio, b.video, b.subtitles, b.captions = nil, nil, nil, nil
+		if !reflect.DeepEqual(a, b) {
+			return false
+		}
+	}
+	if len(p.SessionData) != 0 || len(q.SessionData) != 0 {
+		return reflect.DeepEqual(p.SessionData, q.SessionData)
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package m3u8
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSegmentEqual(t *testing.T) {
+	iv := [16]byte{1}
+	seg := Segment{
+		URI:      "a.ts",
+		Duration: 9009 * time.Millisecond,
+		Keys:     []Key{{Method: EncryptMethodAES128, URI: "key", IV: &iv}},
+		Map:      &Map{URI: "init.mp4"},
+		DateRange: &DateRange{
+			ID:       "ad",
+			Start:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			Duration: 30 * time.Second,
+		},
+		Parts: []Part{{URI: "a.0.ts", Duration: 2 * time.Second}},
+	}
+	var cases = []struct {
+		name  string
+		edit  func(s *Segment)
+		equal bool
+	}{
+		{"same", func(s *Segment) {}, true},
+		{"sub-microsecond duration", func(s *Segment) { s.Duration -= 180 * time.Nanosecond }, true},
+		{"microsecond duration", func(s *Segment) { s.Duration += time.Microsecond }, true},
+		{"millisecond duration", func(s *Segment) { s.Duration += time.Millisecond }, false},
+		{"part duration", func(s *Segment) { s.Parts = []Part{{URI: "a.0.ts", Duration: 2*time.Second + 500}} }, true},
+		{"part uri", func(s *Segment) { s.Parts = []Part{{URI: "b.0.ts", Duration: 2 * time.Second}} }, false},
+		{"uri", func(s *Segment) { s.URI = "b.ts" }, false},
+		{"iv copy", func(s *Segment) {
+			iv := [16]byte{1}
+			s.Keys = []Key{{Method: EncryptMethodAES128, URI: "key", IV: &iv}}
+		}, true},
+		{"iv", func(s *Segment) { s.Keys = []Key{{Method: EncryptMethodAES128, URI: "key"}} }, false},
+		{"map", func(s *Segment) { s.Map = &Map{URI: "init.mp4", ByteRange: ByteRange{100, 0}} }, false},
+		{"no map", func(s *Segment) { s.Map = nil }, false},
+		{"resolved key", func(s *Segment) { s.keys = s.Keys }, true},
+		{"daterange location", func(s *Segment) {
+			dr := *s.DateRange
+			dr.Start = dr.Start.In(time.FixedZone("", 3600))
+			s.DateRange = &dr
+		}, true},
+		{"daterange id", func(s *Segment) {
+			dr := *s.DateRange
+			dr.ID = "other"
+			s.DateRange = &dr
+		}, false},
+		{"empty custom", func(s *Segment) { s.Custom = map[string][]string{} }, true},
+		{"custom", func(s *Segment) { s.Custom = map[string][]string{"#EXT-X-FOO": {"1"}} }, false},
+	}
+	for _, tt := range cases {
+		other := seg
+		tt.edit(&other)
+		if got := seg.Equal(other); got != tt.equal {
+			t.Errorf("%s: Equal returned %t, want %t", tt.name, got, tt.equal)
+		}
+		if got := other.Equal(seg); got != tt.equal {
+			t.Errorf("%s: Equal not symmetric", tt.name)
+		}
+	}
+}
+
+func TestPlaylistEqual(t *testing.T) {
+	for _, name := range []string{"media.m3u8", "master.m3u8", "bbb.m3u8"} {
+		b, err := os.ReadFile("testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := Decode(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := &bytes.Buffer{}
+		if err := Encode(buf, p); err != nil {
+			t.Fatal(err)
+		}
+		q, err := Decode(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !p.Equal(q) {
+			t.Errorf("%s: decoded playlist not equal after round trip", name)
+		}
+		if p.Kind() == KindMedia && len(q.Segments) > 0 {
+			q.Segments[0].Duration += time.Second
+			if p.Equal(q) {
+				t.Errorf("%s: playlists with different segment durations are equal", name)
+			}
+		}
+	}
+	var p *Playlist
+	if !p.Equal(nil) || p.Equal(&Playlist{}) {
+		t.Errorf("nil playlist compared incorrectly")
+	}
+}
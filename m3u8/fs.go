@@ -0,0 +1,49 @@
+package m3u8
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// DecodeFile decodes the playlist file name from fsys.
+// It is useful for reading playlists stored alongside their segments,
+// such as in tests with fstest.MapFS. To refer to the playlist's
+// URIs by their name in fsys, call ResolvePaths with the playlist's
+// directory:
+//
+//	p, err := DecodeFile(fsys, "vod/index.m3u8")
+//	if err != nil {
+//		// handle error
+//	}
+//	p.ResolvePaths(path.Dir("vod/index.m3u8"))
+//	f, err := fsys.Open(p.Segments[0].URI) // e.g. vod/001.ts
+func DecodeFile(fsys fs.FS, name string) (*Playlist, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p, err := Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", name, err)
+	}
+	return p, nil
+}
+
+// ResolvePaths rewrites each relative URI in the playlist as a path
+// joined to dir. URIs with a scheme, such as https URLs, and rooted
+// paths are left unchanged.
+func (p *Playlist) ResolvePaths(dir string) {
+	p.rewriteURIs(func(s string) (string, error) {
+		if strings.HasPrefix(s, "/") {
+			return s, nil
+		}
+		if u, err := url.Parse(s); err == nil && u.IsAbs() {
+			return s, nil
+		}
+		return path.Join(dir, s), nil
+	})
+}
@@ -0,0 +1,43 @@
+package m3u8
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDecodeFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"vod/index.m3u8": &fstest.MapFile{Data: []byte(`#EXTM3U
+#EXT-X-TARGETDURATION:4
+#EXTINF:4.000,
+segments/001.ts
+#EXTINF:4.000,
+https://cdn.example.com/002.ts
+#EXT-X-ENDLIST
+`)},
+		"vod/segments/001.ts": &fstest.MapFile{Data: []byte("not really mpeg-ts")},
+		"bad.m3u8":            &fstest.MapFile{Data: []byte("hello")},
+	}
+	p, err := DecodeFile(fsys, "vod/index.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ResolvePaths("vod")
+	if p.Segments[0].URI != "vod/segments/001.ts" {
+		t.Errorf("resolved segment path %q, want %q", p.Segments[0].URI, "vod/segments/001.ts")
+	}
+	if p.Segments[1].URI != "https://cdn.example.com/002.ts" {
+		t.Errorf("absolute URL changed to %q", p.Segments[1].URI)
+	}
+	f, err := fsys.Open(p.Segments[0].URI)
+	if err != nil {
+		t.Fatalf("open resolved segment: %v", err)
+	}
+	f.Close()
+
+	_, err = DecodeFile(fsys, "bad.m3u8")
+	if err == nil || !strings.Contains(err.Error(), "bad.m3u8") {
+		t.Errorf("error %v does not name bad file", err)
+	}
+}
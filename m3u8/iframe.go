@@ -0,0 +1,70 @@
+package m3u8
+
+import (
+	"fmt"
+	"time"
+)
+
+// IFrame locates an I-frame, or keyframe, within a media segment.
+type IFrame struct {
+	// Offset is the presentation time of the frame from the start of the segment.
+	Offset time.Duration
+	// Range is the byte range of the frame in the segment's resource.
+	Range ByteRange
+}
+
+// IFramePlaylist returns an I-frame playlist, as described in
+// RFC 8216 section 4.3.3.6, for the media playlist p. The function
+// frames returns the I-frames of each segment in presentation order.
+// Each I-frame becomes a segment whose duration lasts until the next
+// I-frame, or until the end of the last segment. Such a playlist lets
+// players implement fast-forward and scrubbing ("trick play").
+func IFramePlaylist(p *Playlist, frames func(seg *Segment) ([]IFrame, error)) (*Playlist, error) {
+	ip := &Playlist{
+		TargetDuration:        p.TargetDuration,
+		Sequence:              p.Sequence,
+		DiscontinuitySequence: p.DiscontinuitySequence,
+		End:                   p.End,
+		Type:                  p.Type,
+		IFramesOnly:           true,
+	}
+	// start is the time of each I-frame since the first segment.
+	var start []time.Duration
+	var elapsed time.Duration
+	for i := range p.Segments {
+		seg := &p.Segments[i]
+		ff, err := frames(seg)
+		if err != nil {
+			return nil, fmt.Errorf("segment %d: %w", i, err)
+		}
+		for j, f := range ff {
+			if f.Offset < 0 || f.Offset >= seg.Duration {
+				return nil, fmt.Errorf("segment %d: frame %d offset %s outside segment duration %s", i, j, f.Offset, seg.Duration)
+			} else if j > 0 && f.Offset <= ff[j-1].Offset {
+				return nil, fmt.Errorf("segment %d: frame %d out of order", i, j)
+			} else if f.Range[0] <= 0 {
+				return nil, fmt.Errorf("segment %d: frame %d: empty byte range", i, j)
+			}
+			iseg := Segment{URI: seg.URI, Range: f.Range}
+			if j == 0 {
+				iseg.Discontinuity = seg.Discontinuity
+				iseg.Keys = seg.Keys
+				iseg.Map = seg.Map
+				iseg.DateTime = seg.DateTime
+			}
+			ip.Segments = append(ip.Segments, iseg)
+			start = append(start, elapsed+f.Offset)
+		}
+		elapsed += seg.Duration
+	}
+	for i := range ip.Segments {
+		next := elapsed
+		if i+1 < len(start) {
+			next = start[i+1]
+		}
+		ip.Segments[i].Duration = next - start[i]
+		ip.Segments[i].SequenceNumber = ip.Sequence + int64(i)
+	}
+	ip.Version = ip.RequiredVersion()
+	return ip, nil
+}
@@ -0,0 +1,73 @@
+package m3u8
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIFramePlaylist(t *testing.T) {
+	p := &Playlist{
+		Version:        3,
+		TargetDuration: 6 * time.Second,
+		End:            true,
+		Segments: []Segment{
+			{URI: "0.ts", Duration: 6 * time.Second},
+			{URI: "1.ts", Duration: 4 * time.Second},
+		},
+	}
+	keyframes := map[string][]IFrame{
+		"0.ts": {
+			{0, ByteRange{1000, 376}},
+			{2 * time.Second, ByteRange{1200, 40000}},
+		},
+		"1.ts": {
+			{500 * time.Millisecond, ByteRange{900, 188}},
+		},
+	}
+	ip, err := IFramePlaylist(p, func(seg *Segment) ([]IFrame, error) {
+		ff, ok := keyframes[seg.URI]
+		if !ok {
+			return nil, fmt.Errorf("unknown segment %s", seg.URI)
+		}
+		return ff, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.IFramesOnly || ip.Version < 4 {
+		t.Errorf("I-frame playlist has I-frames only %v, version %d", ip.IFramesOnly, ip.Version)
+	}
+	want := []Segment{
+		{URI: "0.ts", Duration: 2 * time.Second, Range: ByteRange{1000, 376}},
+		{URI: "0.ts", Duration: 4500 * time.Millisecond, Range: ByteRange{1200, 40000}, SequenceNumber: 1},
+		{URI: "1.ts", Duration: 3500 * time.Millisecond, Range: ByteRange{900, 188}, SequenceNumber: 2},
+	}
+	if len(ip.Segments) != len(want) {
+		t.Fatalf("got %d segments, want %d", len(ip.Segments), len(want))
+	}
+	for i := range want {
+		got := ip.Segments[i]
+		if got.URI != want[i].URI || got.Duration != want[i].Duration || got.Range != want[i].Range || got.SequenceNumber != want[i].SequenceNumber {
+			t.Errorf("segment %d: got %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	buf := &strings.Builder{}
+	if err := Encode(buf, ip); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"#EXT-X-VERSION:4\n", "#EXT-X-I-FRAMES-ONLY\n", "#EXT-X-BYTERANGE:1200@40000\n"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("encoded playlist missing %q", s)
+		}
+	}
+	again, err := Decode(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.IFramesOnly {
+		t.Errorf("decoded I-frame playlist not I-frames only")
+	}
+}
@@ -0,0 +1,199 @@
+package m3u8
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func parseKey(items chan item) (*Key, error) {
+	attrs, err := parseAttributeList(items)
+	if err != nil {
+		return nil, err
+	}
+	var k Key
+	var method bool
+	for _, attr := range attrs {
+		switch attr.name {
+		case "METHOD":
+			m, err := parseEncryptMethod(attr.value.val)
+			if err != nil {
+				return nil, err
+			}
+			k.Method = m
+			method = true
+		case "URI":
+			k.URI, err = unquote(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse uri: %w", err)
+			}
+		case "IV":
+			iv, err := parseIV(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse iv: %w", err)
+			}
+			k.IV = &iv
+		case "KEYFORMAT":
+			k.Format, err = unquote(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse key format: %w", err)
+			}
+		case "KEYFORMATVERSIONS":
+			s, err := unquote(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse key format versions: %w", err)
+			}
+			for _, v := range strings.Split(s, "/") {
+				n, err := strconv.ParseUint(v, 10, 32)
+				if err != nil {
+					return nil, fmt.Errorf("parse key format versions: %w", err)
+				}
+				k.FormatVersions = append(k.FormatVersions, uint32(n))
+			}
+		default:
+			return nil, fmt.Errorf("unknown attribute %s", attr.name)
+		}
+	}
+	if !method {
+		return nil, fmt.Errorf("missing method")
+	}
+	if k.Method != EncryptMethodNone && k.URI == "" {
+		return nil, fmt.Errorf("missing URI with method %s", k.Method)
+	}
+	return &k, nil
+}
+
+// format returns the key's Format, or "identity" if unset,
+// as specified in RFC 8216 section 4.3.2.4.
+func (k Key) format() string {
+	if k.Format == "" {
+		return "identity"
+	}
+	return k.Format
+}
+
+func parseEncryptMethod(s string) (EncryptMethod, error) {
+	for m := EncryptMethodNone; m <= EncryptMethodSampleAES; m++ {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown method %q", s)
+}
+
+// parseIV parses a hexadecimal-sequence, as specified in RFC 8216
+// section 4.2, holding a 128-bit initialisation vector.
+func parseIV(s string) ([16]byte, error) {
+	var iv [16]byte
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return iv, fmt.Errorf("missing 0x prefix")
+	}
+	h := s[2:]
+	if len(h) != 2*len(iv) {
+		return iv, fmt.Errorf("need %d hex digits, have %d", 2*len(iv), len(h))
+	}
+	if _, err := hex.Decode(iv[:], []byte(h)); err != nil {
+		return iv, err
+	}
+	return iv, nil
+}
+
+// ResolveKeys determines the keys and initialisation vector of each
+// segment, as returned by Segment.EffectiveKeys and
+// Segment.EffectiveIV. The keys of a segment apply to it and every
+// following segment until the next segment with keys.
+// The key of each segment's Map is resolved too; see Map.EffectiveKey.
+// Decode calls ResolveKeys; it need only be called explicitly on
+// playlists built or modified by other means.
+func (p *Playlist) ResolveKeys() {
+	var keys []Key
+	for i := range p.Segments {
+		seg := &p.Segments[i]
+		if len(seg.Keys) > 0 {
+			keys = seg.Keys
+		}
+		seg.keys = keys
+		seg.iv = nil
+		var key *Key
+		if len(keys) > 0 {
+			key = &keys[0]
+		}
+		if seg.Map != nil {
+			seg.Map.key = key
+		}
+		if key == nil || key.Method == EncryptMethodNone {
+			continue
+		}
+		if key.IV != nil {
+			iv := *key.IV
+			seg.iv = &iv
+			continue
+		}
+		// RFC 8216 section 5.2: the media sequence number as a
+		// big-endian binary representation in a 16-octet buffer.
+		var iv [16]byte
+		binary.BigEndian.PutUint64(iv[8:], uint64(seg.SequenceNumber))
+		seg.iv = &iv
+	}
+}
+
+// Key returns the first of the segment's Keys, or nil if it has none.
+// It is a convenience for the common case of a single key.
+func (seg *Segment) Key() *Key {
+	if len(seg.Keys) == 0 {
+		return nil
+	}
+	return &seg.Keys[0]
+}
+
+// EffectiveKeys returns the keys in effect for the segment: its own
+// Keys, or those of the closest preceding segment with keys.
+// See Playlist.ResolveKeys.
+func (seg *Segment) EffectiveKeys() []Key {
+	return seg.keys
+}
+
+// EffectiveIV returns the initialisation vector to decrypt the segment,
+// or nil if the segment is not encrypted. The explicit IV of the first
+// key in effect is used if set, otherwise the segment's media sequence
+// number. See Playlist.ResolveKeys.
+func (seg *Segment) EffectiveIV() []byte {
+	if seg.iv == nil {
+		return nil
+	}
+	iv := *seg.iv
+	return iv[:]
+}
+
+// EffectiveKey returns the key in effect at the map's position in
+// its playlist, or nil if there is none. As specified in RFC 8216
+// section 4.3.2.5, the Media Initialization Section is encrypted
+// with the same key as the segments it initialises; see Encrypted.
+// The key is that of the first of the segment's EffectiveKeys.
+// See Playlist.ResolveKeys.
+func (m *Map) EffectiveKey() *Key {
+	return m.key
+}
+
+// Encrypted reports whether the Media Initialization Section must be
+// decrypted before use, which is the case if its EffectiveKey has
+// method AES-128. With SAMPLE-AES only the media samples of segments
+// are encrypted, so the section is used as is.
+func (m *Map) Encrypted() bool {
+	return m.key != nil && m.key.Method == EncryptMethodAES128
+}
+
+// checkMapKeys returns an error if a map of p is encrypted with
+// AES-128 but its key has no IV. Unlike segments, a Media
+// Initialization Section has no media sequence number from which an
+// IV can be derived, so RFC 8216 section 4.3.2.5 requires one.
+func checkMapKeys(p *Playlist) error {
+	for _, seg := range p.Segments {
+		if seg.Map != nil && seg.Map.Encrypted() && seg.Map.key.IV == nil {
+			return fmt.Errorf("map %s: encrypted with key %s with no IV", seg.Map.URI, seg.Map.key.URI)
+		}
+	}
+	return nil
+}
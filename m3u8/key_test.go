@@ -0,0 +1,181 @@
+package m3u8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const encrypted = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:7
+#EXTINF:6.000,
+clear.ts
+#EXT-X-KEY:METHOD=AES-128,URI="https://example.com/key"
+#EXTINF:6.000,
+0.ts
+#EXTINF:6.000,
+1.ts
+#EXT-X-KEY:METHOD=AES-128,URI="https://example.com/key2",IV=0x000102030405060708090a0b0c0d0e0f
+#EXTINF:6.000,
+2.ts
+#EXTINF:6.000,
+3.ts
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:6.000,
+4.ts
+`
+
+func TestEffectiveIV(t *testing.T) {
+	p, err := Decode(strings.NewReader(encrypted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	explicit := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	want := [][]byte{
+		nil,
+		// media sequence numbers 8 and 9, big-endian.
+		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 8},
+		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 9},
+		explicit,
+		explicit,
+		nil,
+	}
+	for i := range p.Segments {
+		got := p.Segments[i].EffectiveIV()
+		if !bytes.Equal(got, want[i]) {
+			t.Errorf("segment %d (%s): iv %x, want %x", i, p.Segments[i].URI, got, want[i])
+		}
+	}
+	if p.Segments[2].Key() != nil {
+		t.Errorf("segment 2 has key tag, want none")
+	}
+
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), tagKey) != 3 {
+		t.Errorf("expected 3 key tags in encoded playlist")
+		t.Log(buf.String())
+	}
+}
+
+func TestMultipleKeys(t *testing.T) {
+	const plist = `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-TARGETDURATION:6
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key1",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="data:text/plain;base64,AAAA",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",KEYFORMATVERSIONS="1"
+#EXTINF:6.000,
+0.ts
+#EXTINF:6.000,
+1.ts
+`
+	p, err := Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := p.Segments[0]
+	if len(first.Keys) != 2 {
+		t.Fatalf("got %d keys, want 2", len(first.Keys))
+	}
+	if first.Key().Format != "com.apple.streamingkeydelivery" {
+		t.Errorf("first key format %q, want %q", first.Key().Format, "com.apple.streamingkeydelivery")
+	}
+	if len(p.Segments[1].Keys) != 0 {
+		t.Errorf("second segment has key tags, want none")
+	}
+	if keys := p.Segments[1].EffectiveKeys(); len(keys) != 2 || keys[1].URI != first.Keys[1].URI {
+		t.Errorf("keys not carried forward to second segment: %v", keys)
+	}
+
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	t.Log("got:", buf.String())
+	if !strings.Contains(buf.String(), "\n"+first.Keys[0].String()+"\n"+first.Keys[1].String()+"\n") {
+		t.Errorf("keys not written in order")
+	}
+
+	dup := strings.Replace(plist, "urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed", "com.apple.streamingkeydelivery", 1)
+	if _, err := Decode(strings.NewReader(dup)); err == nil {
+		t.Errorf("nil error decoding segment with two keys of the same format")
+	}
+}
+
+func TestParseBadKey(t *testing.T) {
+	for _, k := range []string{
+		`METHOD=AES-128`,
+		`URI="key.bin"`,
+		`METHOD=ROT13,URI="key.bin"`,
+		`METHOD=AES-128,URI="key.bin",IV=0x0102`,
+		`METHOD=AES-128,URI="key.bin",IV=01020304050607080910111213141516`,
+	} {
+		plist := "#EXTM3U\n#EXT-X-KEY:" + k + "\n#EXTINF:6.000,\n0.ts\n"
+		if _, err := Decode(strings.NewReader(plist)); err == nil {
+			t.Errorf("nil error decoding key %s", k)
+		}
+	}
+}
+
+func TestEncryptedMap(t *testing.T) {
+	const plist = `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-TARGETDURATION:4
+#EXT-X-MAP:URI="clear.mp4"
+#EXTINF:4.000,
+001.m4s
+#EXT-X-KEY:METHOD=AES-128,URI="key.bin",IV=0x000102030405060708090a0b0c0d0e0f
+#EXT-X-MAP:URI="init.mp4",BYTERANGE="720@0"
+#EXTINF:4.000,
+002.m4s
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key2",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-MAP:URI="sample.mp4"
+#EXTINF:4.000,
+003.m4s
+`
+	p, err := Decoder{Strict: true}.Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clear, aes, sample := p.Segments[0].Map, p.Segments[1].Map, p.Segments[2].Map
+	if clear.EffectiveKey() != nil || clear.Encrypted() {
+		t.Errorf("map before any key has key %v", clear.EffectiveKey())
+	}
+	if k := aes.EffectiveKey(); k == nil || k.URI != "key.bin" {
+		t.Errorf("map %s: got key %v, want key.bin", aes.URI, k)
+	}
+	if !aes.Encrypted() {
+		t.Errorf("map %s not encrypted", aes.URI)
+	}
+	if aes.ByteRange != (ByteRange{720, 0}) {
+		t.Errorf("map %s: got byte range %s", aes.URI, aes.ByteRange)
+	}
+	if k := sample.EffectiveKey(); k == nil || k.Method != EncryptMethodSampleAES {
+		t.Errorf("map %s: got key %v, want SAMPLE-AES key", sample.URI, k)
+	}
+	if sample.Encrypted() {
+		t.Errorf("map %s with SAMPLE-AES key reported encrypted", sample.URI)
+	}
+
+	noIV := strings.Replace(plist, ",IV=0x000102030405060708090a0b0c0d0e0f", "", 1)
+	if _, err := (Decoder{Strict: true}).Decode(strings.NewReader(noIV)); err == nil {
+		t.Error("nil error decoding AES-128 encrypted map with no IV in strict mode")
+	}
+	var warned bool
+	dec := Decoder{OnWarning: func(error) { warned = true }}
+	if _, err := dec.Decode(strings.NewReader(noIV)); err != nil {
+		t.Fatal(err)
+	}
+	if !warned {
+		t.Error("no warning decoding AES-128 encrypted map with no IV")
+	}
+
+	bad := strings.Replace(plist, `BYTERANGE="720@0"`, `BYTERANGE="0@100"`, 1)
+	if _, err := Decode(strings.NewReader(bad)); err == nil {
+		t.Error("nil error decoding map with zero length byte range")
+	}
+}
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 	"unicode/utf8"
 )
 
 type item struct {
-	typ itemType
-	val string
+	typ  itemType
+	val  string
+	line int // line number in the input, starting at 1
 }
 
 func (it item) String() string {
@@ -72,7 +74,12 @@ type lexer struct {
 	start int
 	pos   int
 	width int
+	line  int
 	items chan item
+	// done is closed when no more items will be received,
+	// so the lexer stops reading input.
+	done     chan struct{}
+	stopOnce sync.Once
 }
 
 type stateFn func(*lexer) stateFn
@@ -100,7 +107,7 @@ func (l *lexer) peek() rune {
 
 func (l *lexer) errorf(format string, a ...any) stateFn {
 	err := fmt.Sprintf(format, a...)
-	l.items <- item{itemError, err}
+	l.send(item{typ: itemError, val: err, line: l.line})
 	return nil
 }
 
@@ -112,20 +119,41 @@ func (l *lexer) run() {
 }
 
 func (l *lexer) emit(t itemType) {
-	l.items <- item{t, l.input[l.start:l.pos]}
-	// fmt.Println(item{t, l.input[l.start:l.pos]})
+	l.send(item{typ: t, val: l.input[l.start:l.pos], line: l.line})
 	l.start = l.pos
 }
 
+func (l *lexer) send(it item) {
+	select {
+	case l.items <- it:
+	case <-l.done:
+	}
+}
+
+// stop signals the lexer to stop reading input.
+// It may be called more than once.
+func (l *lexer) stop() { l.stopOnce.Do(func() { close(l.done) }) }
+
+func (l *lexer) stopped() bool {
+	select {
+	case <-l.done:
+		return true
+	default:
+		return false
+	}
+}
+
 func newLexer(r io.Reader) *lexer {
 	return &lexer{
 		sc:    bufio.NewScanner(r),
 		items: make(chan item),
+		done:  make(chan struct{}),
 	}
 }
 
 func lexStart(l *lexer) stateFn {
-	for l.sc.Scan() {
+	for !l.stopped() && l.sc.Scan() {
+		l.line++
 		if l.sc.Text() == "" {
 			continue // ignore blank lines
 		}
@@ -144,7 +172,7 @@ func lexStart(l *lexer) stateFn {
 		l.emit(itemNewline)
 	}
 	if err := l.sc.Err(); err != nil {
-		panic(err)
+		return l.errorf("read: %v", err)
 	}
 	return nil
 }
@@ -171,15 +199,79 @@ func lexTagName(l *lexer) stateFn {
 			l.emit(itemNewline)
 			return lexStart(l)
 		case ':':
+			tag := l.input[l.start:l.pos]
 			l.emit(itemTag)
 			l.next()
 			l.ignore()
+			if tag == tagDateTime {
+				// the value is a date-time, not an attribute list.
+				return lexRawString(l)
+			} else if tag == tagSegmentDuration {
+				return lexDuration(l)
+			} else if !parsedTags[tag] {
+				// we don't know its syntax, so keep it whole.
+				return lexLine(l)
+			}
 			return lexAttrs(l)
 		}
 		return l.errorf("illegal tag character %q", r)
 	}
 }
 
+// parsedTags are the tags whose values are lexed as attributes.
+// The values of other tags, such as vendor tags, are emitted as a
+// single string item.
+var parsedTags = map[string]bool{
+	tagVersion:               true,
+	tagVariant:               true,
+	tagRendition:             true,
+	tagPlaylistType:          true,
+	tagTargetDuration:        true,
+	tagMediaSequence:         true,
+	tagDiscontinuitySequence: true,
+	tagByteRange:             true,
+	tagKey:                   true,
+	tagMap:                   true,
+	tagDateRange:             true,
+	tagPart:                  true,
+	tagContentSteering:       true,
+	tagStartPoint:            true,
+	tagServerControl:         true,
+}
+
+// lexLine emits the rest of the line as a string.
+func lexLine(l *lexer) stateFn {
+	for l.peek() != '\n' {
+		l.next()
+	}
+	l.emit(itemString)
+	l.next()
+	l.emit(itemNewline)
+	return lexStart(l)
+}
+
+// lexDuration emits the duration of an EXTINF tag as a number,
+// followed by the title as a string if there is one. The title is
+// free text, so only the first comma separates it from the duration.
+func lexDuration(l *lexer) stateFn {
+	for l.peek() != ',' && l.peek() != '\n' {
+		l.next()
+	}
+	l.emit(itemNumber)
+	if l.next() == ',' {
+		l.ignore()
+		for l.peek() != '\n' {
+			l.next()
+		}
+		if l.pos > l.start {
+			l.emit(itemString)
+		}
+		l.next()
+	}
+	l.emit(itemNewline)
+	return lexStart(l)
+}
+
 func isTagNameChar(r rune) bool {
 	if r >= 'A' && r <= 'Z' {
 		return true
@@ -218,7 +310,8 @@ func lexAttrs(l *lexer) stateFn {
 		case r == '.':
 			return lexAttrValue(l)
 		case r == '@':
-			return lexAttrValue(l)
+			// a byte range, e.g. 1024@2048
+			return lexRawString(l)
 		default:
 			return l.errorf("illegal character %q in attribute name", r)
 		}
@@ -0,0 +1,104 @@
+package m3u8
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// LiveWriter writes a media playlist incrementally, segment by segment,
+// such as when an origin serves a live stream as it is encoded.
+// After each write, the underlying writer is flushed if it
+// implements http.Flusher, sending data to clients without delay.
+type LiveWriter struct {
+	w      io.Writer
+	header *Playlist
+	target time.Duration
+	wrote  bool
+	closed bool
+}
+
+// NewLiveWriter returns a LiveWriter writing to w. The header tags
+// written are taken from header, whose segments are ignored.
+func NewLiveWriter(w io.Writer, header *Playlist) *LiveWriter {
+	return &LiveWriter{w: w, header: header}
+}
+
+// WriteHeader writes the playlist header.
+// It must be called exactly once, before any segments are appended.
+// Since later segments are unknown, the header's TargetDuration must be set.
+func (lw *LiveWriter) WriteHeader() error {
+	if lw.wrote {
+		return fmt.Errorf("header already written")
+	}
+	if lw.header.TargetDuration <= 0 {
+		return fmt.Errorf("target duration unset")
+	}
+	lw.target = lw.header.TargetDuration.Truncate(time.Second)
+	writeHeader(lw.w, lw.header, lw.target)
+	lw.wrote = true
+	lw.flush()
+	return nil
+}
+
+// AppendSegment writes seg to the playlist.
+// An error is returned if the segment, rounded to the nearest second,
+// is longer than the target duration.
+func (lw *LiveWriter) AppendSegment(seg Segment) error {
+	if lw.closed {
+		return fmt.Errorf("append to closed playlist")
+	} else if !lw.wrote {
+		return fmt.Errorf("header not written")
+	}
+	if d := seg.Duration.Round(time.Second); d > lw.target {
+		return fmt.Errorf("duration %s exceeds target duration %s", seg.Duration, lw.target)
+	}
+	if _, err := WriteSegment(lw.w, &seg); err != nil {
+		return err
+	}
+	lw.flush()
+	return nil
+}
+
+// Close ends the playlist by writing the EXT-X-ENDLIST tag.
+// The underlying writer is not closed.
+func (lw *LiveWriter) Close() error {
+	if lw.closed {
+		return fmt.Errorf("playlist already closed")
+	} else if !lw.wrote {
+		return fmt.Errorf("header not written")
+	}
+	lw.closed = true
+	if _, err := fmt.Fprintln(lw.w, tagEndList); err != nil {
+		return err
+	}
+	lw.flush()
+	return nil
+}
+
+// Finalize marks the live or event media playlist p as complete,
+// such as when a live event ends and its recording is kept for
+// on-demand viewing. Since no further segments may be added,
+// End is set so that Encode writes the EXT-X-ENDLIST tag.
+// An event playlist holds every segment of the event, so its Type
+// becomes PlaylistVOD. An error is returned if p has already ended
+// or is a master playlist.
+func (p *Playlist) Finalize() error {
+	if p.End {
+		return fmt.Errorf("playlist already ended")
+	} else if len(p.Variants) > 0 || len(p.Media) > 0 {
+		return fmt.Errorf("finalize master playlist")
+	}
+	p.End = true
+	if p.Type == PlaylistEvent {
+		p.Type = PlaylistVOD
+	}
+	return nil
+}
+
+func (lw *LiveWriter) flush() {
+	if f, ok := lw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,102 @@
+package m3u8
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flushRecorder records what had been written at each flush.
+type flushRecorder struct {
+	strings.Builder
+	flushed []string
+}
+
+func (r *flushRecorder) Flush() { r.flushed = append(r.flushed, r.String()) }
+
+func TestLiveWriter(t *testing.T) {
+	rec := &flushRecorder{}
+	lw := NewLiveWriter(rec, &Playlist{Version: 3, TargetDuration: 4 * time.Second, Sequence: 10})
+	if err := lw.AppendSegment(Segment{URI: "early.ts", Duration: time.Second}); err == nil {
+		t.Errorf("nil error appending segment before header")
+	}
+	if err := lw.WriteHeader(); err != nil {
+		t.Fatal(err)
+	}
+	if err := lw.WriteHeader(); err == nil {
+		t.Errorf("nil error writing header twice")
+	}
+	for i := 0; i < 5; i++ {
+		seg := Segment{URI: fmt.Sprintf("%d.ts", i), Duration: 4 * time.Second}
+		if err := lw.AppendSegment(seg); err != nil {
+			t.Fatalf("append segment %d: %v", i, err)
+		}
+		last := rec.flushed[len(rec.flushed)-1]
+		if !strings.HasSuffix(last, seg.URI+"\n") {
+			t.Errorf("segment %s not flushed", seg.URI)
+		}
+	}
+	if err := lw.AppendSegment(Segment{URI: "long.ts", Duration: 6 * time.Second}); err == nil {
+		t.Errorf("nil error appending segment longer than target duration")
+	}
+	if err := lw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := lw.AppendSegment(Segment{URI: "late.ts", Duration: time.Second}); err == nil {
+		t.Errorf("nil error appending segment after close")
+	}
+	if len(rec.flushed) != 7 {
+		t.Errorf("flushed %d times, want 7", len(rec.flushed))
+	}
+
+	p, err := Decode(strings.NewReader(rec.String()))
+	if err != nil {
+		t.Fatalf("decode written playlist: %v", err)
+	}
+	if len(p.Segments) != 5 || !p.End || p.Sequence != 10 {
+		t.Errorf("unexpected playlist: %d segments, end %v, sequence %d", len(p.Segments), p.End, p.Sequence)
+		t.Log(rec.String())
+	}
+}
+
+func TestFinalize(t *testing.T) {
+	const event = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-PLAYLIST-TYPE:EVENT
+#EXT-X-TARGETDURATION:4
+#EXTINF:4.000,
+0.ts
+#EXTINF:4.000,
+1.ts
+`
+	p, err := Decode(strings.NewReader(event))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+	if p.Type != PlaylistVOD {
+		t.Errorf("finalized event playlist has type %s, want %s", p.Type, PlaylistVOD)
+	}
+	if err := p.Finalize(); err == nil {
+		t.Errorf("nil error finalizing ended playlist")
+	}
+
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	t.Log("got:", buf.String())
+	if n := strings.Count(buf.String(), tagEndList); n != 1 {
+		t.Errorf("got %d %s tags, want 1", n, tagEndList)
+	}
+	if !strings.HasSuffix(buf.String(), "1.ts\n"+tagEndList+"\n") {
+		t.Errorf("%s not written after last segment", tagEndList)
+	}
+
+	if err := (&Playlist{Variants: []Variant{{URI: "low.m3u8"}}}).Finalize(); err == nil {
+		t.Errorf("nil error finalizing master playlist")
+	}
+}
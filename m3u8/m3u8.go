@@ -16,6 +16,11 @@ const MimeType string = "application/vnd.apple.mpegurl"
 
 const RFC3339Milli string = "2006-01-02T15:04:05.999Z07:00"
 
+// Playlist represents either a media playlist or a master playlist,
+// as distinguished by Kind. A media playlist lists the Segments of a
+// single rendition; its header fields run from TargetDuration to
+// ServerControl. A master playlist instead lists Variants and their
+// alternative renditions in Media.
 type Playlist struct {
 	Version             int
 	Segments            []Segment
@@ -24,24 +29,41 @@ type Playlist struct {
 
 	// Media playlist
 	// RFC 8216, 4.4.3.1
-	TargetDuration        time.Duration
-	Sequence              int
+	TargetDuration time.Duration
+	// Sequence is the media sequence number of the first segment.
+	// It is zero if the EXT-X-MEDIA-SEQUENCE tag is absent, as
+	// specified in RFC 8216 section 4.3.3.2.
+	// It is an int64 so that numbers from long-running live streams
+	// do not overflow on 32-bit platforms.
+	Sequence              int64
 	DiscontinuitySequence int
 	End                   bool
 	Type                  PlaylistType
 	IFramesOnly           bool
+	ServerControl         *ServerControl
 
 	// Master playlist
 	Media       []Rendition
 	Variants    []Variant
 	SessionData []SessionData
 	SessionKey  *Key
+	Steering    *ContentSteering
+
+	// comments holds the comments read among the header tags
+	// if Decoder.PreserveOrder is set.
+	comments []string
+	// raw holds the tags read outside of any segment, keyed by tag,
+	// if Decoder.RawTags is set.
+	raw map[string][]string
 }
 
 type Segment struct {
 	URI string
 	// Duration of this specific segment from the #EXTINF tag.
 	Duration time.Duration
+	// Title is the human-readable title of the segment, everything
+	// after the first comma of the #EXTINF tag.
+	Title string
 	// Indicates this segment holds a subset of the segment point to by URI.
 	// Range is the length of the subsegment from from the #EXT-X-BYTERANGE tag.
 	Range ByteRange
@@ -49,12 +71,74 @@ type Segment struct {
 	// are discontinuous. For example, this segment is part of a
 	// commercial break.
 	Discontinuity bool
-	// Holds information on how to decrypt this segment.
-	// If nil, the segment is not encrypted.
-	Key       *Key
+	// Keys holds the keys from the EXT-X-KEY tags preceding the
+	// segment, each with a different Format, such as for content
+	// protected by several DRM systems. Keys apply to this and every
+	// following segment until the next segment with Keys; see
+	// EffectiveKeys. See also Key.
+	Keys      []Key
 	Map       *Map
 	DateTime  time.Time
 	DateRange *DateRange
+	// Gap indicates the segment is unavailable and must not be
+	// loaded, from the EXT-X-GAP tag. See also IsGap.
+	Gap bool
+	// Parts holds the partial segments of the segment used in
+	// Low-Latency HLS. The last segment of a live playlist may
+	// have Parts but no URI if it is still being produced.
+	Parts []Part
+	// SequenceNumber is the media sequence number of the segment:
+	// the playlist's Sequence plus the segment's position in the playlist.
+	// It is set by Decode and ignored by Encode.
+	SequenceNumber int64
+	// Custom holds the values of nonstandard tags declared in
+	// Decoder.CustomTags, keyed by tag name. It is ignored by Encode;
+	// see Decoder.PreserveOrder to write such tags unchanged.
+	Custom map[string][]string
+
+	// iv is the initialisation vector in effect for the segment,
+	// and keys the keys in effect, set by Playlist.ResolveKeys.
+	iv   *[16]byte
+	keys []Key
+	// lines holds the segment as read if Decoder.PreserveOrder is set.
+	lines []taggedLine
+	// raw holds the segment's tags as read, keyed by tag,
+	// if Decoder.RawTags is set.
+	raw map[string][]string
+	// endList is set while decoding if an EXT-X-ENDLIST tag
+	// appears among the segment's tags.
+	endList bool
+}
+
+// Part represents a partial segment from the EXT-X-PART tag used in
+// Low-Latency HLS, as specified in draft-pantos-hls-rfc8216bis
+// section 4.4.4.9.
+type Part struct {
+	URI      string
+	Duration time.Duration
+	// Independent indicates the part contains an independent frame.
+	Independent bool
+	Range       ByteRange
+	// Gap indicates the part is unavailable and must not be loaded.
+	Gap bool
+}
+
+func (p Part) String() string {
+	us := p.Duration / time.Microsecond
+	attrs := []string{
+		fmt.Sprintf("DURATION=%.05f", float64(us)/1e6),
+		fmt.Sprintf("URI=%q", p.URI),
+	}
+	if p.Independent {
+		attrs = append(attrs, "INDEPENDENT=YES")
+	}
+	if p.Range != [2]int{0, 0} {
+		attrs = append(attrs, fmt.Sprintf("BYTERANGE=\"%s\"", p.Range))
+	}
+	if p.Gap {
+		attrs = append(attrs, "GAP=YES")
+	}
+	return tagPart + ":" + strings.Join(attrs, ",")
 }
 
 // Key represents the EXT-X-KEY tag specified in RFC 8216 seciton 4.3.2.3.
@@ -69,15 +153,22 @@ type Key struct {
 	// version; subsequent values are minor versions.
 	FormatVersions []uint32
 	// IV is a 128-bit unsigned integer holding the key's
-	// initialisation vector.
-	IV [16]byte
+	// initialisation vector. If nil, the media sequence number of
+	// each segment is used instead; see Segment.EffectiveIV.
+	IV *[16]byte
 }
 
 func (k Key) String() string {
+	if k.Method == EncryptMethodNone {
+		// No other attributes are allowed; RFC 8216 section 4.3.2.4.
+		return tagKey + ":METHOD=" + k.Method.String()
+	}
 	var attrs []string
 	attrs = append(attrs, fmt.Sprintf("METHOD=%s", k.Method))
 	attrs = append(attrs, fmt.Sprintf("URI=%q", k.URI))
-	attrs = append(attrs, fmt.Sprintf("IV=0x%s", hex.EncodeToString(k.IV[:])))
+	if k.IV != nil {
+		attrs = append(attrs, fmt.Sprintf("IV=0x%s", hex.EncodeToString(k.IV[:])))
+	}
 	if k.Format != "" {
 		attrs = append(attrs, fmt.Sprintf("KEYFORMAT=%q", k.Format))
 	}
@@ -113,26 +204,37 @@ func (m EncryptMethod) String() string {
 	return "invalid"
 }
 
+// Map represents the EXT-X-MAP tag, specifying the Media
+// Initialization Section required to parse the following segments,
+// as described in RFC 8216 section 4.3.2.5.
 type Map struct {
 	URI       string
 	ByteRange ByteRange
+
+	// key is the key in effect at the map's position,
+	// set by Playlist.ResolveKeys.
+	key *Key
 }
 
 func (m Map) String() string {
 	if m.ByteRange != [2]int{0, 0} {
-		return fmt.Sprintf("%s:URI=%q,BYTERANGE=%s", tagMap, m.URI, m.ByteRange)
+		return fmt.Sprintf("%s:URI=%q,BYTERANGE=\"%s\"", tagMap, m.URI, m.ByteRange)
 	}
 	return fmt.Sprintf("%s:URI=%q", tagMap, m.URI)
 }
 
-// ByteRange represents...
-// The first entry is an offset, the second...?
+// ByteRange represents a sub-range of a resource, as specified in
+// RFC 8216 section 4.3.2.2. The first entry is the length of the
+// sub-range in bytes, the second is the offset in bytes from the
+// start of the resource.
+//
+// The offset may be omitted in a playlist, in which case the
+// sub-range follows the sub-range of the previous segment. Decode
+// resolves such offsets so that both entries are always set,
+// and the offset is always written.
 type ByteRange [2]int
 
 func (r ByteRange) String() string {
-	if r[1] == 0 {
-		return strconv.Itoa(r[0])
-	}
 	return fmt.Sprintf("%d@%d", r[0], r[1])
 }
 
@@ -143,7 +245,10 @@ type DateRange struct {
 	End      time.Time
 	Duration time.Duration
 	Planned  time.Duration
-	// value must be a string, float or hex sequence (int?)
+	// Custom holds X-<client-attribute> attributes keyed by name,
+	// such as "X-COM-EXAMPLE-AD-ID". Values are a string, []byte
+	// or float64 for quoted strings, hexadecimal sequences and
+	// numbers respectively. WriteDateRange writes them sorted by name.
 	Custom     map[string]any
 	CueCommand *scte35.Splice
 	// Contains the first of the in/out cue pair. Command may be
@@ -151,8 +256,45 @@ type DateRange struct {
 	CueOut *scte35.Splice
 	// Contains the second of the cue in/out pair. The Command's
 	// Type must match the "out" cue.
-	CueIn     *scte35.Splice
+	CueIn *scte35.Splice
+	// EndOnNext indicates the range ends at the start of the
+	// following range with the same Class.
 	EndOnNext bool
+	// ImpliedEnd is set by Decode for ranges with EndOnNext set
+	// to the start of the following range with the same Class.
+	// It is the zero time if there is no such range in the playlist,
+	// or if Class is empty.
+	// It is ignored by Encode.
+	ImpliedEnd time.Time
+}
+
+// Kind identifies whether a Playlist is a media or master playlist.
+type Kind uint8
+
+const (
+	KindMedia Kind = iota
+	KindMaster
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindMedia:
+		return "media"
+	case KindMaster:
+		return "master"
+	}
+	return "invalid"
+}
+
+// Kind reports whether p is a media or master playlist.
+// A playlist with any variants, renditions, session data, session key
+// or content steering is a master playlist; any other playlist,
+// including one with no segments, is a media playlist.
+func (p *Playlist) Kind() Kind {
+	if len(p.Variants) > 0 || len(p.Media) > 0 || len(p.SessionData) > 0 || p.SessionKey != nil || p.Steering != nil {
+		return KindMaster
+	}
+	return KindMedia
 }
 
 type PlaylistType uint8
@@ -172,8 +314,3 @@ func (t PlaylistType) String() string {
 	}
 	return "invalid"
 }
-
-type StartPoint struct {
-	Offset  float32
-	Precise bool
-}
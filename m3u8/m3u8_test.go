@@ -2,6 +2,7 @@ package m3u8
 
 import (
 	"bytes"
+	"os"
 	"strings"
 	"testing"
 )
@@ -62,3 +63,30 @@ func TestWriteSessionData(t *testing.T) {
 		})
 	}
 }
+
+func TestKind(t *testing.T) {
+	var tests = []struct {
+		name string
+		want Kind
+	}{
+		{"testdata/master.m3u8", KindMaster},
+		{"testdata/media.m3u8", KindMedia},
+	}
+	for _, tt := range tests {
+		f, err := os.Open(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("decode %s: %v", tt.name, err)
+		}
+		if p.Kind() != tt.want {
+			t.Errorf("%s: kind %s, want %s", tt.name, p.Kind(), tt.want)
+		}
+	}
+	if k := (&Playlist{}).Kind(); k != KindMedia {
+		t.Errorf("empty playlist kind %s, want %s", k, KindMedia)
+	}
+}
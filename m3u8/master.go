@@ -2,6 +2,7 @@ package m3u8
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -43,9 +44,50 @@ type Rendition struct {
 	// Characteristics contains Uniform Type Identifiers.
 	// For example []string{CharacteristicTranscribesDialog, ChractersticEasyToRead}
 	Characteristics []string
-	// Channels contains the different counts of audio channels available in the Rendition.
-	// For example []string{"2,6"} represents stereo (2) and 5.1 surround-sound (5 + 1) channels.
-	Channels []string
+	// Channels describes the audio channels of an audio Rendition.
+	Channels *Channels
+	// StableID identifies the Rendition across reloads and
+	// redundant playlists, from the STABLE-RENDITION-ID attribute.
+	StableID string
+	// Pathway is the content steering pathway of the Rendition,
+	// from the PATHWAY-ID attribute. See ContentSteering.
+	Pathway string
+}
+
+// Channels represents the CHANNELS attribute of an audio rendition.
+// For example "6/JOC" is six channels carrying Dolby Atmos objects
+// by joint object coding.
+type Channels struct {
+	// Count is the number of independent, simultaneous audio channels.
+	Count int
+	// CodingIdentifiers lists the audio object coding used, such as
+	// "JOC". A single "-" indicates no audio object coding.
+	CodingIdentifiers []string
+	// Spatial lists any additional audio flags, such as "BINAURAL"
+	// or "IMMERSIVE", from the optional third parameter.
+	Spatial []string
+}
+
+func (c Channels) String() string {
+	params := []string{strconv.Itoa(c.Count)}
+	if len(c.CodingIdentifiers) > 0 || len(c.Spatial) > 0 {
+		params = append(params, strings.Join(c.CodingIdentifiers, ","))
+	}
+	if len(c.Spatial) > 0 {
+		params = append(params, strings.Join(c.Spatial, ","))
+	}
+	return strings.Join(params, "/")
+}
+
+// ObjectCoded reports whether the channels carry object-based audio,
+// such as Dolby Atmos.
+func (c Channels) ObjectCoded() bool {
+	for _, id := range c.CodingIdentifiers {
+		if id != "-" && id != "" {
+			return true
+		}
+	}
+	return false
 }
 
 func (r Rendition) String() string {
@@ -78,9 +120,14 @@ func (r Rendition) String() string {
 		chars := strings.Join(r.Characteristics, ",")
 		attrs = append(attrs, fmt.Sprintf("CHARACTERISTICS=%q", chars))
 	}
-	if len(r.Channels) > 0 {
-		channels := strings.Join(r.Channels, "/")
-		attrs = append(attrs, fmt.Sprintf("CHANNELS=%q", channels))
+	if r.Channels != nil {
+		attrs = append(attrs, fmt.Sprintf("CHANNELS=%q", r.Channels))
+	}
+	if r.StableID != "" {
+		attrs = append(attrs, fmt.Sprintf("STABLE-RENDITION-ID=%q", r.StableID))
+	}
+	if r.Pathway != "" {
+		attrs = append(attrs, fmt.Sprintf("PATHWAY-ID=%q", r.Pathway))
 	}
 	return tagRendition + ":" + strings.Join(attrs, ",")
 }
@@ -156,6 +203,23 @@ type Variant struct {
 	// (HDCPLevelNone) indicates no output copy protection is
 	// required for playing.
 	HDCP HDCPLevel
+	// AllowedCPC restricts the Variant to devices whose DRM
+	// implements one of the listed Content Protection Configurations,
+	// from the ALLOWED-CPC attribute. Nil indicates no restriction.
+	AllowedCPC []CPC
+
+	// Score is the relative preference for this Variant over
+	// others in the playlist; higher is better. Zero is unset.
+	Score float64
+	// VideoRange describes the dynamic range of the video.
+	VideoRange VideoRange
+	// StableID identifies the Variant across reloads and
+	// redundant playlists, from the STABLE-VARIANT-ID attribute.
+	StableID string
+	// Pathway is the content steering pathway of the Variant,
+	// from the PATHWAY-ID attribute. The empty string indicates
+	// DefaultPathway. See ContentSteering.
+	Pathway string
 
 	// Each remaining field identifies a matching rendition of
 	// the same type in the playlist. The match has its Group set to
@@ -181,14 +245,27 @@ type Variant struct {
 	Audio          string
 	Video          string
 	Subtitles      string
-	ClosedCaptions string // May be NoClosedCaptions to explicitly signal no rendition.
+	ClosedCaptions string
+	// NoClosedCaptions explicitly signals that the variant has no
+	// closed captions, written as CLOSED-CAPTIONS=NONE.
+	// It takes precedence over ClosedCaptions.
+	NoClosedCaptions bool
+
+	// The renditions of each group named above,
+	// set by Playlist.ResolveRenditions.
+	audio, video, subtitles, captions []Rendition
 }
 
 func (v Variant) String() string {
+	// Attributes are written in the order they are listed in
+	// RFC 8216 section 4.3.4.2 so that output is reproducible.
 	var attrs []string
 	attrs = append(attrs, fmt.Sprintf("BANDWIDTH=%d", v.Bandwidth))
 	if v.AverageBandwidth > 0 {
-		attrs = append(attrs, fmt.Sprintf("AVERAGE-BANDWIDTH=%d,", v.AverageBandwidth))
+		attrs = append(attrs, fmt.Sprintf("AVERAGE-BANDWIDTH=%d", v.AverageBandwidth))
+	}
+	if v.Score > 0 {
+		attrs = append(attrs, "SCORE="+strconv.FormatFloat(v.Score, 'f', -1, 64))
 	}
 	if len(v.Codecs) > 0 {
 		attrs = append(attrs, fmt.Sprintf("CODECS=%q", strings.Join(v.Codecs, ",")))
@@ -202,6 +279,19 @@ func (v Variant) String() string {
 	if v.HDCP != HDCPNone {
 		attrs = append(attrs, fmt.Sprintf("HDCP-LEVEL=%s", v.HDCP))
 	}
+	if len(v.AllowedCPC) > 0 {
+		cpcs := make([]string, len(v.AllowedCPC))
+		for i := range v.AllowedCPC {
+			cpcs[i] = v.AllowedCPC[i].String()
+		}
+		attrs = append(attrs, fmt.Sprintf("ALLOWED-CPC=%q", strings.Join(cpcs, ",")))
+	}
+	if v.VideoRange != VideoRangeUnspecified {
+		attrs = append(attrs, fmt.Sprintf("VIDEO-RANGE=%s", v.VideoRange))
+	}
+	if v.StableID != "" {
+		attrs = append(attrs, fmt.Sprintf("STABLE-VARIANT-ID=%q", v.StableID))
+	}
 	if v.Audio != "" {
 		attrs = append(attrs, fmt.Sprintf("AUDIO=%q", v.Audio))
 	}
@@ -211,16 +301,108 @@ func (v Variant) String() string {
 	if v.Subtitles != "" {
 		attrs = append(attrs, fmt.Sprintf("SUBTITLES=%q", v.Subtitles))
 	}
-	if v.ClosedCaptions != "" && v.ClosedCaptions != NoClosedCaptions {
+	if v.NoClosedCaptions {
+		attrs = append(attrs, "CLOSED-CAPTIONS=NONE")
+	} else if v.ClosedCaptions != "" {
 		attrs = append(attrs, fmt.Sprintf("CLOSED-CAPTIONS=%q", v.ClosedCaptions))
 	}
+	if v.Pathway != "" {
+		attrs = append(attrs, fmt.Sprintf("PATHWAY-ID=%q", v.Pathway))
+	}
 	return fmt.Sprintf("%s:%s\n%s", tagVariant, strings.Join(attrs, ","), v.URI)
 }
 
-// NoClosedCaptions may be the value for Variant.ClosedCaptions to
-// explicitly indicate that no closed captions are available for the
-// Variant.
-const NoClosedCaptions string = "NONE"
+// VideoRange represents the VIDEO-RANGE attribute of a Variant.
+// The zero value indicates the attribute is absent, which players
+// treat as SDR.
+type VideoRange uint8
+
+const (
+	VideoRangeUnspecified VideoRange = iota
+	VideoRangeSDR
+	VideoRangeHLG
+	VideoRangePQ
+)
+
+func (r VideoRange) String() string {
+	switch r {
+	case VideoRangeUnspecified:
+		return ""
+	case VideoRangeSDR:
+		return "SDR"
+	case VideoRangeHLG:
+		return "HLG"
+	case VideoRangePQ:
+		return "PQ"
+	}
+	return "invalid"
+}
+
+func parseVideoRange(s string) (VideoRange, error) {
+	for r := VideoRangeSDR; r <= VideoRangePQ; r++ {
+		if r.String() == s {
+			return r, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown video range %q", s)
+}
+
+// AudioRenditions returns the renditions of the variant's Audio group.
+// It returns nil until Playlist.ResolveRenditions is called, as it is
+// by Decode.
+func (v Variant) AudioRenditions() []Rendition { return v.audio }
+
+// VideoRenditions returns the renditions of the variant's Video group.
+// See AudioRenditions.
+func (v Variant) VideoRenditions() []Rendition { return v.video }
+
+// SubtitleRenditions returns the renditions of the variant's Subtitles group.
+// See AudioRenditions.
+func (v Variant) SubtitleRenditions() []Rendition { return v.subtitles }
+
+// ClosedCaptionRenditions returns the renditions of the variant's
+// ClosedCaptions group, or nil if NoClosedCaptions is set.
+// See AudioRenditions.
+func (v Variant) ClosedCaptionRenditions() []Rendition { return v.captions }
+
+// ResolveRenditions associates each variant with the renditions of
+// the groups named by its Audio, Video, Subtitles and ClosedCaptions
+// fields, as returned by Variant.AudioRenditions and similar methods.
+// Every variant is resolved, but an error is returned for the first
+// group which has no renditions of the matching type, as RFC 8216
+// section 4.3.4.2 requires each named group to exist.
+// Decode calls ResolveRenditions, reporting any error to
+// Decoder.OnWarning; it need only be called explicitly on playlists
+// built or modified by other means.
+func (p *Playlist) ResolveRenditions() error {
+	var err error
+	group := func(typ MediaType, id string) []Rendition {
+		if id == "" {
+			return nil
+		}
+		var rends []Rendition
+		for _, r := range p.Media {
+			if r.Type == typ && r.Group == id {
+				rends = append(rends, r)
+			}
+		}
+		if rends == nil && err == nil {
+			err = fmt.Errorf("no %s renditions in group %q", typ, id)
+		}
+		return rends
+	}
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		v.audio = group(MediaAudio, v.Audio)
+		v.video = group(MediaVideo, v.Video)
+		v.subtitles = group(MediaSubtitles, v.Subtitles)
+		v.captions = nil
+		if !v.NoClosedCaptions {
+			v.captions = group(MediaClosedCaptions, v.ClosedCaptions)
+		}
+	}
+	return err
+}
 
 type HDCPLevel uint8
 
@@ -242,6 +424,80 @@ func (l HDCPLevel) String() string {
 	return "unknown"
 }
 
+// CPC is an entry of the ALLOWED-CPC attribute of a Variant. It lists
+// the Content Protection Configurations, such as "SW" or "HW", of
+// the DRM identified by KeyFormat which may play the Variant.
+// The labels are defined by the key system, not by RFC 8216.
+type CPC struct {
+	KeyFormat string // matches the KEYFORMAT of an EXT-X-KEY tag
+	Labels    []string
+}
+
+func (c CPC) String() string {
+	return c.KeyFormat + ":" + strings.Join(c.Labels, "/")
+}
+
+// parseAllowedCPC parses the value of the ALLOWED-CPC attribute,
+// for example "com.example.drm1:SMART-TV/PC,com.example.drm2:HW".
+func parseAllowedCPC(s string) ([]CPC, error) {
+	var cpcs []CPC
+	for _, entry := range strings.Split(s, ",") {
+		format, labels, ok := strings.Cut(entry, ":")
+		if !ok || format == "" {
+			return nil, fmt.Errorf("entry %q: missing key format", entry)
+		}
+		c := CPC{KeyFormat: format, Labels: strings.Split(labels, "/")}
+		for _, l := range c.Labels {
+			if l == "" {
+				return nil, fmt.Errorf("entry %q: empty label", entry)
+			}
+		}
+		cpcs = append(cpcs, c)
+	}
+	return cpcs, nil
+}
+
+// DeviceCapabilities describes what a playback device supports,
+// for choosing a Variant with SelectVariant.
+type DeviceCapabilities struct {
+	// Bandwidth is the available network bandwidth in bits per
+	// second. Zero indicates no limit.
+	Bandwidth int
+	// HDCP is the highest HDCP level the device's outputs support.
+	HDCP HDCPLevel
+}
+
+// SelectVariant returns the best variant of p which the device
+// described by caps can play, or nil if there is none. Variants
+// requiring a higher HDCP level than caps.HDCP are skipped, as
+// players must not choose them (RFC 8216 section 4.3.4.2).
+// Of the remaining variants whose Bandwidth fits caps.Bandwidth,
+// the one with the highest Score is chosen, or the highest Bandwidth
+// among equal scores. If no variant fits, the one with the lowest
+// Bandwidth is chosen.
+func (p *Playlist) SelectVariant(caps DeviceCapabilities) *Variant {
+	var best, lowest *Variant
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		if v.HDCP > caps.HDCP {
+			continue
+		}
+		if lowest == nil || v.Bandwidth < lowest.Bandwidth {
+			lowest = v
+		}
+		if caps.Bandwidth > 0 && v.Bandwidth > caps.Bandwidth {
+			continue
+		}
+		if best == nil || v.Score > best.Score || (v.Score == best.Score && v.Bandwidth > best.Bandwidth) {
+			best = v
+		}
+	}
+	if best == nil {
+		return lowest
+	}
+	return best
+}
+
 // IFrameInfo represents the EXT-X-I-FRAME-STREAM-INF tag.
 // It has the same structure as Variant, but the following fields should be unset:
 // - FrameRate
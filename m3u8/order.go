@@ -0,0 +1,150 @@
+package m3u8
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// taggedLine is a line of a playlist as it was read.
+type taggedLine struct {
+	tag  string // empty for a URI line
+	text string
+}
+
+// headerTags are tags which may precede the first media segment.
+var headerTags = map[string]bool{
+	tagHead:                  true,
+	tagVersion:               true,
+	tagIndependentSegments:   true,
+	tagPlaylistType:          true,
+	tagTargetDuration:        true,
+	tagMediaSequence:         true,
+	tagDiscontinuitySequence: true,
+	tagIFramesOnly:           true,
+	"#EXT-X-ALLOW-CACHE":     true,
+	tagStartPoint:            true,
+	"#EXT-X-PART-INF":        true,
+	tagServerControl:         true,
+}
+
+// recordLines stores the lines of data belonging to each segment of p,
+// from the first line after the preceding segment's URI up to and
+// including its own URI. Comments and unknown tags are kept in place.
+// Comments among the header tags are stored in p.comments.
+func recordLines(p *Playlist, data []byte) {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	var lines []taggedLine
+	header := true
+	i := 0
+	for sc.Scan() && i < len(p.Segments) {
+		text := sc.Text()
+		if text == "" {
+			continue
+		}
+		var tag string
+		if strings.HasPrefix(text, "#") {
+			tag, _, _ = strings.Cut(text, ":")
+			if tag == tagEndList {
+				continue // written by Encode from Playlist.End
+			}
+		}
+		if header && headerTags[tag] {
+			continue
+		} else if header && isComment(text) {
+			p.comments = append(p.comments, text)
+			continue
+		}
+		header = false
+		lines = append(lines, taggedLine{tag, text})
+		if !strings.HasPrefix(text, "#") {
+			p.Segments[i].lines = lines
+			lines = nil
+			i++
+		}
+	}
+	if i < len(p.Segments) && len(lines) > 0 {
+		// the last segment is in progress, with only parts.
+		p.Segments[i].lines = lines
+	}
+}
+
+// isComment reports whether line is a comment: a line beginning with
+// "#" which is not a tag, as specified in RFC 8216 section 4.1.
+func isComment(line string) bool {
+	return strings.HasPrefix(line, "#") && !strings.HasPrefix(line, tagStart)
+}
+
+// replayLines writes the recorded lines of seg to buf. The segment's
+// current URI replaces the recorded one, so URIs may be resolved.
+func replayLines(buf *bytes.Buffer, seg *Segment) {
+	for i, l := range seg.lines {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if l.tag == "" {
+			buf.WriteString(seg.URI)
+			continue
+		}
+		buf.WriteString(l.text)
+	}
+}
+
+// recordRawTags stores the text of each tag line in data. Tags
+// belonging to a segment, from the line after the preceding segment's
+// URI up to its own URI, are stored in the segment. Header tags,
+// tags following the last segment, and all tags of a master playlist
+// are stored in p.
+func recordRawTags(p *Playlist, data []byte) {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	master := p.Kind() == KindMaster
+	header := true
+	i := 0
+	for sc.Scan() {
+		text := sc.Text()
+		if text == "" || isComment(text) {
+			continue
+		}
+		if !strings.HasPrefix(text, "#") {
+			// a URI, ending the segment.
+			header = false
+			if !master {
+				i++
+			}
+			continue
+		}
+		tag, _, _ := strings.Cut(text, ":")
+		if master || i >= len(p.Segments) || tag == tagEndList || (header && headerTags[tag]) {
+			p.raw = appendRaw(p.raw, tag, text)
+			continue
+		}
+		header = false
+		p.Segments[i].raw = appendRaw(p.Segments[i].raw, tag, text)
+	}
+}
+
+func appendRaw(raw map[string][]string, tag, text string) map[string][]string {
+	if raw == nil {
+		raw = make(map[string][]string)
+	}
+	raw[tag] = append(raw[tag], text)
+	return raw
+}
+
+// RawTags returns the text of each occurrence of tag in the segment
+// exactly as read, without its line terminator. For example:
+//
+//	seg.RawTags("#EXT-X-KEY")
+//
+// might return []string{`#EXT-X-KEY:METHOD=AES-128,URI="key.bin"`}.
+// It returns nil unless the playlist was decoded with Decoder.RawTags set.
+func (seg *Segment) RawTags(tag string) []string {
+	return seg.raw[tag]
+}
+
+// RawTags returns the text of each occurrence of tag exactly as read
+// outside of any segment: among the header tags, following the last
+// segment, or anywhere in a master playlist. See Segment.RawTags.
+func (p *Playlist) RawTags(tag string) []string {
+	return p.raw[tag]
+}
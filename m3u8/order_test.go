@@ -0,0 +1,156 @@
+package m3u8
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const cueTags = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:0
+#EXTINF:6.000,
+0.ts
+#EXT-X-CUE-OUT:30.000
+#EXTINF:6.000,
+ad0.ts
+#EXT-X-CUE-OUT-CONT:ElapsedTime=6.000,Duration=30.000
+#EXTINF:6.000,
+ad1.ts
+# a comment
+#EXTINF:6.000,
+#EXT-X-CUE-IN
+1.ts
+#EXT-X-ENDLIST
+`
+
+func TestPreserveOrder(t *testing.T) {
+	p, err := Decoder{PreserveOrder: true}.Decode(strings.NewReader(cueTags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != cueTags {
+		t.Errorf("playlist not preserved")
+		t.Log("got:", buf.String())
+		t.Log("want:", cueTags)
+	}
+
+	p.Segments[1].URI = "https://example.com/ad0.ts"
+	buf.Reset()
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "#EXTINF:6.000,\nhttps://example.com/ad0.ts\n") {
+		t.Errorf("changed URI not written")
+		t.Log(buf.String())
+	}
+}
+
+func TestComments(t *testing.T) {
+	const plist = `#EXTM3U
+# generated by ffmpeg
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:0
+# first segment follows
+#EXTINF:6.000,
+0.ts
+#EXTINF:6.000,
+1.ts
+`
+	p, err := Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatalf("decode playlist with comments: %v", err)
+	}
+	if len(p.Segments) != 2 {
+		t.Fatalf("got %d segments, want 2", len(p.Segments))
+	}
+
+	p, err = Decoder{PreserveOrder: true}.Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	t.Log("got:", buf.String())
+	// header comments are written after the header tags.
+	want := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:0
+# generated by ffmpeg
+# first segment follows
+#EXTINF:6.000,
+0.ts
+#EXTINF:6.000,
+1.ts
+`
+	if buf.String() != want {
+		t.Errorf("comments not preserved")
+		t.Log("want:", want)
+	}
+}
+
+func TestRawTags(t *testing.T) {
+	const key0 = `#EXT-X-KEY:URI="https://keys.example.com/0",METHOD=AES-128,IV=0x0000000000000000000000000000000a`
+	const key1 = `#EXT-X-KEY:METHOD=AES-128,URI="https://keys.example.com/1"`
+	in := "#EXTM3U\n" +
+		"#EXT-X-VERSION:3\n" +
+		"#EXT-X-TARGETDURATION:6\n" +
+		key0 + "\n" +
+		"#EXTINF:6.0,\n" +
+		"0.ts\n" +
+		"# a comment\n" +
+		key1 + "\n" +
+		"#EXTINF:6.0,\n" +
+		"1.ts\n" +
+		"#EXTINF:6.0,\n" +
+		"2.ts\n" +
+		"#EXT-X-ENDLIST\n"
+	p, err := Decoder{RawTags: true}.Decode(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases = []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"segment 0 key", p.Segments[0].RawTags(tagKey), []string{key0}},
+		{"segment 1 key", p.Segments[1].RawTags(tagKey), []string{key1}},
+		{"segment 2 key", p.Segments[2].RawTags(tagKey), nil},
+		{"segment 1 duration", p.Segments[1].RawTags(tagSegmentDuration), []string{"#EXTINF:6.0,"}},
+		{"version", p.RawTags(tagVersion), []string{"#EXT-X-VERSION:3"}},
+		{"end list", p.RawTags(tagEndList), []string{tagEndList}},
+		{"header key", p.RawTags(tagKey), nil},
+	}
+	for _, tt := range cases {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// Encode still writes the playlist from its fields.
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), key0) {
+		t.Errorf("raw key written by Encode")
+	}
+
+	p, err = Decode(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw := p.Segments[0].RawTags(tagKey); raw != nil {
+		t.Errorf("raw tags recorded by default: %q", raw)
+	}
+}
@@ -1,30 +1,308 @@
 package m3u8
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 const (
-	tagHead                = tagStart + "M3U"
-	tagVersion             = "#EXT-X-VERSION"
-	tagVariant             = "#EXT-X-STREAM-INF"
-	tagRendition           = "#EXT-X-MEDIA"
-	tagPlaylistType        = "#EXT-X-PLAYLIST-TYPE"        // RFC 8216, 4.4.3.5
-	tagTargetDuration      = "#EXT-X-TARGETDURATION"       // RFC 8216, 4.4.3.1
-	tagMediaSequence       = "#EXT-X-MEDIA-SEQUENCE"       // RFC 8216, 4.3.3.2
-	tagEndList             = "#EXT-X-ENDLIST"              // RFC 8216, 4.4.3.4
-	tagIndependentSegments = "#EXT-X-INDEPENDENT-SEGMENTS" // RFC 8216, 4.3.5.1
-	tagSessionData         = "#EXT-X-SESSION-DATA"         // RFC 8216, 4.3.4.4
+	tagHead                  = tagStart + "M3U"
+	tagVersion               = "#EXT-X-VERSION"
+	tagVariant               = "#EXT-X-STREAM-INF"
+	tagRendition             = "#EXT-X-MEDIA"
+	tagPlaylistType          = "#EXT-X-PLAYLIST-TYPE"          // RFC 8216, 4.4.3.5
+	tagTargetDuration        = "#EXT-X-TARGETDURATION"         // RFC 8216, 4.4.3.1
+	tagMediaSequence         = "#EXT-X-MEDIA-SEQUENCE"         // RFC 8216, 4.3.3.2
+	tagDiscontinuitySequence = "#EXT-X-DISCONTINUITY-SEQUENCE" // RFC 8216, 4.4.3.3
+	tagEndList               = "#EXT-X-ENDLIST"                // RFC 8216, 4.4.3.4
+	tagIndependentSegments   = "#EXT-X-INDEPENDENT-SEGMENTS"   // RFC 8216, 4.3.5.1
+	tagSessionData           = "#EXT-X-SESSION-DATA"           // RFC 8216, 4.3.4.4
+	tagIFramesOnly           = "#EXT-X-I-FRAMES-ONLY"          // RFC 8216, 4.3.3.6
 )
 
+// Decode reads a playlist from rd.
+// Decode is equivalent to calling Decode on a zero Decoder.
 func Decode(rd io.Reader) (*Playlist, error) {
+	return Decoder{}.Decode(rd)
+}
+
+// Decoder reads playlists with configurable behaviour.
+type Decoder struct {
+	// RelaxedDateTime, if true, permits a space instead of 'T'
+	// separating the date and time of EXT-X-PROGRAM-DATE-TIME tags,
+	// as sent by some encoders.
+	RelaxedDateTime bool
+
+	// PreserveOrder, if true, records the lines of each segment as
+	// read, including comments and unknown tags such as
+	// EXT-X-CUE-OUT-CONT, so that Encode writes them in their
+	// original order. Changes to a recorded segment's fields, other
+	// than its URI, are then not reflected by Encode.
+	// Comments preceding the first segment are kept too, and are
+	// written following the header tags.
+	PreserveOrder bool
+
+	// CustomTags declares nonstandard segment tags, such as
+	// "#EXT-X-CUE-OUT", to be read into Segment.Custom. Each tag's
+	// value is passed to its TagParser, whose results are appended to
+	// the Custom entry named by the tag. A nil TagParser stores the
+	// value unchanged.
+	CustomTags map[string]TagParser
+
+	// OnSegment, if non-nil, is called with each media segment as it
+	// is read, before it is added to the playlist, so that it may be
+	// modified. Its SequenceNumber is set, but fields resolved once the
+	// whole playlist is read, such as a date range's ImpliedEnd, are not.
+	// If OnSegment returns an error, decoding stops and the error
+	// is returned.
+	OnSegment func(*Segment) error
+
+	// Strict, if true, makes malformed segments which are otherwise
+	// tolerated an error. Currently these are a segment URI with no
+	// preceding EXTINF tag, which when not strict is given the
+	// playlist's target duration, a map encrypted with AES-128 by a
+	// key with no IV, an EXT-X-MEDIA-SEQUENCE tag following the
+	// first segment, which when not strict renumbers the segments
+	// preceding it, and content steering pathway IDs not used by
+	// any variant.
+	Strict bool
+
+	// RequireUTF8, if true, makes input which is not valid UTF-8 an
+	// error, as RFC 8216 section 4.1 requires playlists to be UTF-8
+	// encoded. Otherwise invalid bytes, as found in legacy Latin-1
+	// playlists, are passed through unchanged in URIs and titles.
+	RequireUTF8 bool
+
+	// OnWarning, if non-nil, is called with a description of each
+	// malformed but tolerated part of the playlist, including each
+	// problem reported by CheckDateRanges and CheckByteRanges.
+	OnWarning func(error)
+
+	// RawTags, if true, records the text of each tag line exactly as
+	// read, such as for audit logging, to be returned by
+	// Segment.RawTags and Playlist.RawTags. Known tags are recorded
+	// as well as unknown ones. Unlike PreserveOrder, Encode still
+	// writes the playlist from its fields. Recording holds a copy of
+	// every tag in memory, so is off by default.
+	RawTags bool
+
+	// MaxSegments is the largest number of media segments read
+	// before decoding stops with an error wrapping ErrTooManySegments.
+	// If zero, DefaultMaxSegments is used. If negative, there is no limit.
+	MaxSegments int
+
+	// MaxBytes is the largest number of bytes read before decoding
+	// stops with an error wrapping ErrTooLarge. Input is counted as it
+	// is read, so untrusted playlists of any size may be decoded
+	// safely. If zero, DefaultMaxBytes is used. If negative, there is
+	// no limit.
+	MaxBytes int64
+}
+
+// Default limits of a Decoder. They are much larger than any
+// legitimate playlist; a VOD playlist of a day-long stream split
+// into 2 second segments has 43200 segments and is around 4MB.
+const (
+	DefaultMaxSegments = 100000
+	DefaultMaxBytes    = 64 << 20
+)
+
+// Errors returned by Decoder.Decode when input exceeds its limits.
+var (
+	ErrTooManySegments = errors.New("too many segments")
+	ErrTooLarge        = errors.New("playlist too large")
+)
+
+// TagParser parses the value of a custom tag, everything after the
+// colon, into values stored in Segment.Custom. The value is empty if
+// the tag has no colon.
+type TagParser func(value string) ([]string, error)
+
+// Decode reads a playlist from rd.
+func (d Decoder) Decode(rd io.Reader) (*Playlist, error) {
+	lr := d.limitReader(rd)
+	if !d.PreserveOrder && !d.RequireUTF8 && !d.RawTags {
+		p, err := d.decode(lr, false)
+		if err != nil && lr.Err() != nil {
+			return p, lr.Err()
+		}
+		return p, err
+	}
+	b, err := io.ReadAll(lr)
+	if err != nil {
+		return nil, err
+	}
+	if d.RequireUTF8 {
+		if err := checkUTF8(b); err != nil {
+			return nil, err
+		}
+	}
+	p, err := d.decode(bytes.NewReader(b), false)
+	if err != nil {
+		return p, err
+	}
+	if d.PreserveOrder {
+		recordLines(p, b)
+	}
+	if d.RawTags {
+		recordRawTags(p, b)
+	}
+	return p, nil
+}
+
+// checkUTF8 returns an error locating the first invalid UTF-8
+// sequence in b, if any.
+func checkUTF8(b []byte) error {
+	line := 1
+	for i := 0; i < len(b); {
+		r, size := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && size <= 1 {
+			return fmt.Errorf("line %d: invalid UTF-8 at byte offset %d", line, i)
+		}
+		if r == '\n' {
+			line++
+		}
+		i += size
+	}
+	return nil
+}
+
+// DecodeHeader is like Decode but stops at the first media segment,
+// returning a playlist with no Segments. This is useful when only
+// playlist metadata such as Version, TargetDuration and Type is needed.
+// Tags which RFC 8216 permits after segments, such as EXT-X-ENDLIST,
+// are not read. Master playlists have no segments and are read fully.
+func DecodeHeader(rd io.Reader) (*Playlist, error) {
+	var d Decoder
+	lr := d.limitReader(rd)
+	p, err := d.decode(lr, true)
+	if err != nil && lr.Err() != nil {
+		return p, lr.Err()
+	}
+	return p, err
+}
+
+// DecodeAll reads every playlist from rd, such as from a file holding
+// several playlists back to back. Each playlist begins with an #EXTM3U
+// line; blank lines between playlists are ignored.
+// DecodeAll is equivalent to calling DecodeAll on a zero Decoder.
+func DecodeAll(rd io.Reader) ([]*Playlist, error) {
+	return Decoder{}.DecodeAll(rd)
+}
+
+// DecodeAll is like Decode but reads every playlist from rd, each
+// decoded independently with the options of d. Errors are prefixed
+// with the index of the playlist in rd, and DecodeAll stops at
+// the first error, returning the playlists decoded before it.
+// The whole stream is read into memory first, so d.MaxBytes limits
+// the size of the stream rather than of each playlist.
+func (d Decoder) DecodeAll(rd io.Reader) ([]*Playlist, error) {
+	b, err := io.ReadAll(d.limitReader(rd))
+	if err != nil {
+		return nil, err
+	}
+	var playlists []*Playlist
+	for i, doc := range splitPlaylists(b) {
+		p, err := d.Decode(bytes.NewReader(doc))
+		if err != nil {
+			return playlists, fmt.Errorf("playlist %d: %w", i, err)
+		}
+		playlists = append(playlists, p)
+	}
+	return playlists, nil
+}
+
+// splitPlaylists splits b before each #EXTM3U line. Leading blank lines
+// are dropped. Any other text before the first #EXTM3U line is
+// returned as the first playlist, for Decode to report it.
+func splitPlaylists(b []byte) [][]byte {
+	var docs [][]byte
+	start := -1
+	for off := 0; off < len(b); {
+		end := bytes.IndexByte(b[off:], '\n')
+		if end < 0 {
+			end = len(b)
+		} else {
+			end += off + 1
+		}
+		line := bytes.TrimSpace(b[off:end])
+		if string(line) == tagHead {
+			if start >= 0 {
+				docs = append(docs, b[start:off])
+			}
+			start = off
+		} else if start < 0 && len(line) > 0 {
+			start = off
+		}
+		off = end
+	}
+	if start >= 0 {
+		docs = append(docs, b[start:])
+	}
+	return docs
+}
+
+// limitedReader reads from r until more than n bytes are read,
+// then returns an error wrapping ErrTooLarge.
+// A negative n means no limit.
+// It is read by the lexer's goroutine, so err is guarded by mu.
+type limitedReader struct {
+	r   io.Reader
+	n   int64
+	max int64
+	mu  sync.Mutex
+	err error
+}
+
+func (d *Decoder) limitReader(r io.Reader) *limitedReader {
+	max := d.MaxBytes
+	if max == 0 {
+		max = DefaultMaxBytes
+	}
+	return &limitedReader{r: r, n: max, max: max}
+}
+
+func (l *limitedReader) Read(b []byte) (int, error) {
+	if err := l.Err(); err != nil {
+		return 0, err
+	}
+	if l.n < 0 {
+		return l.r.Read(b)
+	}
+	// Read one byte more than permitted to detect oversized input.
+	if int64(len(b)) > l.n+1 {
+		b = b[:l.n+1]
+	}
+	n, err := l.r.Read(b)
+	if int64(n) > l.n {
+		l.mu.Lock()
+		l.err = fmt.Errorf("%w: more than %d bytes", ErrTooLarge, l.max)
+		l.mu.Unlock()
+		return 0, l.Err()
+	}
+	l.n -= int64(n)
+	return n, err
+}
+
+// Err returns the error wrapping ErrTooLarge, or nil if the limit
+// has not been exceeded.
+func (l *limitedReader) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+func (d Decoder) decode(rd io.Reader, headerOnly bool) (*Playlist, error) {
 	lex := newLexer(rd)
 	go lex.run()
+	defer lex.stop()
 	it := <-lex.items
 	if it.typ == itemError {
 		return nil, errors.New(it.val)
@@ -34,6 +312,50 @@ func Decode(rd io.Reader) (*Playlist, error) {
 	}
 	p := &Playlist{}
 	var err error
+	var currentMap *Map
+	maxSegments := d.MaxSegments
+	if maxSegments == 0 {
+		maxSegments = DefaultMaxSegments
+	}
+	addSegment := func(leading item) error {
+		if maxSegments > 0 && len(p.Segments) >= maxSegments {
+			return fmt.Errorf("%w: more than %d", ErrTooManySegments, maxSegments)
+		}
+		segment, err := parseSegment(lex.items, leading, &d)
+		var derr *durationError
+		if errors.As(err, &derr) && !d.Strict {
+			segment.Duration = p.TargetDuration
+			if d.OnWarning != nil {
+				d.OnWarning(fmt.Errorf("%w: using target duration %s", derr, p.TargetDuration))
+			}
+			err = nil
+		}
+		if err != nil {
+			return fmt.Errorf("parse segment: %w", err)
+		}
+		if segment.endList {
+			p.End = true
+			segment.endList = false
+		}
+		if segment.Map != nil {
+			currentMap = segment.Map
+		}
+		var prev *Segment
+		if len(p.Segments) > 0 {
+			prev = &p.Segments[len(p.Segments)-1]
+		}
+		if err := resolveOffset(segment, prev, currentMap); err != nil {
+			return fmt.Errorf("parse segment: %w", err)
+		}
+		segment.SequenceNumber = p.Sequence + int64(len(p.Segments))
+		if d.OnSegment != nil {
+			if err := d.OnSegment(segment); err != nil {
+				return err
+			}
+		}
+		p.Segments = append(p.Segments, *segment)
+		return nil
+	}
 	for it := range lex.items {
 		switch it.typ {
 		case itemError:
@@ -51,6 +373,18 @@ func Decode(rd io.Reader) (*Playlist, error) {
 				}
 			case tagIndependentSegments:
 				p.IndependentSegments = true
+			case tagStartPoint:
+				p.Start, err = parseStartPoint(lex.items)
+				if err != nil {
+					return p, fmt.Errorf("parse start point: %w", err)
+				}
+			case tagIFramesOnly:
+				p.IFramesOnly = true
+			case tagServerControl:
+				p.ServerControl, err = parseServerControl(lex.items)
+				if err != nil {
+					return p, fmt.Errorf("parse server control: %w", err)
+				}
 			case tagVariant:
 				variant, err := parseVariant(lex.items)
 				if err != nil {
@@ -63,6 +397,12 @@ func Decode(rd io.Reader) (*Playlist, error) {
 					return p, fmt.Errorf("parse rendition: %w", err)
 				}
 				p.Media = append(p.Media, *rend)
+			case tagContentSteering:
+				steering, err := parseContentSteering(lex.items)
+				if err != nil {
+					return p, fmt.Errorf("parse content steering: %w", err)
+				}
+				p.Steering = steering
 			case tagPlaylistType:
 				it = <-lex.items
 				typ, err := parsePlaylistType(it)
@@ -77,20 +417,112 @@ func Decode(rd io.Reader) (*Playlist, error) {
 					return p, fmt.Errorf("parse target duration: %w", err)
 				}
 				p.TargetDuration = dur
-			case tagSegmentDuration, tagByteRange:
-				segment, err := parseSegment(lex.items, it)
+			case tagMediaSequence, tagDiscontinuitySequence:
+				name := it.val
+				it = <-lex.items
+				bits := 64
+				if name == tagDiscontinuitySequence {
+					bits = strconv.IntSize
+				}
+				n, err := parseSequence(it, bits)
 				if err != nil {
-					return p, fmt.Errorf("parse segment: %w", err)
+					return p, fmt.Errorf("parse %s: %w", name, err)
+				}
+				if name == tagMediaSequence {
+					if len(p.Segments) > 0 {
+						// RFC 8216 section 4.3.3.2: the tag must
+						// precede the first segment.
+						err := fmt.Errorf("%s after first segment", name)
+						if d.Strict {
+							return p, err
+						} else if d.OnWarning != nil {
+							d.OnWarning(err)
+						}
+					}
+					p.Sequence = n
+					for i := range p.Segments {
+						p.Segments[i].SequenceNumber = n + int64(i)
+					}
+				} else {
+					p.DiscontinuitySequence = int(n)
 				}
-				p.Segments = append(p.Segments, *segment)
 			case tagEndList:
+				// Usually the last line, but it may appear anywhere
+				// and following segments are still read.
 				p.End = true
+			default:
+				if !segmentTags[it.val] && !d.customTag(it.val) {
+					break
+				}
+				if headerOnly {
+					return p, nil
+				}
+				if err := addSegment(it); err != nil {
+					return p, err
+				}
+			}
+		case itemURL:
+			// a segment with no preceding tags, not even EXTINF.
+			if headerOnly {
+				return p, nil
+			}
+			if err := addSegment(it); err != nil {
+				return p, err
 			}
 		}
 	}
+	resolveEndOnNext(p.Segments)
+	p.ResolveKeys()
+	if err := p.ResolveRenditions(); err != nil && d.OnWarning != nil {
+		d.OnWarning(err)
+	}
+	if err := checkMapKeys(p); err != nil {
+		if d.Strict {
+			return p, err
+		} else if d.OnWarning != nil {
+			d.OnWarning(err)
+		}
+	}
+	if err := checkPathways(p); err != nil {
+		if d.Strict {
+			return p, err
+		} else if d.OnWarning != nil {
+			d.OnWarning(err)
+		}
+	}
+	if d.OnWarning != nil {
+		if err := checkStartPoint(p); err != nil {
+			d.OnWarning(err)
+		}
+		for _, err := range p.CheckDateRanges() {
+			d.OnWarning(err)
+		}
+		for _, err := range p.CheckByteRanges() {
+			d.OnWarning(err)
+		}
+	}
 	return p, nil
 }
 
+// segmentTags are the tags which may begin a media segment.
+var segmentTags = map[string]bool{
+	tagSegmentDuration: true,
+	tagByteRange:       true,
+	tagDiscontinuity:   true,
+	tagDateRange:       true,
+	tagMap:             true,
+	tagDateTime:        true,
+	tagKey:             true,
+	tagGap:             true,
+	tagPart:            true,
+}
+
+// customTag reports whether tag is declared in d.CustomTags.
+func (d *Decoder) customTag(tag string) bool {
+	_, ok := d.CustomTags[tag]
+	return ok
+}
+
 func parseVariant(items chan item) (*Variant, error) {
 	var v Variant
 	for it := range items {
@@ -155,6 +587,16 @@ func parseVariant(items chan item) (*Variant, error) {
 					return nil, fmt.Errorf("parse HDCP level: %w", err)
 				}
 				v.HDCP = l
+			case "ALLOWED-CPC":
+				it = <-items
+				if it.typ != itemString {
+					return nil, fmt.Errorf("parse allowed cpc: unexpected %s", it)
+				}
+				cpcs, err := parseAllowedCPC(strings.Trim(it.val, `"`))
+				if err != nil {
+					return nil, fmt.Errorf("parse allowed cpc: %w", err)
+				}
+				v.AllowedCPC = cpcs
 			case "AUDIO", "VIDEO", "SUBTITLES":
 				name := attr.val
 				it = <-items
@@ -169,10 +611,47 @@ func parseVariant(items chan item) (*Variant, error) {
 				} else if name == "SUBTITLES" {
 					v.Subtitles = it.val
 				}
+			case "SCORE":
+				it = <-items
+				if it.typ != itemNumber {
+					return nil, fmt.Errorf("parse score: unexpected %s", it)
+				}
+				n, err := strconv.ParseFloat(it.val, 64)
+				if err != nil {
+					return nil, fmt.Errorf("parse score: %w", err)
+				}
+				v.Score = n
+			case "VIDEO-RANGE":
+				it = <-items
+				r, err := parseVideoRange(it.val)
+				if err != nil {
+					return nil, fmt.Errorf("parse video range: %w", err)
+				}
+				v.VideoRange = r
+			case "STABLE-VARIANT-ID":
+				it = <-items
+				if it.typ != itemString {
+					return nil, fmt.Errorf("parse stable variant id: unexpected %s", it)
+				}
+				v.StableID = strings.Trim(it.val, `"`)
+			case "PATHWAY-ID":
+				it = <-items
+				if it.typ != itemString {
+					return nil, fmt.Errorf("parse pathway id: unexpected %s", it)
+				}
+				v.Pathway = strings.Trim(it.val, `"`)
 			case "CLOSED-CAPTIONS":
 				it = <-items
 				if it.typ != itemString {
-					return nil, fmt.Errorf("parse closed-captions: unexpcted %s", it)
+					return nil, fmt.Errorf("parse closed-captions: unexpected %s", it)
+				}
+				// Either the enumerated value NONE, or the quoted ID of a
+				// group, which may itself be "NONE".
+				if it.val == "NONE" {
+					v.NoClosedCaptions = true
+					break
+				} else if !strings.HasPrefix(it.val, `"`) {
+					return nil, fmt.Errorf("parse closed-captions: unquoted group id %s", it.val)
 				}
 				v.ClosedCaptions = strings.Trim(it.val, `"`)
 			default:
@@ -185,7 +664,6 @@ func parseVariant(items chan item) (*Variant, error) {
 			return &v, nil
 		}
 	}
-	fmt.Println(v)
 	return &v, nil
 }
 
@@ -266,7 +744,14 @@ func parseRendition(items chan item) (*Rendition, error) {
 		case "CHARACTERISTICS":
 			rend.Characteristics = strings.Split(it.val, ",")
 		case "CHANNELS":
-			rend.Channels = strings.Split(strings.Trim(it.val, `"`), "/")
+			rend.Channels, err = parseChannels(strings.Trim(it.val, `"`))
+			if err != nil {
+				return nil, fmt.Errorf("parse channels: %w", err)
+			}
+		case "STABLE-RENDITION-ID":
+			rend.StableID = strings.Trim(it.val, `"`)
+		case "PATHWAY-ID":
+			rend.Pathway = strings.Trim(it.val, `"`)
 		default:
 			return nil, fmt.Errorf("unknown rendition attribute %s", attr.val)
 		}
@@ -285,6 +770,28 @@ func parseRendition(items chan item) (*Rendition, error) {
 	return &rend, nil
 }
 
+func parseChannels(s string) (*Channels, error) {
+	params := strings.Split(s, "/")
+	if len(params) > 3 {
+		return nil, fmt.Errorf("too many parameters in %q", s)
+	}
+	n, err := strconv.Atoi(params[0])
+	if err != nil {
+		return nil, fmt.Errorf("parse count: %w", err)
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("non-positive count %d", n)
+	}
+	c := &Channels{Count: n}
+	if len(params) > 1 {
+		c.CodingIdentifiers = strings.Split(params[1], ",")
+	}
+	if len(params) > 2 {
+		c.Spatial = strings.Split(params[2], ",")
+	}
+	return c, nil
+}
+
 func parseMediaType(s string) (MediaType, error) {
 	for t := MediaAudio; t <= MediaClosedCaptions; t++ {
 		if t.String() == s {
@@ -362,6 +869,22 @@ func parseTargetDuration(it item) (time.Duration, error) {
 	return time.Duration(i) * time.Second, nil
 }
 
+// parseSequence parses a sequence number which must fit in a signed
+// integer of the given bit size.
+func parseSequence(it item, bitSize int) (int64, error) {
+	if it.typ != itemAttrName && it.typ != itemNumber {
+		return 0, fmt.Errorf("got %s: want attribute name or number", it)
+	}
+	n, err := strconv.ParseInt(it.val, 10, bitSize)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("negative sequence number %d", n)
+	}
+	return n, nil
+}
+
 func parseByteRange(s string) (ByteRange, error) {
 	offset, until, found := strings.Cut(s, "@")
 	if !found {
@@ -1,10 +1,15 @@
 package m3u8
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -91,6 +96,66 @@ func TestParseDuration(t *testing.T) {
 	}
 }
 
+func TestParseWholeDuration(t *testing.T) {
+	var cases = []struct {
+		in   string
+		want time.Duration
+	}{
+		{"6", 6 * time.Second},
+		{"6.000", 6 * time.Second},
+		{"10.0", 10 * time.Second},
+		{"6.", 6 * time.Second},
+		{"0.000", 0},
+		{"6.006", 6006 * time.Millisecond},
+		{"6.0001", 6000100 * time.Microsecond},
+		{"9.9667", 9966700 * time.Microsecond},
+		{"9.009", 9009 * time.Millisecond},
+		{"0.011122222", 11122222 * time.Nanosecond},
+		{"1.0000000019", time.Second + time.Nanosecond},
+	}
+	for _, tt := range cases {
+		dur, err := parseSegmentDuration(item{typ: itemNumber, val: tt.in})
+		if err != nil {
+			t.Errorf("parse %s: %v", tt.in, err)
+			continue
+		}
+		if dur != tt.want {
+			t.Errorf("parseSegmentDuration(%s) = %s, want %s", tt.in, dur, tt.want)
+		}
+	}
+	for _, s := range []string{"", ".", "6..0", "6.0x", "99999999999999999999"} {
+		if dur, err := parseSegmentDuration(item{typ: itemNumber, val: s}); err == nil {
+			t.Errorf("parse %q: nil error, got %s", s, dur)
+		}
+	}
+}
+
+func BenchmarkParseSegmentDuration(b *testing.B) {
+	it := item{typ: itemNumber, val: "6.000"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseSegmentDuration(it); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeWholeSeconds(b *testing.B) {
+	buf := &bytes.Buffer{}
+	buf.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:6\n")
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(buf, "#EXTINF:6.000,\n%05d.ts\n", i)
+	}
+	raw := buf.Bytes()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(bytes.NewReader(raw)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func TestParseByteRange(t *testing.T) {
 	var tests = []struct {
 		in    string
@@ -135,3 +200,683 @@ func TestFrameRate(t *testing.T) {
 		}
 	}
 }
+
+func TestSequenceNumber(t *testing.T) {
+	p, err := Decode(strings.NewReader(liveSnapshot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, seg := range p.Segments {
+		want := int64(100 + i)
+		if seg.SequenceNumber != want {
+			t.Errorf("segment %d: sequence number %d, want %d", i, seg.SequenceNumber, want)
+		}
+	}
+}
+
+const singleFileCMAF = `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:4
+#EXT-X-MAP:URI="video.mp4",BYTERANGE="1234@0"
+#EXTINF:4.000,
+#EXT-X-BYTERANGE:50000
+video.mp4
+#EXTINF:4.000,
+#EXT-X-BYTERANGE:60000
+video.mp4
+#EXTINF:4.000,
+#EXT-X-BYTERANGE:55000@111234
+video.mp4
+#EXT-X-ENDLIST
+`
+
+func TestSingleFileByteRange(t *testing.T) {
+	p, err := Decode(strings.NewReader(singleFileCMAF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMap := &Map{URI: "video.mp4", ByteRange: ByteRange{1234, 0}}
+	if !reflect.DeepEqual(p.Segments[0].Map, wantMap) {
+		t.Errorf("first segment map = %v, want %v", p.Segments[0].Map, wantMap)
+	}
+	want := []ByteRange{{50000, 1234}, {60000, 51234}, {55000, 111234}}
+	for i, seg := range p.Segments {
+		if seg.Range != want[i] {
+			t.Errorf("segment %d: range %s, want %s", i, seg.Range, want[i])
+		}
+	}
+
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Decode(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("decode encoded playlist: %v", err)
+	}
+	if !reflect.DeepEqual(again.Segments, p.Segments) {
+		t.Errorf("segments changed after round trip")
+		t.Log(buf.String())
+	}
+
+	orphan := strings.Replace(singleFileCMAF, `URI="video.mp4"`, `URI="init.mp4"`, 1)
+	if _, err := Decode(strings.NewReader(orphan)); err == nil {
+		t.Errorf("nil error decoding implicit offset with no previous sub-range")
+	}
+}
+
+func TestDecodeHeader(t *testing.T) {
+	f, err := os.Open("testdata/media.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	p, err := DecodeHeader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Segments) > 0 {
+		t.Errorf("got %d segments, want 0", len(p.Segments))
+	}
+	if p.Version == 0 {
+		t.Errorf("version not parsed")
+	}
+	if p.TargetDuration == 0 {
+		t.Errorf("target duration not parsed")
+	}
+
+	p, err = DecodeHeader(strings.NewReader(liveSnapshot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Segments) > 0 {
+		t.Errorf("got %d segments, want 0", len(p.Segments))
+	}
+	if p.Sequence != 100 || p.DiscontinuitySequence != 4 {
+		t.Errorf("got sequence %d, discontinuity sequence %d, want 100 and 4", p.Sequence, p.DiscontinuitySequence)
+	}
+}
+
+func TestParseChannels(t *testing.T) {
+	var cases = []struct {
+		in   string
+		want *Channels
+	}{
+		{"2", &Channels{Count: 2}},
+		{"6/JOC", &Channels{Count: 6, CodingIdentifiers: []string{"JOC"}}},
+		{"16/-/BINAURAL,IMMERSIVE", &Channels{Count: 16, CodingIdentifiers: []string{"-"}, Spatial: []string{"BINAURAL", "IMMERSIVE"}}},
+		{"0", nil},
+		{"two", nil},
+	}
+	for _, tt := range cases {
+		got, err := parseChannels(tt.in)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("parseChannels(%q): nil error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseChannels(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseChannels(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if got.String() != tt.in {
+			t.Errorf("channels string = %q, want %q", got.String(), tt.in)
+		}
+	}
+
+	plist := `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="Stereo",URI="stereo.m3u8",CHANNELS="2"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="atmos",NAME="Atmos",URI="atmos.m3u8",CHANNELS="6/JOC"
+`
+	p, err := Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Media[0].Channels.ObjectCoded() {
+		t.Errorf("stereo rendition reported as object coded")
+	}
+	if !p.Media[1].Channels.ObjectCoded() {
+		t.Errorf("atmos rendition not reported as object coded")
+	}
+}
+
+func TestVariantHints(t *testing.T) {
+	plist := `#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=16000000,SCORE=2.5,CODECS="hvc1.2.4.L150.B0",RESOLUTION=3840x2160,VIDEO-RANGE=PQ,STABLE-VARIANT-ID="hdr-2160"
+hdr/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=8000000,RESOLUTION=1920x1080,VIDEO-RANGE=SDR
+sdr/index.m3u8
+`
+	p, err := Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdr := p.Variants[0]
+	if hdr.Score != 2.5 || hdr.VideoRange != VideoRangePQ || hdr.StableID != "hdr-2160" {
+		t.Errorf("unexpected hints: score %v, video range %s, stable id %q", hdr.Score, hdr.VideoRange, hdr.StableID)
+	}
+	if p.Variants[1].VideoRange != VideoRangeSDR {
+		t.Errorf("video range = %s, want %s", p.Variants[1].VideoRange, VideoRangeSDR)
+	}
+	want := `#EXT-X-STREAM-INF:BANDWIDTH=16000000,SCORE=2.5,CODECS="hvc1.2.4.L150.B0",RESOLUTION=3840x2160,VIDEO-RANGE=PQ,STABLE-VARIANT-ID="hdr-2160"
+hdr/index.m3u8`
+	if hdr.String() != want {
+		t.Errorf("unexpected variant text")
+		t.Log("got:", hdr.String())
+		t.Log("want:", want)
+	}
+
+	bad := strings.Replace(plist, "VIDEO-RANGE=PQ", "VIDEO-RANGE=HDR10", 1)
+	if _, err := Decode(strings.NewReader(bad)); err == nil {
+		t.Errorf("nil error decoding unknown video range")
+	}
+}
+
+func TestOnSegment(t *testing.T) {
+	var seen []string
+	d := Decoder{OnSegment: func(seg *Segment) error {
+		seen = append(seen, seg.URI)
+		seg.URI = "https://example.com/" + seg.URI
+		return nil
+	}}
+	p, err := d.Decode(strings.NewReader(liveSnapshot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != len(p.Segments) {
+		t.Fatalf("callback called with %d segments, want %d", len(seen), len(p.Segments))
+	}
+	for i, seg := range p.Segments {
+		if seg.URI != "https://example.com/"+seen[i] {
+			t.Errorf("segment %d: change from callback not kept: got %s", i, seg.URI)
+		}
+	}
+
+	errStop := errors.New("stop")
+	d.OnSegment = func(seg *Segment) error {
+		if seg.SequenceNumber == p.Segments[1].SequenceNumber {
+			return errStop
+		}
+		return nil
+	}
+	p, err = d.Decode(strings.NewReader(liveSnapshot))
+	if !errors.Is(err, errStop) {
+		t.Errorf("got error %v, want %v", err, errStop)
+	}
+	if len(p.Segments) != 1 {
+		t.Errorf("got %d segments after aborting, want 1", len(p.Segments))
+	}
+}
+
+func TestLargeSequenceNumber(t *testing.T) {
+	// beyond the range of a 32-bit int.
+	const first = 1<<40 + 7
+	plist := strings.Replace(liveSnapshot, "#EXT-X-MEDIA-SEQUENCE:100", fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d", int64(first)), 1)
+	p, err := Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Sequence != first {
+		t.Fatalf("media sequence = %d, want %d", p.Sequence, int64(first))
+	}
+	for i, seg := range p.Segments {
+		if want := int64(first + i); seg.SequenceNumber != want {
+			t.Errorf("segment %d: sequence number %d, want %d", i, seg.SequenceNumber, want)
+		}
+	}
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d\n", int64(first))) {
+		t.Errorf("large media sequence not written")
+		t.Log(buf.String())
+	}
+
+	plist = strings.Replace(liveSnapshot, "#EXT-X-MEDIA-SEQUENCE:100", "#EXT-X-MEDIA-SEQUENCE:99999999999999999999", 1)
+	if _, err := Decode(strings.NewReader(plist)); err == nil {
+		t.Errorf("nil error decoding out of range media sequence")
+	}
+}
+
+func TestStartPoint(t *testing.T) {
+	const plist = `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXT-X-START:TIME-OFFSET=%s
+#EXTINF:6.000,
+0.ts
+#EXTINF:6.000,
+1.ts
+#EXTINF:6.000,
+2.ts
+#EXT-X-ENDLIST
+`
+	var tests = []struct {
+		offset string
+		want   time.Duration
+		warn   bool
+	}{
+		{"7.5,PRECISE=YES", 7500 * time.Millisecond, false},
+		{"-6", 12 * time.Second, false},
+		{"-25.5", 0, true},
+		{"30", 18 * time.Second, true},
+	}
+	for _, tt := range tests {
+		var warnings []error
+		d := Decoder{OnWarning: func(err error) { warnings = append(warnings, err) }}
+		p, err := d.Decode(strings.NewReader(fmt.Sprintf(plist, tt.offset)))
+		if err != nil {
+			t.Errorf("decode with offset %s: %v", tt.offset, err)
+			continue
+		}
+		if got := p.StartOffset(); got != tt.want {
+			t.Errorf("start offset %s: got %s, want %s", tt.offset, got, tt.want)
+		}
+		if tt.warn != (len(warnings) > 0) {
+			t.Errorf("start offset %s: got warnings %v, want warning %t", tt.offset, warnings, tt.warn)
+		}
+	}
+
+	p, err := Decode(strings.NewReader(fmt.Sprintf(plist, "7.5,PRECISE=YES")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Start.Precise {
+		t.Errorf("precise start point not parsed")
+	}
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\n#EXT-X-START:TIME-OFFSET=7.5,PRECISE=YES\n") {
+		t.Errorf("start point not written")
+		t.Log(buf.String())
+	}
+}
+
+func TestEndListMidStream(t *testing.T) {
+	const plist = `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXTINF:6.000,
+001.ts
+#EXT-X-ENDLIST
+#EXTINF:6.000,
+002.ts
+#EXTINF:6.000,
+003.ts
+`
+	inSegment := strings.Replace(plist, "#EXT-X-ENDLIST\n", "", 1)
+	inSegment = strings.Replace(inSegment, "#EXTINF:6.000,\n003.ts", "#EXTINF:6.000,\n#EXT-X-ENDLIST\n003.ts", 1)
+	for _, raw := range []string{plist, inSegment} {
+		for _, dec := range []Decoder{{}, {PreserveOrder: true}} {
+			p, err := dec.Decode(strings.NewReader(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !p.End {
+				t.Errorf("playlist not ended")
+			}
+			if len(p.Segments) != 3 {
+				t.Errorf("got %d segments, want 3", len(p.Segments))
+			}
+			buf := &strings.Builder{}
+			if err := Encode(buf, p); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Count(buf.String(), tagEndList) != 1 || !strings.HasSuffix(buf.String(), "003.ts\n"+tagEndList+"\n") {
+				t.Errorf("%s not written once as last line", tagEndList)
+				t.Log("got:", buf.String())
+			}
+		}
+	}
+}
+
+func TestRequireUTF8(t *testing.T) {
+	plist := "#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.000,Caf\xe9\n001.ts\n"
+	p, err := Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatalf("permissive decode: %v", err)
+	}
+	if p.Segments[0].Title != "Caf\xe9" {
+		t.Errorf("latin-1 title changed: got %q", p.Segments[0].Title)
+	}
+
+	_, err = Decoder{RequireUTF8: true}.Decode(strings.NewReader(plist))
+	if err == nil {
+		t.Fatal("nil error decoding invalid UTF-8")
+	}
+	want := "line 3: invalid UTF-8 at byte offset 49"
+	if err.Error() != want {
+		t.Errorf("got error %q, want %q", err, want)
+	}
+
+	valid := strings.Replace(plist, "\xe9", "é", 1)
+	p, err = Decoder{RequireUTF8: true}.Decode(strings.NewReader(valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Segments[0].Title != "Café" {
+		t.Errorf("got title %q, want %q", p.Segments[0].Title, "Café")
+	}
+}
+
+func TestSelectVariant(t *testing.T) {
+	const plist = `#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=16000000,RESOLUTION=3840x2160,HDCP-LEVEL=TYPE-1,ALLOWED-CPC="com.example.drm:HW"
+2160p.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=8000000,RESOLUTION=1920x1080,HDCP-LEVEL=TYPE-0,ALLOWED-CPC="com.example.drm:SW/HW,com.example.other:BASELINE"
+1080p.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=3000000,RESOLUTION=1280x720,HDCP-LEVEL=NONE
+720p.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=1000000,RESOLUTION=640x360
+360p.m3u8
+`
+	p, err := Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCPC := []CPC{
+		{KeyFormat: "com.example.drm", Labels: []string{"SW", "HW"}},
+		{KeyFormat: "com.example.other", Labels: []string{"BASELINE"}},
+	}
+	if !reflect.DeepEqual(p.Variants[1].AllowedCPC, wantCPC) {
+		t.Errorf("got allowed cpc %+v, want %+v", p.Variants[1].AllowedCPC, wantCPC)
+	}
+	if p.Variants[0].HDCP != HDCPType1 || p.Variants[1].HDCP != HDCPType0 || p.Variants[2].HDCP != HDCPNone {
+		t.Errorf("HDCP levels not parsed")
+	}
+
+	var cases = []struct {
+		name string
+		caps DeviceCapabilities
+		want string
+	}{
+		{"type-0 device", DeviceCapabilities{HDCP: HDCPType0}, "1080p.m3u8"},
+		{"type-1 device", DeviceCapabilities{HDCP: HDCPType1}, "2160p.m3u8"},
+		{"no hdcp", DeviceCapabilities{}, "720p.m3u8"},
+		{"type-0 limited bandwidth", DeviceCapabilities{HDCP: HDCPType0, Bandwidth: 5000000}, "720p.m3u8"},
+		{"nothing fits", DeviceCapabilities{HDCP: HDCPType0, Bandwidth: 500000}, "360p.m3u8"},
+	}
+	for _, tt := range cases {
+		v := p.SelectVariant(tt.caps)
+		if v == nil {
+			t.Errorf("%s: no variant selected", tt.name)
+			continue
+		}
+		if v.URI != tt.want {
+			t.Errorf("%s: selected %s, want %s", tt.name, v.URI, tt.want)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `HDCP-LEVEL=TYPE-0,ALLOWED-CPC="com.example.drm:SW/HW,com.example.other:BASELINE"`) {
+		t.Errorf("allowed cpc not written")
+		t.Log("got:", buf.String())
+	}
+
+	bad := strings.Replace(plist, "HDCP-LEVEL=TYPE-0", "HDCP-LEVEL=TYPE-2", 1)
+	if _, err := Decode(strings.NewReader(bad)); err == nil {
+		t.Error("nil error decoding unknown HDCP level")
+	}
+	p.Variants[0].HDCP = HDCPType1 + 1
+	if err := Encode(io.Discard, p); err == nil {
+		t.Error("nil error encoding unknown HDCP level")
+	}
+}
+
+// endlessPlaylist is an infinitely long media playlist.
+type endlessPlaylist struct {
+	buf bytes.Buffer
+	n   int
+}
+
+func (r *endlessPlaylist) Read(b []byte) (int, error) {
+	if r.buf.Len() == 0 {
+		if r.n == 0 {
+			r.buf.WriteString("#EXTM3U\n#EXT-X-TARGETDURATION:2\n")
+		}
+		fmt.Fprintf(&r.buf, "#EXTINF:2.000\n%d.ts\n", r.n)
+		r.n++
+	}
+	return r.buf.Read(b)
+}
+
+func TestDecodeLimits(t *testing.T) {
+	segments := func(n int) string {
+		s := "#EXTM3U\n#EXT-X-TARGETDURATION:2\n"
+		for i := 0; i < n; i++ {
+			s += fmt.Sprintf("#EXTINF:2.000\n%d.ts\n", i)
+		}
+		return s + "#EXT-X-ENDLIST\n"
+	}
+	var cases = []struct {
+		name string
+		d    Decoder
+		in   io.Reader
+		err  error
+	}{
+		{"at segment limit", Decoder{MaxSegments: 10}, strings.NewReader(segments(10)), nil},
+		{"over segment limit", Decoder{MaxSegments: 10}, strings.NewReader(segments(11)), ErrTooManySegments},
+		{"no segment limit", Decoder{MaxSegments: -1}, strings.NewReader(segments(11)), nil},
+		{"at byte limit", Decoder{MaxBytes: int64(len(segments(10)))}, strings.NewReader(segments(10)), nil},
+		{"over byte limit", Decoder{MaxBytes: 100}, strings.NewReader(segments(10)), ErrTooLarge},
+		{"over byte limit preserving order", Decoder{MaxBytes: 100, PreserveOrder: true}, strings.NewReader(segments(10)), ErrTooLarge},
+		{"endless", Decoder{}, &endlessPlaylist{}, ErrTooManySegments},
+		{"endless without segment limit", Decoder{MaxSegments: -1, MaxBytes: 1 << 16}, &endlessPlaylist{}, ErrTooLarge},
+	}
+	for _, tt := range cases {
+		_, err := tt.d.Decode(tt.in)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+// TestDecodeErrorStopsLexer checks that a failed decode does not
+// leave the lexer reading input in the background.
+func TestDecodeErrorStopsLexer(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		in := io.MultiReader(strings.NewReader("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-VERSION:4\n"), &endlessPlaylist{n: 1})
+		if _, err := Decode(in); err == nil {
+			t.Fatal("nil error decoding repeated version")
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines after failed decodes, want %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestResolveRenditions(t *testing.T) {
+	const master = `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",LANGUAGE="en",DEFAULT=YES,URI="en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="Deutsch",LANGUAGE="de",URI="de.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="ac3",NAME="English",LANGUAGE="en",URI="en-ac3.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,AUDIO="aac",CLOSED-CAPTIONS=NONE
+low.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2560000,AUDIO="aac",SUBTITLES="subs"
+mid.m3u8
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English",LANGUAGE="en",URI="subs.m3u8"
+`
+	var warnings []error
+	d := Decoder{OnWarning: func(err error) { warnings = append(warnings, err) }}
+	p, err := d.Decode(strings.NewReader(master))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) > 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	low, mid := p.Variants[0], p.Variants[1]
+	if !low.NoClosedCaptions || low.ClosedCaptions != "" {
+		t.Errorf("got closed captions %q, no closed captions %t, want NONE", low.ClosedCaptions, low.NoClosedCaptions)
+	}
+	if low.ClosedCaptionRenditions() != nil {
+		t.Errorf("got closed caption renditions for NONE")
+	}
+	audio := low.AudioRenditions()
+	if len(audio) != 2 || audio[0].Name != "English" || audio[1].Name != "Deutsch" {
+		t.Errorf("got audio renditions %v, want those of group aac", audio)
+	}
+	if len(low.SubtitleRenditions()) != 0 {
+		t.Errorf("variant without subtitles has subtitle renditions")
+	}
+	// The subtitles rendition follows the variant referring to it.
+	if subs := mid.SubtitleRenditions(); len(subs) != 1 || subs[0].URI != "subs.m3u8" {
+		t.Errorf("got subtitle renditions %v", subs)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "CLOSED-CAPTIONS=NONE\n") {
+		t.Errorf("unquoted NONE not written")
+		t.Log("got:", buf.String())
+	}
+
+	missing := strings.Replace(master, `AUDIO="aac",SUBTITLES`, `AUDIO="opus",SUBTITLES`, 1)
+	warnings = nil
+	if _, err := d.Decode(strings.NewReader(missing)); err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("got %d warnings for a missing audio group, want 1", len(warnings))
+	}
+
+	// A quoted "NONE" is the ID of a group, not the enumerated value.
+	quoted := strings.Replace(master, "CLOSED-CAPTIONS=NONE", `CLOSED-CAPTIONS="NONE"`, 1)
+	warnings = nil
+	p, err = d.Decode(strings.NewReader(quoted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("got %d warnings for a missing closed captions group, want 1", len(warnings))
+	}
+	low = p.Variants[0]
+	if low.NoClosedCaptions || low.ClosedCaptions != "NONE" {
+		t.Errorf("got closed captions %q, no closed captions %t, want group NONE", low.ClosedCaptions, low.NoClosedCaptions)
+	}
+	buf.Reset()
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `CLOSED-CAPTIONS="NONE"`+"\n") {
+		t.Errorf("quoted group id NONE not written")
+		t.Log("got:", buf.String())
+	}
+	unquoted := strings.Replace(master, "CLOSED-CAPTIONS=NONE", "CLOSED-CAPTIONS=cc", 1)
+	if _, err := Decode(strings.NewReader(unquoted)); err == nil {
+		t.Errorf("nil error decoding unquoted group id")
+	}
+}
+
+func TestDefaultMediaSequence(t *testing.T) {
+	const vod = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-TARGETDURATION:6
+#EXT-X-KEY:METHOD=AES-128,URI="key.bin"
+#EXTINF:6.0,
+0.ts
+#EXTINF:6.0,
+1.ts
+#EXTINF:6.0,
+2.ts
+#EXT-X-ENDLIST
+`
+	p, err := Decode(strings.NewReader(vod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Sequence != 0 {
+		t.Errorf("got media sequence %d, want 0", p.Sequence)
+	}
+	for i, seg := range p.Segments {
+		if seg.SequenceNumber != int64(i) {
+			t.Errorf("segment %d: sequence number %d", i, seg.SequenceNumber)
+		}
+		// The IV is the sequence number; RFC 8216 section 5.2.
+		var want [16]byte
+		want[15] = byte(i)
+		if iv := seg.EffectiveIV(); !bytes.Equal(iv, want[:]) {
+			t.Errorf("segment %d: got iv %x, want %x", i, iv, want)
+		}
+	}
+
+	// Misplaced tags still number every segment from the tag's value.
+	late := strings.Replace(vod, "1.ts\n", "1.ts\n#EXT-X-MEDIA-SEQUENCE:10\n", 1)
+	var warnings []error
+	p, err = Decoder{OnWarning: func(err error) { warnings = append(warnings, err) }}.Decode(strings.NewReader(late))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("got %d warnings, want 1 for misplaced %s", len(warnings), tagMediaSequence)
+	}
+	for i, seg := range p.Segments {
+		if want := int64(10 + i); seg.SequenceNumber != want {
+			t.Errorf("segment %d: sequence number %d, want %d", i, seg.SequenceNumber, want)
+		}
+	}
+	if _, err := (Decoder{Strict: true}).Decode(strings.NewReader(late)); err == nil {
+		t.Errorf("nil error strictly decoding misplaced %s", tagMediaSequence)
+	}
+}
+
+func TestDecodeAll(t *testing.T) {
+	const first = `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:100
+#EXTINF:6.000,
+100.ts
+#EXT-X-ENDLIST
+`
+	const second = `#EXTM3U
+#EXT-X-TARGETDURATION:4
+#EXTINF:4.000,
+a.ts
+#EXTINF:4.000,
+b.ts
+`
+	stream := "\n" + first + "\n\n" + second
+	playlists, err := DecodeAll(strings.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(playlists) != 2 {
+		t.Fatalf("got %d playlists, want 2", len(playlists))
+	}
+	if p := playlists[0]; len(p.Segments) != 1 || p.Sequence != 100 || !p.End {
+		t.Errorf("unexpected first playlist")
+		t.Log("got:", p.Dump())
+	}
+	if p := playlists[1]; len(p.Segments) != 2 || p.TargetDuration != 4*time.Second || p.End {
+		t.Errorf("unexpected second playlist")
+		t.Log("got:", p.Dump())
+	}
+
+	bad := first + "#EXTM3U\n#EXT-X-TARGETDURATION:four\n"
+	playlists, err = DecodeAll(strings.NewReader(bad))
+	if err == nil {
+		t.Fatal("nil error decoding bad second playlist")
+	}
+	if !strings.HasPrefix(err.Error(), "playlist 1: ") {
+		t.Errorf("error %q not attributed to playlist 1", err)
+	}
+	if len(playlists) != 1 {
+		t.Errorf("got %d playlists before error, want 1", len(playlists))
+	}
+}
@@ -26,19 +26,16 @@ const (
 
 // parseSegment returns the next segment from items and the leading
 // item which indecated the start of a segment.
-func parseSegment(items chan item, leading item) (*Segment, error) {
+// If the segment has no duration, the segment is returned with a
+// *durationError.
+func parseSegment(items chan item, leading item, d *Decoder) (*Segment, error) {
 	var seg Segment
-	switch leading.typ {
-	case itemTag:
-		switch leading.val {
-		case tagSegmentDuration:
-			it := <-items
-			dur, err := parseSegmentDuration(it)
-			if err != nil {
-				return nil, fmt.Errorf("parse segment duration: %w", err)
-			}
-			seg.Duration = dur
-		}
+	if leading.typ == itemURL {
+		seg.URI = leading.val
+		return &seg, &durationError{leading.val, leading.line}
+	}
+	if err := parseSegmentTag(&seg, items, leading, d); err != nil {
+		return nil, err
 	}
 	for it := range items {
 		if it.typ == itemError {
@@ -47,48 +44,369 @@ func parseSegment(items chan item, leading item) (*Segment, error) {
 		switch it.typ {
 		case itemURL:
 			seg.URI = it.val
+			if seg.Duration == 0 {
+				return &seg, &durationError{it.val, it.line}
+			}
 			return &seg, nil
 		case itemTag:
-			switch it.val {
-			case tagSegmentDuration:
-				it = <-items
-				dur, err := parseSegmentDuration(it)
-				if err != nil {
-					return nil, fmt.Errorf("parse segment duration: %w", err)
-				}
-				seg.Duration = dur
-			case tagByteRange:
-				it = <-items
-				if it.typ != itemString {
-					return nil, fmt.Errorf("parse byte range: got %s, want item type string", it)
-				}
-				r, err := parseByteRange(it.val)
-				if err != nil {
-					return nil, fmt.Errorf("parse byte range: %w", err)
-				}
-				seg.Range = r
-			case tagDiscontinuity:
-				seg.Discontinuity = true
-			case tagKey:
-				return nil, fmt.Errorf("parsing %s unsupported", it)
-			default:
-				return nil, fmt.Errorf("parsing %s unsupported", it)
+			if err := parseSegmentTag(&seg, items, it, d); err != nil {
+				return nil, err
 			}
 		}
 	}
+	if len(seg.Parts) > 0 {
+		// a segment still being produced.
+		return &seg, nil
+	}
 	return nil, fmt.Errorf("no url")
 }
 
+// durationError describes a segment with no duration, usually
+// because its EXTINF tag is missing.
+type durationError struct {
+	uri  string
+	line int // line number of the segment's URI
+}
+
+func (e *durationError) Error() string {
+	return fmt.Sprintf("line %d: segment %s has no duration", e.line, e.uri)
+}
+
+// parseSegmentTag reads the value of the segment tag from items into seg.
+func parseSegmentTag(seg *Segment, items chan item, tag item, d *Decoder) error {
+	switch tag.val {
+	case tagSegmentDuration:
+		it := <-items
+		dur, err := parseSegmentDuration(it)
+		if err != nil {
+			return fmt.Errorf("parse segment duration: %w", err)
+		}
+		seg.Duration = dur
+		if it = <-items; it.typ == itemString {
+			seg.Title = it.val
+		}
+	case tagByteRange:
+		it := <-items
+		if it.typ != itemString && it.typ != itemAttrName && it.typ != itemNumber {
+			return fmt.Errorf("parse byte range: got %s, want item type string", it)
+		}
+		r, err := parseByteRange(it.val)
+		if err != nil {
+			return fmt.Errorf("parse byte range: %w", err)
+		}
+		if !strings.Contains(it.val, "@") {
+			r[1] = implicitOffset
+		}
+		seg.Range = r
+	case tagMap:
+		m, err := parseMap(items)
+		if err != nil {
+			return fmt.Errorf("parse map: %w", err)
+		}
+		seg.Map = m
+	case tagKey:
+		k, err := parseKey(items)
+		if err != nil {
+			return fmt.Errorf("parse key: %w", err)
+		}
+		for _, key := range seg.Keys {
+			if key.format() == k.format() {
+				return fmt.Errorf("parse key: duplicate key format %q", k.format())
+			}
+		}
+		seg.Keys = append(seg.Keys, *k)
+	case tagDiscontinuity:
+		seg.Discontinuity = true
+	case tagGap:
+		seg.Gap = true
+	case tagEndList:
+		// RFC 8216 section 4.3.3.4: the tag may appear anywhere,
+		// even between a segment's tags and its URI.
+		seg.endList = true
+	case tagPart:
+		part, err := parsePart(items)
+		if err != nil {
+			return fmt.Errorf("parse part %d: %w", len(seg.Parts), err)
+		}
+		if part.Range[1] == implicitOffset {
+			if len(seg.Parts) == 0 {
+				return fmt.Errorf("parse part %d: byte range has no offset", len(seg.Parts))
+			}
+			prev := seg.Parts[len(seg.Parts)-1]
+			if prev.URI != part.URI || prev.Range == [2]int{0, 0} {
+				return fmt.Errorf("parse part %d: byte range has no offset, but no previous sub-range of %s", len(seg.Parts), part.URI)
+			}
+			part.Range[1] = prev.Range[1] + prev.Range[0]
+		}
+		seg.Parts = append(seg.Parts, *part)
+	case tagDateRange:
+		dr, err := parseDateRange(items)
+		if err != nil {
+			return fmt.Errorf("parse date range: %w", err)
+		}
+		seg.DateRange = dr
+	case tagDateTime:
+		it := <-items
+		if it.typ != itemString {
+			return fmt.Errorf("parse program date time: got %s, want item type string", it)
+		}
+		t, err := parseDateTime(it.val, d.RelaxedDateTime)
+		if err != nil {
+			return fmt.Errorf("parse program date time: %w", err)
+		}
+		seg.DateTime = t
+	default:
+		if d.customTag(tag.val) {
+			return parseCustomTag(seg, items, tag.val, d.CustomTags[tag.val])
+		}
+		// unknown or unsupported tags, such as vendor tags, are
+		// ignored as in RFC 8216 section 6.3.1.
+	}
+	return nil
+}
+
+// parseCustomTag reads the value of the custom tag from items
+// into seg.Custom using parse.
+func parseCustomTag(seg *Segment, items chan item, tag string, parse TagParser) error {
+	var value string
+	it := <-items
+	switch it.typ {
+	case itemString:
+		value = it.val
+	case itemNewline, itemEOF:
+	case itemError:
+		return errors.New(it.val)
+	default:
+		return fmt.Errorf("parse %s: unexpected %s", tag, it)
+	}
+	values := []string{value}
+	if parse != nil {
+		var err error
+		values, err = parse(value)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", tag, err)
+		}
+	}
+	if seg.Custom == nil {
+		seg.Custom = make(map[string][]string)
+	}
+	seg.Custom[tag] = append(seg.Custom[tag], values...)
+	return nil
+}
+
+func parsePart(items chan item) (*Part, error) {
+	attrs, err := parseAttributeList(items)
+	if err != nil {
+		return nil, err
+	}
+	var part Part
+	for _, attr := range attrs {
+		switch attr.name {
+		case "URI":
+			part.URI, err = unquote(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse uri: %w", err)
+			}
+		case "DURATION":
+			part.Duration, err = parseSegmentDuration(attr.value)
+			if err != nil {
+				return nil, fmt.Errorf("parse duration: %w", err)
+			}
+		case "INDEPENDENT", "GAP":
+			b, err := parseBool(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", attr.name, err)
+			}
+			if attr.name == "INDEPENDENT" {
+				part.Independent = b
+			} else {
+				part.Gap = b
+			}
+		case "BYTERANGE":
+			s, err := unquote(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse byte range: %w", err)
+			}
+			part.Range, err = parseByteRange(s)
+			if err != nil {
+				return nil, fmt.Errorf("parse byte range: %w", err)
+			}
+			if !strings.Contains(s, "@") {
+				part.Range[1] = implicitOffset
+			}
+		default:
+			return nil, fmt.Errorf("unknown attribute %s", attr.name)
+		}
+	}
+	if part.URI == "" {
+		return nil, fmt.Errorf("missing URI")
+	} else if part.Duration == 0 {
+		return nil, fmt.Errorf("missing duration")
+	}
+	return &part, nil
+}
+
+// IsGap reports whether the segment is unavailable, either from
+// an EXT-X-GAP tag or because all of its parts are gaps.
+func (seg *Segment) IsGap() bool {
+	if seg.Gap {
+		return true
+	}
+	if len(seg.Parts) == 0 {
+		return false
+	}
+	for _, part := range seg.Parts {
+		if !part.Gap {
+			return false
+		}
+	}
+	return true
+}
+
+// dateTimeLayouts are tried in turn when parsing date-times.
+// Encoders differ in the number of fractional second digits they write,
+// RFC3339Milli being the most common.
+var dateTimeLayouts = []string{RFC3339Milli, time.RFC3339, time.RFC3339Nano}
+
+// parseDateTime parses s as an RFC 3339 date-time, as required by
+// RFC 8216 section 4.3.2.6. If relaxed is true, the date and time
+// may also be separated by a space.
+func parseDateTime(s string, relaxed bool) (time.Time, error) {
+	v := s
+	if relaxed {
+		v = strings.Replace(v, " ", "T", 1)
+	}
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date-time %q", s)
+}
+
+// implicitOffset marks a parsed ByteRange with no offset,
+// to be resolved once the segment is completely parsed.
+const implicitOffset = -1
+
+// resolveOffset sets the offset of the byte range of seg if it was
+// omitted in the playlist. The range follows the range of the previous
+// segment if it is of the same resource, otherwise m, the map in
+// effect for seg.
+func resolveOffset(seg *Segment, prev *Segment, m *Map) error {
+	if seg.Range[1] != implicitOffset {
+		return nil
+	}
+	if prev != nil && prev.URI == seg.URI && prev.Range != [2]int{0, 0} {
+		seg.Range[1] = prev.Range[1] + prev.Range[0]
+		return nil
+	}
+	if m != nil && m.URI == seg.URI && m.ByteRange != [2]int{0, 0} {
+		seg.Range[1] = m.ByteRange[1] + m.ByteRange[0]
+		return nil
+	}
+	return fmt.Errorf("byte range %d has no offset, but no previous sub-range of %s", seg.Range[0], seg.URI)
+}
+
+func parseMap(items chan item) (*Map, error) {
+	attrs, err := parseAttributeList(items)
+	if err != nil {
+		return nil, err
+	}
+	var m Map
+	for _, attr := range attrs {
+		switch attr.name {
+		case "URI":
+			m.URI, err = unquote(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse uri: %w", err)
+			}
+		case "BYTERANGE":
+			s, err := unquote(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse byte range: %w", err)
+			}
+			// an omitted offset is from the start of the resource.
+			m.ByteRange, err = parseByteRange(s)
+			if err != nil {
+				return nil, fmt.Errorf("parse byte range: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown attribute %s", attr.name)
+		}
+	}
+	if m.URI == "" {
+		return nil, fmt.Errorf("missing URI")
+	}
+	if m.ByteRange != [2]int{0, 0} && m.ByteRange[0] <= 0 {
+		return nil, fmt.Errorf("impossible byte range: non-positive length %d", m.ByteRange[0])
+	}
+	return &m, nil
+}
+
+// wholeSeconds returns the number of seconds in s if s is
+// a whole number of seconds: up to 9 digits, optionally followed
+// by a decimal point and zeroes.
+func wholeSeconds(s string) (int64, bool) {
+	var n int64
+	i := 0
+	for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		if i == 9 {
+			return 0, false
+		}
+		n = n*10 + int64(s[i]-'0')
+	}
+	if i == 0 {
+		return 0, false
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+	}
+	for ; i < len(s); i++ {
+		if s[i] != '0' {
+			return 0, false
+		}
+	}
+	return n, true
+}
+
+// decimalSeconds returns the duration of s if s is a decimal number
+// of seconds: up to 9 digits, a decimal point, then at least one digit.
+// Digits beyond nanosecond precision are truncated.
+func decimalSeconds(s string) (time.Duration, bool) {
+	whole, frac, ok := strings.Cut(s, ".")
+	if !ok || frac == "" {
+		return 0, false
+	}
+	secs, ok := wholeSeconds(whole)
+	if !ok {
+		return 0, false
+	}
+	var ns int64
+	for i := 0; i < len(frac); i++ {
+		if frac[i] < '0' || frac[i] > '9' {
+			return 0, false
+		}
+		if i < 9 {
+			ns = ns*10 + int64(frac[i]-'0')
+		}
+	}
+	for i := len(frac); i < 9; i++ {
+		ns *= 10
+	}
+	return time.Duration(secs)*time.Second + time.Duration(ns), true
+}
+
 func parseSegmentDuration(it item) (time.Duration, error) {
 	if it.typ != itemAttrName && it.typ != itemNumber {
 		return 0, fmt.Errorf("got %s: want attribute name or number", it)
 	}
-	// Some numbers can be converted straight to ints, e.g.:
+	// Most durations are whole seconds, e.g.:
 	// 	10
 	// 	10.000
-	// Others need to be converted from floating point, e.g:
-	// 	9.967
-	// Try the easiest paths first.
+	// so try those first without the cost of ParseFloat.
+	if secs, ok := wholeSeconds(it.val); ok {
+		return time.Duration(secs) * time.Second, nil
+	}
 	if !strings.Contains(it.val, ".") {
 		i, err := strconv.Atoi(it.val)
 		if err != nil {
@@ -96,21 +414,15 @@ func parseSegmentDuration(it item) (time.Duration, error) {
 		}
 		return time.Duration(i) * time.Second, nil
 	}
-	// 10.000
-	before, after, _ := strings.Cut(it.val, ".")
-	var allZeroes = true
-	for r := range after {
-		if r != '0' {
-			allZeroes = false
-		}
-	}
-	if allZeroes {
-		i, err := strconv.Atoi(before)
-		if err != nil {
-			return 0, err
-		}
-		return time.Duration(i) * time.Second, nil
+	// Others have fractional seconds, e.g.:
+	// 	9.967
+	// 	9.9667
+	// which are read exactly rather than through floating point so
+	// that durations derived from a 90KHz clock are not rounded.
+	if d, ok := decimalSeconds(it.val); ok {
+		return d, nil
 	}
+	// Anything else, such as an exponent, is left to ParseFloat.
 	seconds, err := strconv.ParseFloat(it.val, 32)
 	if err != nil {
 		return 0, err
@@ -120,57 +432,196 @@ func parseSegmentDuration(it item) (time.Duration, error) {
 	return time.Duration(microseconds) * time.Microsecond, nil
 }
 
-func writeSegments(w io.Writer, segments []Segment) (n int, err error) {
-	for i, seg := range segments {
-		b, err := seg.MarshalText()
-		if err != nil {
-			return n, fmt.Errorf("segment %d: %w", i, err)
-		}
-		nn, err := fmt.Fprintln(w, string(b))
+func writeSegments(w io.Writer, segments []Segment, precise bool) (n int, err error) {
+	for i := range segments {
+		nn, err := writeSegment(w, &segments[i], precise)
 		n += nn
 		if err != nil {
-			return n, err
+			return n, fmt.Errorf("segment %d: %w", i, err)
 		}
 	}
 	return n, nil
 }
 
 func (seg *Segment) MarshalText() ([]byte, error) {
+	return seg.marshal(false)
+}
+
+// marshal encodes seg, writing its duration precisely if precise is
+// set; see Encoder.PreciseDurations.
+func (seg *Segment) marshal(precise bool) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if len(seg.lines) > 0 {
+		replayLines(buf, seg)
+		return buf.Bytes(), nil
+	}
+	for i, part := range seg.Parts {
+		if part.URI == "" {
+			return nil, fmt.Errorf("part %d: empty URI", i)
+		} else if part.Duration <= 0 {
+			return nil, fmt.Errorf("part %d: non-positive duration", i)
+		}
+		fmt.Fprintln(buf, part)
+	}
+	if seg.URI == "" && len(seg.Parts) > 0 {
+		// segment in progress; only its parts are known.
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+	}
 	if seg.URI == "" {
 		return nil, fmt.Errorf("empty URI")
 	}
 	if seg.Duration == 0 {
 		return nil, fmt.Errorf("zero duration")
 	}
-	var tags []string
+	if strings.ContainsAny(seg.Title, "\r\n") {
+		return nil, fmt.Errorf("title %q contains line break", seg.Title)
+	}
 	if seg.Discontinuity {
-		tags = append(tags, tagDiscontinuity)
+		fmt.Fprintln(buf, tagDiscontinuity)
 	}
 	if seg.DateRange != nil {
-		buf := &bytes.Buffer{}
-		if err := writeDateRange(buf, seg.DateRange); err != nil {
+		if _, err := WriteDateRange(buf, seg.DateRange); err != nil {
 			return nil, fmt.Errorf("write date range: %w", err)
 		}
-		tags = append(tags, buf.String())
 	}
 	if seg.Range != [2]int{0, 0} {
-		if seg.Range[0] >= seg.Range[1] {
-			return nil, fmt.Errorf("impossible range: offset (%d) must be smaller than next %d", seg.Range[0], seg.Range[1])
+		if seg.Range[0] <= 0 {
+			return nil, fmt.Errorf("impossible range: non-positive length %d", seg.Range[0])
+		} else if seg.Range[1] < 0 {
+			return nil, fmt.Errorf("impossible range: negative offset %d", seg.Range[1])
 		}
-		tags = append(tags, fmt.Sprintf("%s:%s", tagByteRange, seg.Range))
+		fmt.Fprintf(buf, "%s:%s\n", tagByteRange, seg.Range)
 	}
-	if seg.Key != nil {
-		tags = append(tags, seg.Key.String())
+	for i, k := range seg.Keys {
+		if _, err := WriteKey(buf, k); err != nil {
+			return nil, fmt.Errorf("write key %d: %w", i, err)
+		}
 	}
 	if seg.Map != nil {
-		tags = append(tags, seg.Map.String())
+		if _, err := WriteMap(buf, *seg.Map); err != nil {
+			return nil, fmt.Errorf("write map: %w", err)
+		}
 	}
 	if !seg.DateTime.IsZero() {
-		tags = append(tags, fmt.Sprintf("%s:%s", tagDateTime, seg.DateTime.Format(RFC3339Milli)))
+		WriteDateTime(buf, seg.DateTime)
+	}
+	if seg.Gap {
+		fmt.Fprintln(buf, tagGap)
+	}
+	fmt.Fprintf(buf, "%s:%s", tagSegmentDuration, formatDuration(seg.Duration, precise))
+	if seg.Title != "" {
+		buf.WriteString("," + seg.Title)
+	}
+	buf.WriteString("\n")
+	buf.WriteString(seg.URI)
+	return buf.Bytes(), nil
+}
+
+// formatDuration formats d as decimal seconds. Unless precise is set,
+// d is rounded to milliseconds.
+func formatDuration(d time.Duration, precise bool) string {
+	if !precise {
+		us := d / time.Microsecond
+		// we do .03f for the same precision as test-streams.mux.dev.
+		return fmt.Sprintf("%.03f", float32(us)/1e6)
+	}
+	frac := strings.TrimRight(fmt.Sprintf("%09d", d%time.Second), "0")
+	for len(frac) < 3 {
+		frac += "0"
+	}
+	return strconv.FormatInt(int64(d/time.Second), 10) + "." + frac
+}
+
+// SegmentBuilder builds a Segment, for example when packaging
+// media. Each method returns the builder so calls can be chained:
+//
+//	seg, err := new(SegmentBuilder).WithURI("001.ts").WithDuration(4 * time.Second).Build()
+//
+// The first invalid value set is reported by Build.
+type SegmentBuilder struct {
+	seg Segment
+	err error
+}
+
+func (b *SegmentBuilder) WithURI(uri string) *SegmentBuilder {
+	if uri == "" && b.err == nil {
+		b.err = fmt.Errorf("empty URI")
+	}
+	b.seg.URI = uri
+	return b
+}
+
+func (b *SegmentBuilder) WithDuration(d time.Duration) *SegmentBuilder {
+	if d <= 0 && b.err == nil {
+		b.err = fmt.Errorf("non-positive duration %s", d)
+	}
+	b.seg.Duration = d
+	return b
+}
+
+func (b *SegmentBuilder) WithDiscontinuity() *SegmentBuilder {
+	b.seg.Discontinuity = true
+	return b
+}
+
+func (b *SegmentBuilder) WithKey(k Key) *SegmentBuilder {
+	if k.Method != EncryptMethodNone && k.URI == "" && b.err == nil {
+		b.err = fmt.Errorf("key: empty URI with method %s", k.Method)
+	}
+	b.seg.Keys = append(b.seg.Keys, k)
+	return b
+}
+
+// Build returns the built segment, or an error if the segment is invalid.
+func (b *SegmentBuilder) Build() (Segment, error) {
+	if b.err != nil {
+		return Segment{}, b.err
+	}
+	if b.seg.URI == "" {
+		return Segment{}, fmt.Errorf("empty URI")
+	} else if b.seg.Duration == 0 {
+		return Segment{}, fmt.Errorf("zero duration")
+	}
+	return b.seg, nil
+}
+
+// Append adds seg to the end of the playlist. The playlist's
+// TargetDuration is increased if needed to cover the segment's
+// duration, rounded to the nearest second.
+// Segments cannot be added to a playlist which has ended.
+func (p *Playlist) Append(seg Segment) error {
+	if p.End {
+		return fmt.Errorf("playlist has ended")
+	}
+	if seg.URI == "" {
+		return fmt.Errorf("empty URI")
+	} else if seg.Duration <= 0 {
+		return fmt.Errorf("non-positive duration %s", seg.Duration)
+	}
+	if d := seg.Duration.Round(time.Second); d > p.TargetDuration {
+		p.TargetDuration = d
+	}
+	seg.SequenceNumber = p.Sequence + int64(len(p.Segments))
+	p.Segments = append(p.Segments, seg)
+	return nil
+}
+
+// Periods groups the playlist's segments into periods separated by
+// discontinuities. Segments in a period share a timeline and encoding
+// parameters, so a player need only reset its decoder at the start of
+// each period. A segment with Discontinuity set starts a new period.
+// The returned slices share memory with p.Segments.
+func (p *Playlist) Periods() [][]Segment {
+	var periods [][]Segment
+	start := 0
+	for i := range p.Segments {
+		if p.Segments[i].Discontinuity && i > start {
+			periods = append(periods, p.Segments[start:i])
+			start = i
+		}
+	}
+	if start < len(p.Segments) {
+		periods = append(periods, p.Segments[start:])
 	}
-	us := seg.Duration / time.Microsecond
-	// we do .03f for the same precision as test-streams.mux.dev.
-	tags = append(tags, fmt.Sprintf("%s:%.03f", tagSegmentDuration, float32(us)/1e6))
-	tags = append(tags, seg.URI)
-	return []byte(strings.Join(tags, "\n")), nil
+	return periods
 }
@@ -1,7 +1,12 @@
 package m3u8
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -64,7 +69,8 @@ func TestMarshalBadSegments(t *testing.T) {
 	}{
 		{"empty", Segment{}},
 		{"no duration", Segment{URI: "video.ts"}},
-		{"impossible range", Segment{URI: "bbb.ts", Duration: 6 * time.Second, Range: ByteRange{999, 10}}},
+		{"impossible range", Segment{URI: "bbb.ts", Duration: 6 * time.Second, Range: ByteRange{0, 10}}},
+		{"negative offset", Segment{URI: "bbb.ts", Duration: 6 * time.Second, Range: ByteRange{999, -10}}},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -82,7 +88,7 @@ func TestWriteKey(t *testing.T) {
 	k := Key{
 		Method:         EncryptMethodAES128,
 		URI:            "magic.key",
-		IV:             iv,
+		IV:             &iv,
 		Format:         defaultKeyFormat,
 		FormatVersions: []uint32{1, 2, 5},
 	}
@@ -93,3 +99,385 @@ func TestWriteKey(t *testing.T) {
 		t.Log("want:", want)
 	}
 }
+
+func TestWriteKeyNone(t *testing.T) {
+	k := Key{Method: EncryptMethodNone, URI: "ignored.key", Format: defaultKeyFormat}
+	buf := &bytes.Buffer{}
+	if _, err := WriteKey(buf, k); err != nil {
+		t.Fatal(err)
+	}
+	want := "#EXT-X-KEY:METHOD=NONE\n"
+	if buf.String() != want {
+		t.Errorf("WriteKey(%v) = %q, want %q", k, buf.String(), want)
+	}
+	if _, err := WriteKey(buf, Key{Method: EncryptMethodAES128}); err == nil {
+		t.Errorf("nil error writing AES-128 key with empty URI")
+	}
+}
+
+func TestSegmentBuilder(t *testing.T) {
+	p := &Playlist{Version: 3, Type: PlaylistVOD}
+	for i := 0; i < 10; i++ {
+		builder := new(SegmentBuilder).
+			WithURI(fmt.Sprintf("%03d.ts", i)).
+			WithDuration(4 * time.Second)
+		if i == 5 {
+			builder.WithDiscontinuity().WithDuration(6006 * time.Millisecond)
+		}
+		seg, err := builder.Build()
+		if err != nil {
+			t.Fatalf("build segment %d: %v", i, err)
+		}
+		if err := p.Append(seg); err != nil {
+			t.Fatalf("append segment %d: %v", i, err)
+		}
+	}
+	if p.TargetDuration != 6*time.Second {
+		t.Errorf("target duration %s, want %s", p.TargetDuration, 6*time.Second)
+	}
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), tagSegmentDuration); n != 10 {
+		t.Errorf("encoded %d segments, want 10", n)
+	}
+	if !strings.Contains(buf.String(), "#EXT-X-DISCONTINUITY\n#EXTINF:6.006\n005.ts\n") {
+		t.Errorf("discontinuous segment not encoded")
+		t.Log(buf.String())
+	}
+
+	p.End = true
+	if err := p.Append(Segment{URI: "late.ts", Duration: time.Second}); err == nil {
+		t.Errorf("nil error appending segment to ended playlist")
+	}
+}
+
+func TestBuildBadSegment(t *testing.T) {
+	if _, err := new(SegmentBuilder).WithURI("a.ts").WithDuration(0).Build(); err == nil {
+		t.Errorf("nil error building segment with zero duration")
+	}
+	if _, err := new(SegmentBuilder).WithDuration(time.Second).Build(); err == nil {
+		t.Errorf("nil error building segment with no URI")
+	}
+	if _, err := new(SegmentBuilder).WithURI("a.ts").WithDuration(time.Second).WithKey(Key{Method: EncryptMethodAES128}).Build(); err == nil {
+		t.Errorf("nil error building segment with invalid key")
+	}
+}
+
+func TestPeriods(t *testing.T) {
+	plist := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:6
+#EXTINF:6.000,
+content0.ts
+#EXTINF:6.000,
+content1.ts
+#EXT-X-DISCONTINUITY
+#EXTINF:6.000,
+ad0.ts
+#EXT-X-DISCONTINUITY
+#EXTINF:6.000,
+content2.ts
+#EXTINF:6.000,
+content3.ts
+#EXT-X-ENDLIST
+`
+	p, err := Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	periods := p.Periods()
+	want := [][]string{
+		{"content0.ts", "content1.ts"},
+		{"ad0.ts"},
+		{"content2.ts", "content3.ts"},
+	}
+	if len(periods) != len(want) {
+		t.Fatalf("got %d periods, want %d", len(periods), len(want))
+	}
+	for i := range periods {
+		var uris []string
+		for _, seg := range periods[i] {
+			uris = append(uris, seg.URI)
+		}
+		if !reflect.DeepEqual(uris, want[i]) {
+			t.Errorf("period %d: got %v, want %v", i, uris, want[i])
+		}
+	}
+}
+
+func TestParseDateTime(t *testing.T) {
+	var cases = []struct {
+		in      string
+		relaxed bool
+		want    time.Time
+		err     bool
+	}{
+		{"2010-02-19T14:54:23Z", false, time.Date(2010, 2, 19, 14, 54, 23, 0, time.UTC), false},
+		{"2010-02-19T14:54:23.031Z", false, time.Date(2010, 2, 19, 14, 54, 23, 31e6, time.UTC), false},
+		{"2010-02-19T14:54:23.123456789Z", false, time.Date(2010, 2, 19, 14, 54, 23, 123456789, time.UTC), false},
+		{"2010-02-19 14:54:23.031Z", true, time.Date(2010, 2, 19, 14, 54, 23, 31e6, time.UTC), false},
+		{"2010-02-19 14:54:23.031Z", false, time.Time{}, true},
+		{"yesterday", true, time.Time{}, true},
+	}
+	for _, tt := range cases {
+		got, err := parseDateTime(tt.in, tt.relaxed)
+		if tt.err {
+			if err == nil {
+				t.Errorf("parseDateTime(%q, %v): nil error", tt.in, tt.relaxed)
+			} else if !strings.Contains(err.Error(), tt.in) {
+				t.Errorf("error %q does not contain original input", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseDateTime(%q, %v): %v", tt.in, tt.relaxed, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseDateTime(%q, %v) = %s, want %s", tt.in, tt.relaxed, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeDateTime(t *testing.T) {
+	plist := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:6
+#EXT-X-PROGRAM-DATE-TIME:2010-02-19 14:54:23.031+08:00
+#EXTINF:6.000,
+0.ts
+#EXTINF:6.000,
+1.ts
+`
+	if _, err := Decode(strings.NewReader(plist)); err == nil {
+		t.Errorf("nil error decoding space-separated date-time")
+	}
+	p, err := Decoder{RelaxedDateTime: true}.Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2010, 2, 19, 6, 54, 23, 31e6, time.UTC)
+	if !p.Segments[0].DateTime.Equal(want) {
+		t.Errorf("segment date time = %s, want %s", p.Segments[0].DateTime, want)
+	}
+	if !p.Segments[1].DateTime.IsZero() {
+		t.Errorf("second segment has date time %s, want none", p.Segments[1].DateTime)
+	}
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "#EXT-X-PROGRAM-DATE-TIME:2010-02-19T14:54:23.031+08:00\n") {
+		t.Errorf("date time not written in canonical form")
+		t.Log(buf.String())
+	}
+}
+
+const lowLatency = `#EXTM3U
+#EXT-X-VERSION:9
+#EXT-X-TARGETDURATION:4
+#EXT-X-PART-INF:PART-TARGET=1.0
+#EXT-X-MEDIA-SEQUENCE:20
+#EXT-X-PART:DURATION=1.0,URI="20.0.mp4",INDEPENDENT=YES
+#EXT-X-PART:DURATION=1.0,URI="20.1.mp4"
+#EXT-X-PART:DURATION=1.0,URI="20.2.mp4",GAP=YES
+#EXTINF:3.000,
+20.mp4
+#EXT-X-PART:DURATION=1.0,URI="21.0.mp4",GAP=YES
+#EXT-X-PART:DURATION=1.0,URI="21.1.mp4",GAP=YES
+#EXTINF:2.000,
+21.mp4
+#EXT-X-GAP
+#EXTINF:3.000,
+22.mp4
+#EXT-X-PART:DURATION=1.0,URI="23.0.mp4",INDEPENDENT=YES
+`
+
+func TestParseParts(t *testing.T) {
+	p, err := Decode(strings.NewReader(lowLatency))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Segments) != 4 {
+		t.Fatalf("got %d segments, want 4", len(p.Segments))
+	}
+	first := p.Segments[0]
+	if len(first.Parts) != 3 {
+		t.Fatalf("got %d parts in first segment, want 3", len(first.Parts))
+	}
+	if !first.Parts[0].Independent || first.Parts[1].Gap || !first.Parts[2].Gap {
+		t.Errorf("unexpected parts in first segment: %+v", first.Parts)
+	}
+	if first.IsGap() {
+		t.Errorf("first segment is gap, but has available parts")
+	}
+	if !p.Segments[1].IsGap() {
+		t.Errorf("segment of only gap parts should be a gap")
+	}
+	if !p.Segments[2].Gap || !p.Segments[2].IsGap() {
+		t.Errorf("segment with EXT-X-GAP should be a gap")
+	}
+	last := p.Segments[3]
+	if last.URI != "" || len(last.Parts) != 1 {
+		t.Errorf("want in-progress segment with 1 part, got URI %q with %d parts", last.URI, len(last.Parts))
+	}
+
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Decode(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("decode encoded playlist: %v", err)
+	}
+	if !reflect.DeepEqual(again.Segments, p.Segments) {
+		t.Errorf("segments changed after round trip")
+		t.Log(buf.String())
+	}
+}
+
+func TestCustomTags(t *testing.T) {
+	const plist = `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXTINF:10.000,
+0.ts
+#EXT-X-CUE-OUT:30.000
+#EXTINF:10.000,
+ad0.ts
+#EXT-X-CUE-OUT-CONT:ElapsedTime=10,Duration=30
+#EXTINF:10.000,
+ad1.ts
+#EXT-X-CUE-IN
+#EXTINF:10.000,
+1.ts
+`
+	d := Decoder{CustomTags: map[string]TagParser{
+		"#EXT-X-CUE-OUT":      nil,
+		"#EXT-X-CUE-OUT-CONT": func(v string) ([]string, error) { return strings.Split(v, ","), nil },
+		"#EXT-X-CUE-IN":       nil,
+	}}
+	p, err := d.Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Segments) != 4 {
+		t.Fatalf("got %d segments, want 4", len(p.Segments))
+	}
+	want := []map[string][]string{
+		nil,
+		{"#EXT-X-CUE-OUT": {"30.000"}},
+		{"#EXT-X-CUE-OUT-CONT": {"ElapsedTime=10", "Duration=30"}},
+		{"#EXT-X-CUE-IN": {""}},
+	}
+	for i := range want {
+		if !reflect.DeepEqual(p.Segments[i].Custom, want[i]) {
+			t.Errorf("segment %d custom = %v, want %v", i, p.Segments[i].Custom, want[i])
+		}
+	}
+
+	d.CustomTags["#EXT-X-CUE-OUT"] = func(v string) ([]string, error) {
+		return nil, fmt.Errorf("bad cue")
+	}
+	if _, err := d.Decode(strings.NewReader(plist)); err == nil {
+		t.Errorf("nil error from failing tag parser")
+	}
+	p, err = Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Segments[1].Custom != nil {
+		t.Errorf("undeclared custom tag read into segment")
+	}
+}
+
+func TestMissingDuration(t *testing.T) {
+	const plist = `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXTINF:5.000,
+0.ts
+1.ts
+#EXT-X-DISCONTINUITY
+2.ts
+`
+	var warnings []error
+	d := Decoder{OnWarning: func(err error) { warnings = append(warnings, err) }}
+	p, err := d.Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Segments) != 3 {
+		t.Fatalf("got %d segments, want 3", len(p.Segments))
+	}
+	want := []time.Duration{5 * time.Second, 6 * time.Second, 6 * time.Second}
+	for i, seg := range p.Segments {
+		if seg.Duration != want[i] {
+			t.Errorf("segment %d: duration %s, want %s", i, seg.Duration, want[i])
+		}
+	}
+	if !p.Segments[2].Discontinuity {
+		t.Errorf("tags of segment without duration not kept")
+	}
+	t.Log("got:", warnings)
+	if len(warnings) != 2 {
+		t.Errorf("got %d warnings, want 2", len(warnings))
+	}
+	if err := Encode(io.Discard, p); err != nil {
+		t.Errorf("encode playlist with default durations: %v", err)
+	}
+
+	d = Decoder{Strict: true}
+	_, err = d.Decode(strings.NewReader(plist))
+	if err == nil {
+		t.Fatal("nil error decoding segment with no duration in strict mode")
+	}
+	if !strings.Contains(err.Error(), "line 5") {
+		t.Errorf("error %q does not name line 5", err)
+	}
+}
+
+func TestSegmentTitle(t *testing.T) {
+	const plist = `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXTINF:9.500,Episode 1, Part 2, Director's Cut
+001.ts
+#EXTINF:10,
+002.ts
+#EXTINF:10
+003.ts
+`
+	p, err := Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Segments) != 3 {
+		t.Fatalf("got %d segments, want 3", len(p.Segments))
+	}
+	seg := p.Segments[0]
+	if seg.Duration != 9500*time.Millisecond {
+		t.Errorf("got duration %s, want 9.5s", seg.Duration)
+	}
+	want := "Episode 1, Part 2, Director's Cut"
+	if seg.Title != want {
+		t.Errorf("got title %q, want %q", seg.Title, want)
+	}
+	for _, seg := range p.Segments[1:] {
+		if seg.Title != "" || seg.Duration != 10*time.Second {
+			t.Errorf("segment %s: got title %q, duration %s", seg.URI, seg.Title, seg.Duration)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "#EXTINF:9.500,"+want+"\n001.ts") {
+		t.Errorf("title not written")
+		t.Log("got:", buf.String())
+	}
+	seg.Title = "two\nlines"
+	if _, err := seg.MarshalText(); err == nil {
+		t.Errorf("nil error marshalling title with line break")
+	}
+}
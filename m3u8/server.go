@@ -0,0 +1,127 @@
+package m3u8
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tagServerControl = "#EXT-X-SERVER-CONTROL" // draft-pantos-hls-rfc8216bis, 4.4.3.8
+
+// ServerControl represents the EXT-X-SERVER-CONTROL tag of a media
+// playlist, through which a server advertises the Low-Latency HLS
+// delivery directives it supports.
+type ServerControl struct {
+	// CanSkipUntil, if non-zero, indicates the server can produce
+	// playlist delta updates skipping segments older than this
+	// from the end of the playlist.
+	CanSkipUntil time.Duration
+	// CanSkipDateRanges indicates delta updates may also skip
+	// date ranges. It requires CanSkipUntil.
+	CanSkipDateRanges bool
+	// HoldBack is the recommended minimum distance from the end
+	// of the playlist at which to start playback.
+	HoldBack time.Duration
+	// PartHoldBack is like HoldBack but for playback using parts.
+	PartHoldBack time.Duration
+	// CanBlockReload indicates the server supports blocking
+	// playlist reloads. See Client.ReloadNext.
+	CanBlockReload bool
+}
+
+func (sc ServerControl) String() string {
+	var attrs []string
+	if sc.CanSkipUntil > 0 {
+		attrs = append(attrs, "CAN-SKIP-UNTIL="+formatDuration(sc.CanSkipUntil, true))
+	}
+	if sc.CanSkipDateRanges {
+		attrs = append(attrs, "CAN-SKIP-DATERANGES=YES")
+	}
+	if sc.HoldBack > 0 {
+		attrs = append(attrs, "HOLD-BACK="+formatDuration(sc.HoldBack, true))
+	}
+	if sc.PartHoldBack > 0 {
+		attrs = append(attrs, "PART-HOLD-BACK="+formatDuration(sc.PartHoldBack, true))
+	}
+	if sc.CanBlockReload {
+		attrs = append(attrs, "CAN-BLOCK-RELOAD=YES")
+	}
+	return tagServerControl + ":" + strings.Join(attrs, ",")
+}
+
+func parseServerControl(items chan item) (*ServerControl, error) {
+	attrs, err := parseAttributeList(items)
+	if err != nil {
+		return nil, err
+	}
+	var sc ServerControl
+	for _, attr := range attrs {
+		switch attr.name {
+		case "CAN-SKIP-UNTIL":
+			sc.CanSkipUntil, err = parseSegmentDuration(attr.value)
+		case "HOLD-BACK":
+			sc.HoldBack, err = parseSegmentDuration(attr.value)
+		case "PART-HOLD-BACK":
+			sc.PartHoldBack, err = parseSegmentDuration(attr.value)
+		case "CAN-SKIP-DATERANGES":
+			sc.CanSkipDateRanges, err = parseBool(attr.value.val)
+		case "CAN-BLOCK-RELOAD":
+			sc.CanBlockReload, err = parseBool(attr.value.val)
+		default:
+			return nil, fmt.Errorf("unknown attribute %s", attr.name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", attr.name, err)
+		}
+	}
+	if sc.CanSkipDateRanges && sc.CanSkipUntil == 0 {
+		return nil, fmt.Errorf("CAN-SKIP-DATERANGES without CAN-SKIP-UNTIL")
+	}
+	return &sc, nil
+}
+
+// nextPart returns the media sequence number and part index of the
+// segment or part expected to follow the last one of p, for a
+// blocking playlist reload. If the last segment is still being
+// produced, as it has parts but no URI, the next is its following
+// part. hasPart is false if p has no parts, in which case only whole
+// segments should be requested.
+func (p *Playlist) nextPart() (msn int64, part int, hasPart bool) {
+	if len(p.Segments) == 0 {
+		return p.Sequence, 0, false
+	}
+	last := p.Segments[len(p.Segments)-1]
+	if last.URI == "" && len(last.Parts) > 0 {
+		return last.SequenceNumber, len(last.Parts), true
+	}
+	for i := range p.Segments {
+		if len(p.Segments[i].Parts) > 0 {
+			hasPart = true
+			break
+		}
+	}
+	return last.SequenceNumber + 1, 0, hasPart
+}
+
+// blockingReloadURL returns rawURL with the _HLS_msn and _HLS_part
+// query parameters requesting the playlist once the segment or part
+// following the last of p is available, as specified in
+// draft-pantos-hls-rfc8216bis section 6.2.5.2.
+func blockingReloadURL(rawURL string, p *Playlist) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	msn, part, hasPart := p.nextPart()
+	q := u.Query()
+	q.Set("_HLS_msn", strconv.FormatInt(msn, 10))
+	if hasPart {
+		q.Set("_HLS_part", strconv.Itoa(part))
+	} else {
+		q.Del("_HLS_part")
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
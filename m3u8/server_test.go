@@ -0,0 +1,166 @@
+package m3u8
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestServerControl(t *testing.T) {
+	const tag = "#EXT-X-SERVER-CONTROL:CAN-SKIP-UNTIL=12.000,PART-HOLD-BACK=1.002,CAN-BLOCK-RELOAD=YES"
+	in := "#EXTM3U\n#EXT-X-TARGETDURATION:4\n" + tag + "\n#EXTINF:4.000,\n0.ts\n"
+	p, err := Decode(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ServerControl{CanSkipUntil: 12 * time.Second, PartHoldBack: 1002 * time.Millisecond, CanBlockReload: true}
+	if p.ServerControl == nil || *p.ServerControl != want {
+		t.Fatalf("got server control %+v, want %+v", p.ServerControl, want)
+	}
+	buf := &bytes.Buffer{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), tag+"\n") {
+		t.Errorf("encoded playlist missing %s", tag)
+		t.Log("got:", buf.String())
+	}
+
+	for _, bad := range []string{
+		"#EXT-X-SERVER-CONTROL:CAN-SKIP-DATERANGES=YES",
+		"#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=1",
+		"#EXT-X-SERVER-CONTROL:HOLD-BACK=soon",
+	} {
+		in := "#EXTM3U\n#EXT-X-TARGETDURATION:4\n" + bad + "\n#EXTINF:4.000,\n0.ts\n"
+		if _, err := Decode(strings.NewReader(in)); err == nil {
+			t.Errorf("nil error decoding %s", bad)
+		}
+	}
+}
+
+func TestBlockingReloadURL(t *testing.T) {
+	segs := []Segment{
+		{URI: "10.ts", SequenceNumber: 10},
+		{URI: "11.ts", SequenceNumber: 11},
+	}
+	parts := []Part{{URI: "12.0.ts"}, {URI: "12.1.ts"}}
+	var tests = []struct {
+		name string
+		url  string
+		p    *Playlist
+		want string
+	}{
+		{"empty", "http://example.com/live.m3u8", &Playlist{Sequence: 7}, "http://example.com/live.m3u8?_HLS_msn=7"},
+		{"segments", "http://example.com/live.m3u8", &Playlist{Segments: segs}, "http://example.com/live.m3u8?_HLS_msn=12"},
+		{
+			"in progress",
+			"http://example.com/live.m3u8",
+			&Playlist{Segments: append(segs, Segment{SequenceNumber: 12, Parts: parts})},
+			"http://example.com/live.m3u8?_HLS_msn=12&_HLS_part=2",
+		},
+		{
+			"complete with parts",
+			"http://example.com/live.m3u8?token=abc&_HLS_part=9",
+			&Playlist{Segments: append(segs, Segment{URI: "12.ts", SequenceNumber: 12, Parts: parts})},
+			"http://example.com/live.m3u8?_HLS_msn=13&_HLS_part=0&token=abc",
+		},
+	}
+	for _, tt := range tests {
+		got, err := blockingReloadURL(tt.url, tt.p)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// livePlaylist returns a live playlist supporting blocking reloads
+// whose last segment has media sequence number last.
+func livePlaylist(last int) string {
+	buf := &strings.Builder{}
+	fmt.Fprintln(buf, "#EXTM3U")
+	fmt.Fprintln(buf, "#EXT-X-TARGETDURATION:6")
+	fmt.Fprintln(buf, "#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES")
+	fmt.Fprintf(buf, "#EXT-X-MEDIA-SEQUENCE:%d\n", last-2)
+	for i := last - 2; i <= last; i++ {
+		fmt.Fprintf(buf, "#EXTINF:6.000,\n%d.ts\n", i)
+	}
+	return buf.String()
+}
+
+func TestReloadNext(t *testing.T) {
+	var mu sync.Mutex
+	last := 102
+	published := make(chan struct{})
+	var queries []string
+	var blocked bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		queries = append(queries, req.URL.RawQuery)
+		mu.Unlock()
+		if s := req.URL.Query().Get("_HLS_msn"); s != "" {
+			msn, err := strconv.Atoi(s)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			available := msn <= last
+			mu.Unlock()
+			if !available {
+				blocked = true
+				select {
+				case <-published:
+				case <-req.Context().Done():
+					return
+				}
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write([]byte(livePlaylist(last)))
+	}))
+	defer srv.Close()
+
+	client := &Client{HTTPClient: srv.Client()}
+	url := srv.URL + "/live.m3u8"
+	p, _, err := client.Reload(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(50*time.Millisecond, func() {
+		mu.Lock()
+		last++
+		mu.Unlock()
+		close(published)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	next, modified, err := client.ReloadNext(ctx, url, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified {
+		t.Errorf("blocking reload reported unmodified playlist")
+	}
+	if !blocked {
+		t.Errorf("server did not block the reload")
+	}
+	if n := len(next.Segments); n != 3 || next.Segments[n-1].SequenceNumber != 103 {
+		t.Errorf("blocking reload did not return segment 103")
+		t.Log("got:", next.Dump())
+	}
+	if len(queries) != 2 || queries[0] != "" || queries[1] != "_HLS_msn=103" {
+		t.Errorf("got request queries %q, want %q", queries, []string{"", "_HLS_msn=103"})
+	}
+}
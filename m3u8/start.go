@@ -0,0 +1,108 @@
+package m3u8
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const tagStartPoint = "#EXT-X-START" // RFC 8216, 4.3.5.2
+
+// StartPoint represents the EXT-X-START tag, indicating a preferred
+// point at which to start playing the playlist.
+type StartPoint struct {
+	// Offset is the number of seconds from the beginning of the
+	// playlist, or from the end of the playlist if negative.
+	// See Playlist.StartOffset.
+	Offset float32
+	// Precise indicates players should start at exactly Offset,
+	// rather than at the start of the segment containing it.
+	Precise bool
+}
+
+func (sp StartPoint) String() string {
+	s := tagStartPoint + ":TIME-OFFSET=" + strconv.FormatFloat(float64(sp.Offset), 'f', -1, 32)
+	if sp.Precise {
+		s += ",PRECISE=YES"
+	}
+	return s
+}
+
+func parseStartPoint(items chan item) (*StartPoint, error) {
+	attrs, err := parseAttributeList(items)
+	if err != nil {
+		return nil, err
+	}
+	var sp StartPoint
+	var offset bool
+	for _, attr := range attrs {
+		switch attr.name {
+		case "TIME-OFFSET":
+			f, err := strconv.ParseFloat(attr.value.val, 32)
+			if err != nil {
+				return nil, fmt.Errorf("parse time offset: %w", err)
+			}
+			sp.Offset = float32(f)
+			offset = true
+		case "PRECISE":
+			sp.Precise, err = parseBool(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse precise: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown attribute %s", attr.name)
+		}
+	}
+	if !offset {
+		return nil, fmt.Errorf("missing time offset")
+	}
+	return &sp, nil
+}
+
+// Duration returns the sum of the durations of the playlist's segments.
+func (p *Playlist) Duration() time.Duration {
+	var total time.Duration
+	for _, seg := range p.Segments {
+		total += seg.Duration
+	}
+	return total
+}
+
+// StartOffset returns the preferred point to start playing the
+// playlist as the time from the beginning of the playlist.
+// As specified in RFC 8216 section 4.3.5.2, an offset beyond the
+// duration of the playlist indicates its end if positive, or its
+// beginning if negative, so the offset is clamped to the duration.
+// StartOffset returns zero if p has no start point.
+func (p *Playlist) StartOffset() time.Duration {
+	if p.Start == nil {
+		return 0
+	}
+	total := p.Duration()
+	offset := time.Duration(float64(p.Start.Offset) * float64(time.Second))
+	if offset < 0 {
+		offset += total
+	}
+	if offset < 0 {
+		return 0
+	} else if offset > total {
+		return total
+	}
+	return offset
+}
+
+// checkStartPoint returns an error if the start point of p is beyond
+// the duration of its segments.
+func checkStartPoint(p *Playlist) error {
+	if p.Start == nil || len(p.Segments) == 0 {
+		return nil
+	}
+	total := p.Duration()
+	offset := time.Duration(float64(p.Start.Offset) * float64(time.Second))
+	if offset > total {
+		return fmt.Errorf("start offset %s beyond playlist duration %s: using end of playlist", offset, total)
+	} else if -offset > total {
+		return fmt.Errorf("start offset %s beyond playlist duration %s: using beginning of playlist", offset, total)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package m3u8
+
+import (
+	"fmt"
+	"strings"
+)
+
+const tagContentSteering = "#EXT-X-CONTENT-STEERING"
+
+// DefaultPathway is the pathway of a Variant with no Pathway set.
+const DefaultPathway = "."
+
+// ContentSteering represents the EXT-X-CONTENT-STEERING tag of a
+// master playlist, through which a steering server may direct
+// players between pathways, such as those of different CDNs.
+type ContentSteering struct {
+	// ServerURI locates the steering manifest. It is required.
+	ServerURI string
+	// Pathway is the ID of the pathway to use until the steering
+	// manifest is loaded, from the PATHWAY-ID attribute.
+	// If empty, players may choose any pathway.
+	Pathway string
+}
+
+func (cs ContentSteering) String() string {
+	attrs := []string{fmt.Sprintf("SERVER-URI=%q", cs.ServerURI)}
+	if cs.Pathway != "" {
+		attrs = append(attrs, fmt.Sprintf("PATHWAY-ID=%q", cs.Pathway))
+	}
+	return tagContentSteering + ":" + strings.Join(attrs, ",")
+}
+
+func parseContentSteering(items chan item) (*ContentSteering, error) {
+	attrs, err := parseAttributeList(items)
+	if err != nil {
+		return nil, err
+	}
+	var cs ContentSteering
+	for _, attr := range attrs {
+		switch attr.name {
+		case "SERVER-URI":
+			cs.ServerURI, err = unquote(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse server uri: %w", err)
+			}
+		case "PATHWAY-ID":
+			cs.Pathway, err = unquote(attr.value.val)
+			if err != nil {
+				return nil, fmt.Errorf("parse pathway id: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown attribute %s", attr.name)
+		}
+	}
+	if cs.ServerURI == "" {
+		return nil, fmt.Errorf("missing server uri")
+	}
+	return &cs, nil
+}
+
+// pathway returns the pathway of v, which is DefaultPathway if unset.
+func (v Variant) pathway() string {
+	if v.Pathway == "" {
+		return DefaultPathway
+	}
+	return v.Pathway
+}
+
+// validPathwayID reports whether id only contains the characters
+// permitted in a pathway ID: letters, digits, '.', '-' and '_'.
+func validPathwayID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// checkPathways reports an error if p has content steering and
+// any pathway ID it references is not declared by a Variant.
+func checkPathways(p *Playlist) error {
+	if p.Steering == nil {
+		return nil
+	}
+	pathways := make(map[string]bool)
+	for _, v := range p.Variants {
+		id := v.pathway()
+		if !validPathwayID(id) {
+			return fmt.Errorf("variant %s: invalid pathway id %q", v.URI, id)
+		}
+		pathways[id] = true
+	}
+	if p.Steering.Pathway != "" && !pathways[p.Steering.Pathway] {
+		return fmt.Errorf("content steering: pathway %q not used by any variant", p.Steering.Pathway)
+	}
+	for _, r := range p.Media {
+		if r.Pathway != "" && !pathways[r.Pathway] {
+			return fmt.Errorf("rendition %s: pathway %q not used by any variant", r.Name, r.Pathway)
+		}
+	}
+	return nil
+}
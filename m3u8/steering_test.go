@@ -0,0 +1,77 @@
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+const steeringPlaylist = `#EXTM3U
+#EXT-X-CONTENT-STEERING:SERVER-URI="https://example.com/steering.json",PATHWAY-ID="CDN-A"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",LANGUAGE="en",URI="https://a.example.com/en.m3u8",STABLE-RENDITION-ID="en-aac",PATHWAY-ID="CDN-A"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",LANGUAGE="en",URI="https://b.example.com/en.m3u8",STABLE-RENDITION-ID="en-aac",PATHWAY-ID="CDN-B"
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,AUDIO="aac",STABLE-VARIANT-ID="720p",PATHWAY-ID="CDN-A"
+https://a.example.com/720p.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,AUDIO="aac",STABLE-VARIANT-ID="720p",PATHWAY-ID="CDN-B"
+https://b.example.com/720p.m3u8
+`
+
+func TestContentSteering(t *testing.T) {
+	p, err := Decode(strings.NewReader(steeringPlaylist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ContentSteering{ServerURI: "https://example.com/steering.json", Pathway: "CDN-A"}
+	if p.Steering == nil || *p.Steering != want {
+		t.Fatalf("content steering = %+v, want %+v", p.Steering, want)
+	}
+	if len(p.Media) != 2 || len(p.Variants) != 2 {
+		t.Fatalf("got %d renditions, %d variants; want 2, 2", len(p.Media), len(p.Variants))
+	}
+	for i, r := range p.Media {
+		if r.StableID != "en-aac" {
+			t.Errorf("rendition %d stable id = %q, want %q", i, r.StableID, "en-aac")
+		}
+		if r.Pathway != p.Variants[i].Pathway {
+			t.Errorf("rendition %d pathway %q does not match variant pathway %q", i, r.Pathway, p.Variants[i].Pathway)
+		}
+	}
+
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Decode(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("decode encoded playlist: %v", err)
+	}
+	if *again.Steering != want || again.Media[1].Pathway != "CDN-B" || again.Variants[1].Pathway != "CDN-B" {
+		t.Errorf("steering and pathways not preserved by round trip")
+	}
+
+	var bad = []struct {
+		name string
+		in   string
+	}{
+		{"steering with undeclared pathway", strings.Replace(steeringPlaylist, `PATHWAY-ID="CDN-A"`, `PATHWAY-ID="CDN-C"`, 1)},
+		{"rendition with undeclared pathway", strings.Replace(steeringPlaylist, `URI="https://b.example.com/en.m3u8",STABLE-RENDITION-ID="en-aac",PATHWAY-ID="CDN-B"`, `URI="https://b.example.com/en.m3u8",PATHWAY-ID="CDN-Z"`, 1)},
+	}
+	for _, tt := range bad {
+		strict := Decoder{Strict: true}
+		if _, err := strict.Decode(strings.NewReader(tt.in)); err == nil {
+			t.Errorf("%s: nil error decoding strictly", tt.name)
+		}
+		var warnings []error
+		d := Decoder{OnWarning: func(err error) { warnings = append(warnings, err) }}
+		p, err := d.Decode(strings.NewReader(tt.in))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(warnings) != 1 {
+			t.Errorf("%s: got %d warnings, want 1", tt.name, len(warnings))
+		}
+		if p.Steering == nil || len(p.Variants) != 2 {
+			t.Errorf("%s: playlist not decoded", tt.name)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package m3u8
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// ResolveURIs rewrites each URI in the playlist as an absolute URL
+// resolved against base, typically the URL the playlist was fetched from.
+// Characters not permitted in URLs, such as spaces and non-ASCII
+// characters sent verbatim by some origins, are percent-encoded.
+// Decode and Encode otherwise preserve URIs as written.
+func (p *Playlist) ResolveURIs(base *url.URL) error {
+	return p.rewriteURIs(func(s string) (string, error) {
+		u, err := url.Parse(s)
+		if err != nil {
+			return "", err
+		}
+		return base.ResolveReference(u).String(), nil
+	})
+}
+
+// URIs returns every URI referenced by the playlist, such as those of
+// segments, maps, keys, variants and renditions, without duplicates
+// and in the order they appear in the playlist. URIs are returned as
+// written; see ResolveURIs for absolute URLs. This is useful to
+// fetch every resource needed to play or mirror a presentation.
+func (p *Playlist) URIs() []string {
+	var uris []string
+	seen := make(map[string]bool)
+	p.eachURI(func(s *string) error {
+		if !seen[*s] {
+			seen[*s] = true
+			uris = append(uris, *s)
+		}
+		return nil
+	})
+	return uris
+}
+
+// rewriteURIs replaces each non-empty URI referenced by the playlist
+// with the result of calling fn on it, stopping at the first error.
+func (p *Playlist) rewriteURIs(fn func(string) (string, error)) error {
+	return p.eachURI(func(s *string) error {
+		v, err := fn(*s)
+		if err != nil {
+			return fmt.Errorf("rewrite %q: %w", *s, err)
+		}
+		*s = v
+		return nil
+	})
+}
+
+// eachURI calls fn with each non-empty URI referenced by the playlist
+// in the order they appear in the playlist, stopping at the first error.
+func (p *Playlist) eachURI(fn func(*string) error) error {
+	visit := func(s *string) error {
+		if *s == "" {
+			return nil
+		}
+		return fn(s)
+	}
+	for i := range p.Segments {
+		seg := &p.Segments[i]
+		for j := range seg.Keys {
+			if err := visit(&seg.Keys[j].URI); err != nil {
+				return err
+			}
+		}
+		if seg.Map != nil {
+			if err := visit(&seg.Map.URI); err != nil {
+				return err
+			}
+		}
+		for j := range seg.Parts {
+			if err := visit(&seg.Parts[j].URI); err != nil {
+				return err
+			}
+		}
+		if err := visit(&seg.URI); err != nil {
+			return err
+		}
+	}
+	if p.SessionKey != nil {
+		if err := visit(&p.SessionKey.URI); err != nil {
+			return err
+		}
+	}
+	for i := range p.Media {
+		if err := visit(&p.Media[i].URI); err != nil {
+			return err
+		}
+	}
+	for i := range p.Variants {
+		if err := visit(&p.Variants[i].URI); err != nil {
+			return err
+		}
+	}
+	for i := range p.SessionData {
+		if err := visit(&p.SessionData[i].URI); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package m3u8
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveURIs(t *testing.T) {
+	plist := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:6
+#EXTINF:6.000,
+my segment.ts
+#EXTINF:6.000,
+café.ts
+#EXTINF:6.000,
+https://cdn.example.com/abs.ts
+`
+	p, err := Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Segments[0].URI != "my segment.ts" || p.Segments[1].URI != "café.ts" {
+		t.Fatalf("segment URIs not preserved: %q, %q", p.Segments[0].URI, p.Segments[1].URI)
+	}
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\nmy segment.ts\n") || !strings.Contains(buf.String(), "\ncafé.ts\n") {
+		t.Errorf("URIs not written verbatim")
+		t.Log(buf.String())
+	}
+
+	base, err := url.Parse("https://example.com/live/index.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ResolveURIs(base); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"https://example.com/live/my%20segment.ts",
+		"https://example.com/live/caf%C3%A9.ts",
+		"https://cdn.example.com/abs.ts",
+	}
+	for i, seg := range p.Segments {
+		if seg.URI != want[i] {
+			t.Errorf("segment %d: got %s, want %s", i, seg.URI, want[i])
+		}
+	}
+}
+
+func TestURIs(t *testing.T) {
+	const plist = `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-TARGETDURATION:6
+#EXT-X-KEY:METHOD=AES-128,URI="key.bin"
+#EXT-X-MAP:URI="init.mp4"
+#EXTINF:6.000,
+0.m4s
+#EXTINF:6.000,
+1.m4s
+#EXT-X-KEY:METHOD=AES-128,URI="key.bin",IV=0x000102030405060708090a0b0c0d0e0f
+#EXTINF:6.000,
+2.m4s
+`
+	p, err := Decode(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"key.bin", "init.mp4", "0.m4s", "1.m4s", "2.m4s"}
+	if got := p.URIs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got URIs %q, want %q", got, want)
+	}
+
+	master := &Playlist{
+		Media:    []Rendition{{Type: MediaAudio, Name: "English", Group: "aac", URI: "audio/en.m3u8"}},
+		Variants: []Variant{{URI: "low.m3u8"}, {URI: "high.m3u8"}, {URI: "low.m3u8"}},
+	}
+	want = []string{"audio/en.m3u8", "low.m3u8", "high.m3u8"}
+	if got := master.URIs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got master playlist URIs %q, want %q", got, want)
+	}
+}
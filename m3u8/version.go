@@ -0,0 +1,56 @@
+package m3u8
+
+import "time"
+
+// RequiredVersion returns the lowest protocol version, as declared by
+// the EXT-X-VERSION tag, needed by the features used in p. The rules
+// are from the compatibility table in RFC 8216 section 7. Comparing
+// the result with p.Version catches playlists declaring too old a version.
+func (p *Playlist) RequiredVersion() int {
+	v := 1
+	need := func(n int) {
+		if n > v {
+			v = n
+		}
+	}
+	if p.IFramesOnly {
+		need(4)
+	}
+	if p.SessionKey != nil {
+		need(keyVersion(p.SessionKey))
+	}
+	for i := range p.Segments {
+		seg := &p.Segments[i]
+		if seg.Duration%time.Second != 0 {
+			need(3) // floating-point EXTINF duration
+		}
+		if seg.Range != [2]int{0, 0} {
+			need(4)
+		}
+		for j := range seg.Keys {
+			need(keyVersion(&seg.Keys[j]))
+		}
+		if seg.Map != nil {
+			if p.IFramesOnly {
+				need(5)
+			} else {
+				need(6)
+			}
+		}
+	}
+	for _, r := range p.Media {
+		if r.InstreamID != nil && r.InstreamID.Service {
+			need(7)
+		}
+	}
+	return v
+}
+
+func keyVersion(k *Key) int {
+	if k.Format != "" || len(k.FormatVersions) > 0 {
+		return 5
+	} else if k.IV != nil {
+		return 2
+	}
+	return 1
+}
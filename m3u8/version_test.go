@@ -0,0 +1,65 @@
+package m3u8
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequiredVersion(t *testing.T) {
+	var iv [16]byte
+	var cases = []struct {
+		name string
+		p    Playlist
+		want int
+	}{
+		{"empty", Playlist{}, 1},
+		{
+			"whole seconds",
+			Playlist{Segments: []Segment{{URI: "0.ts", Duration: 6 * time.Second}}},
+			1,
+		},
+		{
+			"key iv",
+			Playlist{Segments: []Segment{{URI: "0.ts", Duration: 6 * time.Second, Keys: []Key{{Method: EncryptMethodAES128, URI: "k", IV: &iv}}}}},
+			2,
+		},
+		{
+			"fractional duration",
+			Playlist{Segments: []Segment{{URI: "0.ts", Duration: 5500 * time.Millisecond}}},
+			3,
+		},
+		{
+			"byte range",
+			Playlist{Segments: []Segment{{URI: "0.ts", Duration: 6 * time.Second, Range: ByteRange{100, 0}}}},
+			4,
+		},
+		{"iframes only", Playlist{IFramesOnly: true}, 4},
+		{
+			"key format",
+			Playlist{Segments: []Segment{{URI: "0.ts", Duration: 6 * time.Second, Keys: []Key{{Method: EncryptMethodSampleAES, URI: "k", Format: "identity"}}}}},
+			5,
+		},
+		{
+			"iframes only map",
+			Playlist{IFramesOnly: true, Segments: []Segment{{URI: "0.mp4", Duration: 6 * time.Second, Map: &Map{URI: "init.mp4"}}}},
+			5,
+		},
+		{
+			"map",
+			Playlist{Segments: []Segment{{URI: "0.mp4", Duration: 5500 * time.Millisecond, Map: &Map{URI: "init.mp4"}}}},
+			6,
+		},
+		{
+			"instream service",
+			Playlist{Media: []Rendition{{Type: MediaClosedCaptions, InstreamID: &CCInfo{ID: 1, Service: true}}}},
+			7,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.RequiredVersion(); got != tt.want {
+				t.Errorf("RequiredVersion() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -10,26 +10,44 @@ import (
 	"github.com/untangledco/streaming/scte35"
 )
 
+// Encode writes p to w in the playlist format.
+// Encode is equivalent to calling Encode on a zero Encoder.
 func Encode(w io.Writer, p *Playlist) error {
-	fmt.Fprintln(w, "#EXTM3U")
-	if p.Version > 0 {
-		fmt.Fprintf(w, "%s:%d\n", tagVersion, p.Version)
-	}
-	if p.Type != PlaylistNone {
-		fmt.Fprintf(w, "%s:%s\n", tagPlaylistType, p.Type)
-	}
-	if p.IndependentSegments {
-		fmt.Fprintln(w, tagIndependentSegments)
-	}
-	if p.TargetDuration > 0 {
-		fmt.Fprintf(w, "%s:%d\n", tagTargetDuration, p.TargetDuration/time.Second)
+	return Encoder{}.Encode(w, p)
+}
+
+// Encoder writes playlists with configurable behaviour.
+type Encoder struct {
+	// PreciseDurations, if true, writes segment durations with as many
+	// fractional digits as needed to represent them exactly, rather
+	// than rounded to milliseconds. Durations then survive a round
+	// trip through Decode unchanged, such as 9.9667 seconds derived
+	// from a 90KHz clock, which would otherwise be written as 9.967.
+	PreciseDurations bool
+}
+
+// Encode writes p to w in the playlist format.
+func (e Encoder) Encode(w io.Writer, p *Playlist) error {
+	target, err := targetDuration(p)
+	if err != nil {
+		return err
 	}
-	fmt.Fprintf(w, "%s:%d\n", tagMediaSequence, p.Sequence)
+	writeHeader(w, p, target)
 
-	if _, err := writeSegments(w, p.Segments); err != nil {
+	if _, err := writeSegments(w, p.Segments, e.PreciseDurations); err != nil {
 		return fmt.Errorf("write segments: %w", err)
 	}
 
+	if p.Steering != nil {
+		if p.Steering.ServerURI == "" {
+			return fmt.Errorf("content steering: empty server uri")
+		}
+		if err := checkPathways(p); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, p.Steering)
+	}
+
 	for _, r := range p.Media {
 		if r.Name == "" {
 			return fmt.Errorf("empty name")
@@ -68,6 +86,59 @@ func Encode(w io.Writer, p *Playlist) error {
 	return nil
 }
 
+// writeHeader writes the tags of p preceding any segments.
+func writeHeader(w io.Writer, p *Playlist, target time.Duration) {
+	fmt.Fprintln(w, "#EXTM3U")
+	if p.Version > 0 {
+		fmt.Fprintf(w, "%s:%d\n", tagVersion, p.Version)
+	}
+	if p.Type != PlaylistNone {
+		fmt.Fprintf(w, "%s:%s\n", tagPlaylistType, p.Type)
+	}
+	if p.IndependentSegments {
+		fmt.Fprintln(w, tagIndependentSegments)
+	}
+	if p.Start != nil {
+		fmt.Fprintln(w, p.Start)
+	}
+	if target > 0 {
+		fmt.Fprintf(w, "%s:%d\n", tagTargetDuration, target/time.Second)
+	}
+	fmt.Fprintf(w, "%s:%d\n", tagMediaSequence, p.Sequence)
+	if p.ServerControl != nil {
+		fmt.Fprintln(w, p.ServerControl)
+	}
+	if p.IFramesOnly {
+		fmt.Fprintln(w, tagIFramesOnly)
+	}
+	for _, c := range p.comments {
+		fmt.Fprintln(w, c)
+	}
+}
+
+// targetDuration returns the target duration to write for p.
+// If p.TargetDuration is unset, it is the longest segment duration.
+// RFC 8216 section 4.3.3.1 requires segment durations rounded to the
+// nearest second be no longer than the target duration, so a 9.009
+// second segment has a target duration of 9 seconds, not 10.
+func targetDuration(p *Playlist) (time.Duration, error) {
+	target := p.TargetDuration.Truncate(time.Second)
+	if target == 0 {
+		for _, seg := range p.Segments {
+			if d := seg.Duration.Round(time.Second); d > target {
+				target = d
+			}
+		}
+		return target, nil
+	}
+	for i, seg := range p.Segments {
+		if d := seg.Duration.Round(time.Second); d > target {
+			return 0, fmt.Errorf("segment %d: duration %s exceeds target duration %s", i, seg.Duration, target)
+		}
+	}
+	return target, nil
+}
+
 func writeVariant(w io.Writer, v *Variant) (n int, err error) {
 	if v.Bandwidth <= 0 {
 		return 0, fmt.Errorf("invalid bandwidth %d: must be larger than zero", v.Bandwidth)
@@ -75,61 +146,107 @@ func writeVariant(w io.Writer, v *Variant) (n int, err error) {
 	if v.URI == "" {
 		return 0, fmt.Errorf("empty URI")
 	}
+	if v.HDCP > HDCPType1 {
+		return 0, fmt.Errorf("unknown HDCP level %d", v.HDCP)
+	}
 	return fmt.Fprintln(w, v)
 }
 
-func writeDateRange(w io.Writer, dr *DateRange) error {
+// WriteDateRange writes dr to w as an EXT-X-DATERANGE tag.
+func WriteDateRange(w io.Writer, dr *DateRange) (n int, err error) {
 	if dr.ID == "" {
-		return fmt.Errorf("empty ID")
+		return 0, fmt.Errorf("empty ID")
 	} else if dr.Start.IsZero() {
-		return fmt.Errorf("zero start time")
+		return 0, fmt.Errorf("zero start time")
 	}
+	// Attributes are written in the order they are listed in
+	// RFC 8216 section 4.3.2.7 so that output is reproducible.
 	var attrs []string
 	attrs = append(attrs, fmt.Sprintf("ID=%q", dr.ID))
+	if dr.Class != "" {
+		attrs = append(attrs, fmt.Sprintf("CLASS=%q", dr.Class))
+	}
 	attrs = append(attrs, fmt.Sprintf("START-DATE=%q", dr.Start.Format(time.RFC3339)))
 	if !dr.End.IsZero() {
 		attrs = append(attrs, fmt.Sprintf("END-DATE=%q", dr.End.Format(time.RFC3339)))
 	}
-	if dr.Class != "" {
-		attrs = append(attrs, fmt.Sprintf("CLASS=%q", dr.Class))
-	}
 	// TODO(otl): dr.Duration, dr.Planned. Differentiate zero value and user-set zero.
-	// TODO(otl): dr.Custom.
-	// TODO(otl): dr.CueCommand, when to write this versuse cuein, cueout.
-	if dr.CueIn != nil {
-		b, err := scte35.Encode(dr.CueIn)
-		if err != nil {
-			return fmt.Errorf("encode cue in: %w", err)
-		}
-		attrs = append(attrs, fmt.Sprintf("SCTE35-IN=0x%s", hex.EncodeToString(b)))
+	custom, err := formatClientAttributes(dr.Custom)
+	if err != nil {
+		return 0, err
 	}
+	attrs = append(attrs, custom...)
+	// TODO(otl): dr.CueCommand, when to write this versuse cuein, cueout.
 	if dr.CueOut != nil {
 		b, err := scte35.Encode(dr.CueOut)
 		if err != nil {
-			return fmt.Errorf("encode cue out: %w", err)
+			return 0, fmt.Errorf("encode cue out: %w", err)
 		}
 		attrs = append(attrs, fmt.Sprintf("SCTE35-OUT=0x%s", hex.EncodeToString(b)))
 	}
+	if dr.CueIn != nil {
+		b, err := scte35.Encode(dr.CueIn)
+		if err != nil {
+			return 0, fmt.Errorf("encode cue in: %w", err)
+		}
+		attrs = append(attrs, fmt.Sprintf("SCTE35-IN=0x%s", hex.EncodeToString(b)))
+	}
 	if dr.EndOnNext {
 		if dr.Class == "" {
-			return fmt.Errorf("empty class with end-on-next set")
+			return 0, fmt.Errorf("empty class with end-on-next set")
 		} else if !dr.End.IsZero() {
-			return fmt.Errorf("non-zero end time with end-on-next set")
+			return 0, fmt.Errorf("non-zero end time with end-on-next set")
 		} else if dr.Duration > 0 {
-			return fmt.Errorf("non-zero duration %s with end-on-next set", dr.Duration)
+			return 0, fmt.Errorf("non-zero duration %s with end-on-next set", dr.Duration)
 		}
-		attrs = append(attrs, "END-ON-NEXT:YES")
+		attrs = append(attrs, "END-ON-NEXT=YES")
+	}
+	return fmt.Fprintln(w, tagDateRange+":"+strings.Join(attrs, ","))
+}
+
+// WriteMap writes m to w as an EXT-X-MAP tag.
+func WriteMap(w io.Writer, m Map) (n int, err error) {
+	if m.URI == "" {
+		return 0, fmt.Errorf("empty URI")
+	} else if m.ByteRange != [2]int{0, 0} && (m.ByteRange[0] <= 0 || m.ByteRange[1] < 0) {
+		return 0, fmt.Errorf("impossible byte range %s", m.ByteRange)
 	}
-	tag := tagDateRange + ":" + strings.Join(attrs, ",")
-	_, err := fmt.Fprintln(w, tag)
-	return err
+	return fmt.Fprintln(w, m)
+}
+
+// WriteKey writes k to w as an EXT-X-KEY tag.
+// A Key with Method set to EncryptMethodNone, signalling that the
+// following segments are not encrypted, is written with no other attributes.
+func WriteKey(w io.Writer, k Key) (n int, err error) {
+	if k.Method > EncryptMethodSampleAES {
+		return 0, fmt.Errorf("unknown method %d", k.Method)
+	}
+	if k.Method != EncryptMethodNone && k.URI == "" {
+		return 0, fmt.Errorf("empty URI with method %s", k.Method)
+	}
+	return fmt.Fprintln(w, k)
+}
+
+// WriteDateTime writes t to w as an EXT-X-PROGRAM-DATE-TIME tag.
+func WriteDateTime(w io.Writer, t time.Time) (n int, err error) {
+	if t.IsZero() {
+		return 0, fmt.Errorf("zero time")
+	}
+	return fmt.Fprintf(w, "%s:%s\n", tagDateTime, t.Format(RFC3339Milli))
+}
+
+// WriteSegment writes seg to w. The segment's tags are written in the
+// same order as by Encode, followed by the segment's URI.
+func WriteSegment(w io.Writer, seg *Segment) (n int, err error) {
+	return writeSegment(w, seg, false)
 }
 
-func writeMap(w io.Writer, m Map) (n int, err error) {
-	if m.ByteRange != [2]int{0, 0} {
-		return fmt.Fprintf(w, "%s:URI=%q,BYTERANGE=%s\n", tagMap, m.URI, m.ByteRange)
+func writeSegment(w io.Writer, seg *Segment, precise bool) (n int, err error) {
+	b, err := seg.marshal(precise)
+	if err != nil {
+		return 0, err
 	}
-	return fmt.Fprintf(w, "%s:URI=%q\n", tagMap, m.URI)
+	return fmt.Fprintln(w, string(b))
 }
 
 func writeSessionData(w io.Writer, sd SessionData) (n int, err error) {
@@ -2,8 +2,11 @@ package m3u8
 
 import (
 	"bytes"
+	"fmt"
+	"io"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestWriteVariant(t *testing.T) {
@@ -62,3 +65,223 @@ small.m3u8`,
 		})
 	}
 }
+
+func TestWriteTags(t *testing.T) {
+	start := time.Date(2024, time.July, 16, 1, 27, 29, 0, time.UTC)
+	var cases = []struct {
+		name  string
+		write func(w io.Writer) (int, error)
+		want  string
+	}{
+		{
+			"key",
+			func(w io.Writer) (int, error) {
+				return WriteKey(w, Key{Method: EncryptMethodAES128, URI: "key.bin"})
+			},
+			`#EXT-X-KEY:METHOD=AES-128,URI="key.bin"`,
+		},
+		{
+			"map",
+			func(w io.Writer) (int, error) {
+				return WriteMap(w, Map{URI: "init.mp4"})
+			},
+			`#EXT-X-MAP:URI="init.mp4"`,
+		},
+		{
+			"map byte range",
+			func(w io.Writer) (int, error) {
+				return WriteMap(w, Map{URI: "video.mp4", ByteRange: ByteRange{1234, 0}})
+			},
+			`#EXT-X-MAP:URI="video.mp4",BYTERANGE="1234@0"`,
+		},
+		{
+			"date range",
+			func(w io.Writer) (int, error) {
+				return WriteDateRange(w, &DateRange{ID: "ad1", Class: "com.example.ad", Start: start, EndOnNext: true})
+			},
+			`#EXT-X-DATERANGE:ID="ad1",CLASS="com.example.ad",START-DATE="2024-07-16T01:27:29Z",END-ON-NEXT=YES`,
+		},
+		{
+			"date time",
+			func(w io.Writer) (int, error) {
+				return WriteDateTime(w, start.Add(250*time.Millisecond))
+			},
+			`#EXT-X-PROGRAM-DATE-TIME:2024-07-16T01:27:29.25Z`,
+		},
+		{
+			"segment",
+			func(w io.Writer) (int, error) {
+				return WriteSegment(w, &Segment{URI: "001.ts", Duration: 4 * time.Second, Discontinuity: true})
+			},
+			"#EXT-X-DISCONTINUITY\n#EXTINF:4.000\n001.ts",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			n, err := tt.write(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != buf.Len() {
+				t.Errorf("reported %d bytes written, buffer has %d", n, buf.Len())
+			}
+			if buf.String() != tt.want+"\n" {
+				t.Errorf("unexpected tag text")
+				t.Log("got:", buf.String())
+				t.Log("want:", tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteTagsStable(t *testing.T) {
+	start := time.Date(2024, 7, 16, 1, 27, 29, 0, time.UTC)
+	iv := [16]byte{0x0f}
+	write := func(w io.Writer) error {
+		dr := &DateRange{
+			ID:    "ad1",
+			Class: "com.example.ad",
+			Start: start,
+			Custom: map[string]any{
+				"X-COM-EXAMPLE-AD-ID":  "abc",
+				"X-COM-EXAMPLE-BEACON": []byte{0xde, 0xad},
+				"X-A":                  2.5,
+				"X-Z":                  "last",
+			},
+		}
+		if _, err := WriteDateRange(w, dr); err != nil {
+			return err
+		}
+		if _, err := WriteKey(w, Key{Method: EncryptMethodAES128, URI: "key.bin", IV: &iv, Format: "identity", FormatVersions: []uint32{1}}); err != nil {
+			return err
+		}
+		if _, err := WriteMap(w, Map{URI: "init.mp4", ByteRange: ByteRange{1234, 0}}); err != nil {
+			return err
+		}
+		v := Variant{URI: "hi.m3u8", Bandwidth: 1000, AverageBandwidth: 900, Score: 1, Codecs: []string{"avc1.64001f"}, Audio: "aac", Pathway: "CDN-A"}
+		_, err := fmt.Fprintln(w, v)
+		return err
+	}
+	first := &bytes.Buffer{}
+	if err := write(first); err != nil {
+		t.Fatal(err)
+	}
+	want := `#EXT-X-DATERANGE:ID="ad1",CLASS="com.example.ad",START-DATE="2024-07-16T01:27:29Z",X-A=2.5,X-COM-EXAMPLE-AD-ID="abc",X-COM-EXAMPLE-BEACON=0xdead,X-Z="last"`
+	if line, _, _ := strings.Cut(first.String(), "\n"); line != want {
+		t.Errorf("unexpected date range text")
+		t.Log("got:", line)
+		t.Log("want:", want)
+	}
+	for i := 0; i < 20; i++ {
+		buf := &bytes.Buffer{}
+		if err := write(buf); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), first.Bytes()) {
+			t.Errorf("write %d differs from first write", i)
+			t.Log("got:", buf.String())
+			t.Log("want:", first.String())
+			break
+		}
+	}
+}
+
+func TestWriteBadClientAttribute(t *testing.T) {
+	start := time.Date(2024, 7, 16, 1, 27, 29, 0, time.UTC)
+	for _, custom := range []map[string]any{
+		{"X-COUNT": 3},
+		{"COUNT": 3.0},
+	} {
+		buf := &bytes.Buffer{}
+		if _, err := WriteDateRange(buf, &DateRange{ID: "ad1", Start: start, Custom: custom}); err == nil {
+			t.Errorf("nil error writing client attributes %v", custom)
+		}
+	}
+}
+
+func TestWriteBadTags(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if _, err := WriteMap(buf, Map{}); err == nil {
+		t.Error("nil error writing map with empty URI")
+	}
+	if _, err := WriteDateRange(buf, &DateRange{ID: "ad1"}); err == nil {
+		t.Error("nil error writing date range with zero start")
+	}
+	if _, err := WriteDateTime(buf, time.Time{}); err == nil {
+		t.Error("nil error writing zero date time")
+	}
+	if buf.Len() > 0 {
+		t.Errorf("wrote %q for invalid tags", buf.String())
+	}
+}
+
+func TestEncodeTargetDuration(t *testing.T) {
+	p := &Playlist{
+		Segments: []Segment{
+			{URI: "0.ts", Duration: 6 * time.Second},
+			{URI: "1.ts", Duration: 9009 * time.Millisecond},
+		},
+	}
+	buf := &strings.Builder{}
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	// 9.009 rounds to 9, the nearest integer.
+	if want := "#EXT-X-TARGETDURATION:9\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("encoded playlist missing %q", want)
+		t.Log(buf.String())
+	}
+
+	p.TargetDuration = 8 * time.Second
+	if err := Encode(io.Discard, p); err == nil {
+		t.Errorf("nil error encoding segment longer than target duration %s", p.TargetDuration)
+	}
+}
+
+func TestEncodePreciseDurations(t *testing.T) {
+	const in = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:10
+#EXT-X-MEDIA-SEQUENCE:0
+#EXTINF:4.004
+0.ts
+#EXTINF:9.9667
+1.ts
+#EXTINF:10.000
+2.ts
+#EXTINF:0.011122222
+3.ts
+#EXT-X-ENDLIST
+`
+	p, err := Decode(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &strings.Builder{}
+	if err := (Encoder{PreciseDurations: true}).Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != in {
+		t.Errorf("precise durations not written unchanged")
+		t.Log("got:", buf.String())
+	}
+	q, err := Decode(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range p.Segments {
+		if p.Segments[i].Duration != q.Segments[i].Duration {
+			t.Errorf("segment %d: duration %s drifted to %s", i, p.Segments[i].Duration, q.Segments[i].Duration)
+		}
+	}
+
+	buf.Reset()
+	if err := Encode(buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "#EXTINF:9.967\n") {
+		t.Errorf("default encoding not rounded to milliseconds")
+		t.Log("got:", buf.String())
+	}
+}
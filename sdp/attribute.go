@@ -0,0 +1,281 @@
+package sdp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Attribute represents an "a=" line, the primary means of extending
+// SDP as described in RFC 8866 section 5.13.
+// Property attributes, such as "a=recvonly", have only a Name.
+// Value attributes, such as "a=rtpmap:99 h263-1998/90000", hold
+// everything after the first colon in Value; further colons,
+// as in fingerprints, IPv6 addresses and "a=control" URLs, are part
+// of the value. Values are stored as read and written unchanged.
+type Attribute struct {
+	Name  string
+	Value string
+	// EmptyValue distinguishes a value attribute with an empty
+	// value, such as "a=x-foo:", from a property attribute.
+	EmptyValue bool
+}
+
+func (a Attribute) String() string {
+	if a.Property() {
+		return a.Name
+	}
+	return a.Name + ":" + a.Value
+}
+
+// Property reports whether a is a property attribute.
+func (a Attribute) Property() bool {
+	return a.Value == "" && !a.EmptyValue
+}
+
+func parseAttribute(s string) Attribute {
+	name, value, found := strings.Cut(s, ":")
+	return Attribute{Name: name, Value: value, EmptyValue: found && value == ""}
+}
+
+// AllAttributes returns an iterator over the attributes of the
+// session followed by those of each media in order. Each attribute
+// is yielded with the index of the media holding it, or -1 for
+// session-level attributes. Iteration stops if yield returns false.
+// The iterator has the signature of iter.Seq2, so from Go 1.23 it
+// may be ranged over:
+//
+//	for i, a := range s.AllAttributes() {
+//		...
+//	}
+func (s *Session) AllAttributes() func(yield func(int, Attribute) bool) {
+	return func(yield func(int, Attribute) bool) {
+		for _, a := range s.Attributes {
+			if !yield(-1, a) {
+				return
+			}
+		}
+		for i, m := range s.Media {
+			for _, a := range m.Attributes {
+				if !yield(i, a) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// hasFlag reports whether attrs contains the property attribute name.
+func hasFlag(attrs []Attribute, name string) bool {
+	for _, a := range attrs {
+		if a.Name == name && a.Property() {
+			return true
+		}
+	}
+	return false
+}
+
+// RTCPReducedSize reports whether the media has the "a=rtcp-rsize"
+// attribute, signalling support for reduced-size RTCP as specified
+// in RFC 5506.
+func (m Media) RTCPReducedSize() bool {
+	return hasFlag(m.Attributes, "rtcp-rsize")
+}
+
+// RTCPMux reports whether the media has the "a=rtcp-mux" attribute
+// specified in RFC 5761, signalling RTP and RTCP packets are
+// multiplexed on a single port.
+func (m Media) RTCPMux() bool {
+	return hasFlag(m.Attributes, "rtcp-mux")
+}
+
+// RTCPMuxOnly reports whether the media has the "a=rtcp-mux-only"
+// attribute specified in RFC 8858, signalling the endpoint will not
+// fall back to using a separate port for RTCP.
+func (m Media) RTCPMuxOnly() bool {
+	return hasFlag(m.Attributes, "rtcp-mux-only")
+}
+
+// attrValue returns the value of the first attribute in attrs with
+// the given name. The boolean is false if there is no such attribute.
+func attrValue(attrs []Attribute, name string) (string, bool) {
+	for _, a := range attrs {
+		if a.Name == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// MID returns the media stream identification from the "a=mid"
+// attribute specified in RFC 5888, or the empty string if unset.
+func (m Media) MID() string {
+	mid, _ := attrValue(m.Attributes, "mid")
+	return mid
+}
+
+// BundleOnly reports whether the media has the "a=bundle-only"
+// attribute specified in RFC 8843 section 6, indicating the media
+// may only be used as part of a BUNDLE group.
+func (m Media) BundleOnly() bool {
+	return hasFlag(m.Attributes, "bundle-only")
+}
+
+// ExtmapAllowMixed reports whether the session has the
+// "a=extmap-allow-mixed" attribute specified in RFC 8285 section 6,
+// signalling one-byte and two-byte RTP header extensions may be
+// mixed in the same packet. The attribute applies to all media.
+func (s *Session) ExtmapAllowMixed() bool {
+	return hasFlag(s.Attributes, "extmap-allow-mixed")
+}
+
+// ExtmapAllowMixed reports whether the media has the "a=extmap-allow-mixed" attribute.
+// See Session.ExtmapAllowMixed.
+func (m Media) ExtmapAllowMixed() bool {
+	return hasFlag(m.Attributes, "extmap-allow-mixed")
+}
+
+// Group represents the "a=group" session attribute specified in RFC 5888.
+// For example "a=group:BUNDLE 0 1" has Semantics "BUNDLE" and
+// IDs 0 and 1, which refer to media by their MID.
+type Group struct {
+	Semantics string
+	IDs       []string
+}
+
+func (g Group) String() string {
+	return strings.Join(append([]string{g.Semantics}, g.IDs...), " ")
+}
+
+func parseGroup(s string) (Group, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return Group{}, fmt.Errorf("missing semantics")
+	}
+	return Group{Semantics: fields[0], IDs: fields[1:]}, nil
+}
+
+// Groups returns the media groups declared by the session's "a=group" attributes.
+func (s *Session) Groups() ([]Group, error) {
+	var groups []Group
+	for _, a := range s.Attributes {
+		if a.Name != "group" {
+			continue
+		}
+		g, err := parseGroup(a.Value)
+		if err != nil {
+			return nil, fmt.Errorf("parse group %q: %w", a.Value, err)
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// BundleTagged returns the tagged media of the session's first BUNDLE
+// group: the media identified by the group's first MID, as specified
+// in RFC 8843 section 7. Its transport, such as its ICE and DTLS
+// parameters, is shared by all media in the group.
+// BundleTagged returns nil if there is no such group or media.
+func (s *Session) BundleTagged() *Media {
+	groups, err := s.Groups()
+	if err != nil {
+		return nil
+	}
+	for _, g := range groups {
+		if g.Semantics == "BUNDLE" && len(g.IDs) > 0 {
+			return s.MediaByMID(g.IDs[0])
+		}
+	}
+	return nil
+}
+
+// transportAttributes are the attributes holding the ICE and DTLS
+// parameters of a media's transport.
+var transportAttributes = []string{"ice-ufrag", "ice-pwd", "fingerprint"}
+
+// hasTransport reports whether attrs holds any transport attributes.
+func hasTransport(attrs []Attribute) bool {
+	for _, name := range transportAttributes {
+		if _, ok := attrValue(attrs, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RTPMap represents the "a=rtpmap" attribute which maps an RTP
+// payload type to an encoding, as specified in RFC 8866 section 6.6.
+type RTPMap struct {
+	PayloadType int
+	Encoding    string // for example "opus" or "H264"
+	ClockRate   int
+	// Channels is the number of audio channels.
+	// Zero indicates the parameter is omitted, implying one channel.
+	Channels int
+}
+
+func (m RTPMap) String() string {
+	s := fmt.Sprintf("%d %s/%d", m.PayloadType, m.Encoding, m.ClockRate)
+	if m.Channels > 0 {
+		s += "/" + strconv.Itoa(m.Channels)
+	}
+	return s
+}
+
+func parseRTPMap(s string) (RTPMap, error) {
+	pt, enc, ok := strings.Cut(s, " ")
+	if !ok {
+		return RTPMap{}, fmt.Errorf("missing encoding")
+	}
+	var m RTPMap
+	var err error
+	m.PayloadType, err = strconv.Atoi(pt)
+	if err != nil {
+		return RTPMap{}, fmt.Errorf("parse payload type: %w", err)
+	} else if m.PayloadType < 0 || m.PayloadType > 127 {
+		return RTPMap{}, fmt.Errorf("payload type %d out of range", m.PayloadType)
+	}
+	fields := strings.Split(strings.TrimSpace(enc), "/")
+	if len(fields) < 2 || len(fields) > 3 {
+		return RTPMap{}, fmt.Errorf("want encoding/clock rate[/channels], got %q", enc)
+	}
+	m.Encoding = fields[0]
+	m.ClockRate, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return RTPMap{}, fmt.Errorf("parse clock rate: %w", err)
+	}
+	if len(fields) == 3 {
+		m.Channels, err = strconv.Atoi(fields[2])
+		if err != nil {
+			return RTPMap{}, fmt.Errorf("parse channels: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// RTPMaps returns the media's "a=rtpmap" attributes.
+// Malformed attributes are skipped.
+func (m Media) RTPMaps() []RTPMap {
+	var maps []RTPMap
+	for _, a := range m.Attributes {
+		if a.Name != "rtpmap" {
+			continue
+		}
+		if rm, err := parseRTPMap(a.Value); err == nil {
+			maps = append(maps, rm)
+		}
+	}
+	return maps
+}
+
+// fmtp returns the format parameters of the "a=fmtp" attribute for
+// the payload type pt. The boolean is false if there is none.
+func (m Media) fmtp(pt int) (string, bool) {
+	prefix := strconv.Itoa(pt) + " "
+	for _, a := range m.Attributes {
+		if a.Name == "fmtp" && strings.HasPrefix(a.Value, prefix) {
+			return strings.TrimSpace(strings.TrimPrefix(a.Value, prefix)), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,201 @@
+package sdp
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RefClock represents the "a=ts-refclk" attribute specified in
+// RFC 7273 section 4.8, identifying the reference clock from which
+// RTP timestamps are derived. SMPTE ST 2110 and AES67 flows use a
+// PTP reference clock, for example:
+//
+//	a=ts-refclk:ptp=IEEE1588-2008:39-A7-94-FF-FE-07-CB-D0:37
+type RefClock struct {
+	// Source is the type of clock source, such as "ptp", "ntp",
+	// "localmac" or "local".
+	Source string
+	// Value is everything following the "=" of the source,
+	// such as the address of an NTP server.
+	Value string
+
+	// The remaining fields are set only for PTP clock sources.
+	//
+	// PTPVersion is the PTP standard, such as "IEEE1588-2008".
+	PTPVersion string
+	// Grandmaster is the EUI-64 clock identity of the PTP grandmaster.
+	// It is zero if the clock is Traceable.
+	Grandmaster [8]byte
+	// Traceable reports whether the clock is traceable to
+	// International Atomic Time rather than a specific grandmaster.
+	Traceable bool
+	// Domain is the PTP domain number.
+	Domain int
+}
+
+// TimestampRefClock returns the reference clock from the media's
+// first "a=ts-refclk" attribute, or nil if there is none. Media
+// without the attribute use the session's; see Session.TimestampRefClock.
+func (m Media) TimestampRefClock() (*RefClock, error) {
+	return refClock(m.Attributes)
+}
+
+// TimestampRefClock returns the reference clock from the session's
+// first "a=ts-refclk" attribute, or nil if there is none.
+func (s *Session) TimestampRefClock() (*RefClock, error) {
+	return refClock(s.Attributes)
+}
+
+func refClock(attrs []Attribute) (*RefClock, error) {
+	v, ok := attrValue(attrs, "ts-refclk")
+	if !ok {
+		return nil, nil
+	}
+	clk, err := parseRefClock(v)
+	if err != nil {
+		return nil, fmt.Errorf("parse ts-refclk %q: %w", v, err)
+	}
+	return &clk, nil
+}
+
+func parseRefClock(s string) (RefClock, error) {
+	source, value, _ := strings.Cut(s, "=")
+	if source == "" {
+		return RefClock{}, fmt.Errorf("empty clock source")
+	}
+	clk := RefClock{Source: source, Value: value}
+	if source != "ptp" {
+		return clk, nil
+	}
+
+	fields := strings.Split(value, ":")
+	clk.PTPVersion = fields[0]
+	if clk.PTPVersion == "" {
+		return RefClock{}, fmt.Errorf("empty ptp version")
+	}
+	if len(fields) == 1 {
+		return clk, nil
+	} else if len(fields) > 3 {
+		return RefClock{}, fmt.Errorf("too many ptp fields")
+	}
+	if fields[1] == "traceable" {
+		if len(fields) > 2 {
+			return RefClock{}, fmt.Errorf("domain with traceable clock")
+		}
+		clk.Traceable = true
+		return clk, nil
+	}
+	gmid, err := parseEUI64(fields[1])
+	if err != nil {
+		return RefClock{}, fmt.Errorf("parse grandmaster id: %w", err)
+	}
+	clk.Grandmaster = gmid
+	if len(fields) == 3 {
+		// RFC 7273 specifies "domain-nmbr=37", while SMPTE ST 2110-10
+		// uses the bare number.
+		n, err := strconv.Atoi(strings.TrimPrefix(fields[2], "domain-nmbr="))
+		if err != nil {
+			return RefClock{}, fmt.Errorf("parse domain: %w", err)
+		}
+		if n < 0 || n > 255 {
+			return RefClock{}, fmt.Errorf("domain %d outside 0 to 255", n)
+		}
+		clk.Domain = n
+	}
+	return clk, nil
+}
+
+// parseEUI64 parses an EUI-64 as eight hexadecimal octets separated
+// by hyphens, such as "39-A7-94-FF-FE-07-CB-D0".
+func parseEUI64(s string) ([8]byte, error) {
+	var id [8]byte
+	octets := strings.Split(s, "-")
+	if len(octets) != len(id) {
+		return id, fmt.Errorf("%q: want %d octets, got %d", s, len(id), len(octets))
+	}
+	for i, o := range octets {
+		if len(o) != 2 {
+			return id, fmt.Errorf("%q: bad octet %q", s, o)
+		}
+		if _, err := hex.Decode(id[i:i+1], []byte(o)); err != nil {
+			return id, fmt.Errorf("%q: %w", s, err)
+		}
+	}
+	return id, nil
+}
+
+// MediaClock represents the "a=mediaclk" attribute specified in
+// RFC 7273 section 5, relating the media clock to the reference
+// clock. SMPTE ST 2110 flows use a direct media clock, for example:
+//
+//	a=mediaclk:direct=0
+type MediaClock struct {
+	// Source is the media clock source: "direct", "sender" or
+	// "IEEE1722".
+	Source string
+	// Offset is the RTP timestamp at the reference clock's epoch.
+	// It is set only for direct media clocks.
+	Offset uint32
+	// Rate is the media clock rate relative to the RTP clock rate as
+	// a numerator and denominator. It is zero if unset.
+	Rate [2]int
+	// StreamID is the IEEE 1722 stream identifier.
+	StreamID string
+}
+
+// MediaClock returns the media clock from the media's "a=mediaclk"
+// attribute, or nil if there is none.
+func (m Media) MediaClock() (*MediaClock, error) {
+	v, ok := attrValue(m.Attributes, "mediaclk")
+	if !ok {
+		return nil, nil
+	}
+	clk, err := parseMediaClock(v)
+	if err != nil {
+		return nil, fmt.Errorf("parse mediaclk %q: %w", v, err)
+	}
+	return &clk, nil
+}
+
+func parseMediaClock(s string) (MediaClock, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return MediaClock{}, fmt.Errorf("empty media clock source")
+	}
+	source, value, found := strings.Cut(fields[0], "=")
+	clk := MediaClock{Source: source}
+	switch source {
+	case "direct":
+		if found {
+			n, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return MediaClock{}, fmt.Errorf("parse offset: %w", err)
+			}
+			clk.Offset = uint32(n)
+		}
+	case "sender":
+	case "IEEE1722":
+		if value == "" {
+			return MediaClock{}, fmt.Errorf("empty stream id")
+		}
+		clk.StreamID = value
+	default:
+		return MediaClock{}, fmt.Errorf("unknown media clock source %q", source)
+	}
+	for _, f := range fields[1:] {
+		if !strings.HasPrefix(f, "rate=") {
+			return MediaClock{}, fmt.Errorf("unknown parameter %q", f)
+		}
+		num, denom, _ := strings.Cut(strings.TrimPrefix(f, "rate="), "/")
+		var err error
+		if clk.Rate[0], err = strconv.Atoi(num); err != nil {
+			return MediaClock{}, fmt.Errorf("parse rate numerator: %w", err)
+		}
+		if clk.Rate[1], err = strconv.Atoi(denom); err != nil {
+			return MediaClock{}, fmt.Errorf("parse rate denominator: %w", err)
+		}
+	}
+	return clk, nil
+}
@@ -0,0 +1,98 @@
+package sdp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTimestampRefClock(t *testing.T) {
+	raw := "v=0\r\n" +
+		"o=- 1 1 IN IP4 192.0.2.1\r\n" +
+		"s=ST 2110 video\r\n" +
+		"t=0 0\r\n" +
+		"a=ts-refclk:ntp=/traceable/\r\n" +
+		"m=video 50000 RTP/AVP 96\r\n" +
+		"c=IN IP4 239.0.0.1/64\r\n" +
+		"a=rtpmap:96 raw/90000\r\n" +
+		"a=ts-refclk:ptp=IEEE1588-2008:39-A7-94-FF-FE-07-CB-D0:37\r\n" +
+		"a=mediaclk:direct=0\r\n" +
+		"m=audio 50002 RTP/AVP 97\r\n" +
+		"c=IN IP4 239.0.0.2/64\r\n" +
+		"a=rtpmap:97 L24/48000/2\r\n" +
+		"a=ts-refclk:ptp=IEEE1588-2008:traceable\r\n" +
+		"a=mediaclk:direct=963214424 rate=48000/1\r\n"
+	session, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clk, err := session.Media[0].TimestampRefClock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &RefClock{
+		Source:      "ptp",
+		Value:       "IEEE1588-2008:39-A7-94-FF-FE-07-CB-D0:37",
+		PTPVersion:  "IEEE1588-2008",
+		Grandmaster: [8]byte{0x39, 0xa7, 0x94, 0xff, 0xfe, 0x07, 0xcb, 0xd0},
+		Domain:      37,
+	}
+	if !reflect.DeepEqual(clk, want) {
+		t.Errorf("got %+v, want %+v", clk, want)
+	}
+	mclk, err := session.Media[0].MediaClock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *mclk != (MediaClock{Source: "direct"}) {
+		t.Errorf("got media clock %+v, want direct with zero offset", mclk)
+	}
+
+	clk, err = session.Media[1].TimestampRefClock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !clk.Traceable || clk.Grandmaster != [8]byte{} {
+		t.Errorf("want traceable clock with no grandmaster, got %+v", clk)
+	}
+	mclk, err = session.Media[1].MediaClock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mclk.Offset != 963214424 || mclk.Rate != [2]int{48000, 1} {
+		t.Errorf("got media clock %+v", mclk)
+	}
+
+	clk, err = session.TimestampRefClock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clk.Source != "ntp" || clk.Value != "/traceable/" {
+		t.Errorf("got session clock %+v", clk)
+	}
+}
+
+func TestBadClock(t *testing.T) {
+	for _, v := range []string{
+		"ptp=",
+		"ptp=IEEE1588-2008:39-A7-94-FF-FE-07-CB",
+		"ptp=IEEE1588-2008:39-A7-94-FF-FE-07-CB-ZZ",
+		"ptp=IEEE1588-2008:39-A7-94-FF-FE-07-CB-D0:256",
+		"ptp=IEEE1588-2008:traceable:0",
+	} {
+		m := Media{Attributes: []Attribute{{Name: "ts-refclk", Value: v}}}
+		if clk, err := m.TimestampRefClock(); err == nil {
+			t.Errorf("%s: nil error, got %+v", v, clk)
+		}
+	}
+	for _, v := range []string{"direct=-1", "direct=0 rate=x/1", "unknown", "direct=0 foo"} {
+		m := Media{Attributes: []Attribute{{Name: "mediaclk", Value: v}}}
+		if clk, err := m.MediaClock(); err == nil {
+			t.Errorf("%s: nil error, got %+v", v, clk)
+		}
+	}
+	if clk, err := (Media{}).MediaClock(); clk != nil || err != nil {
+		t.Errorf("media without mediaclk: got %v, %v", clk, err)
+	}
+}
@@ -0,0 +1,82 @@
+package sdp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Values of the "a=content" attribute specified in RFC 4796 section 5.
+const (
+	ContentSlides       = "slides"  // a presentation, such as a shared screen
+	ContentSpeaker      = "speaker" // the active speaker
+	ContentSignLanguage = "sl"      // sign language interpretation
+	ContentMain         = "main"    // the main media, such as a camera
+	ContentAlt          = "alt"     // an alternative to the main media
+)
+
+// Content returns the value of the media's "a=content" attribute
+// specified in RFC 4796, which describes what the media shows so that
+// a conferencing client can lay it out, for example placing "slides"
+// media apart from "main" media. The value may be a comma-separated
+// list, such as "main,speaker". Values other than the Content
+// constants are returned unchanged, as the RFC permits extensions.
+// The empty string is returned if there is no such attribute.
+func (m Media) Content() string {
+	v, _ := attrValue(m.Attributes, "content")
+	return v
+}
+
+// validateContent checks the media's "a=content" attribute, if any,
+// is a comma-separated list of tokens.
+func validateContent(m Media) error {
+	v, ok := attrValue(m.Attributes, "content")
+	if !ok {
+		return nil
+	}
+	for _, c := range strings.Split(v, ",") {
+		if c == "" {
+			return fmt.Errorf("content %q: empty value", v)
+		}
+		for i := 0; i < len(c); i++ {
+			if !isTokenChar(c[i]) {
+				return fmt.Errorf("content %q: illegal character %q", v, c[i])
+			}
+		}
+	}
+	return nil
+}
+
+// isTokenChar reports whether b may appear in a token as specified
+// in RFC 8866 section 9.
+func isTokenChar(b byte) bool {
+	switch {
+	case b == 0x21, b >= 0x23 && b <= 0x27, b == 0x2a, b == 0x2b, b == 0x2d, b == 0x2e:
+		return true
+	case b >= 0x30 && b <= 0x39, b >= 0x41 && b <= 0x5a, b >= 0x5e && b <= 0x7e:
+		return true
+	}
+	return false
+}
+
+// Label returns the value of the media's "a=label" attribute specified
+// in RFC 4574, an opaque identifier by which applications, such as
+// those using BFCP to share screens, refer to the media. The empty
+// string is returned if there is no such attribute.
+func (m Media) Label() string {
+	label, _ := attrValue(m.Attributes, "label")
+	return label
+}
+
+// MediaByLabel returns the first media with the given label, or nil
+// if there is none. An empty label matches no media. See Media.Label.
+func (s *Session) MediaByLabel(label string) *Media {
+	if label == "" {
+		return nil
+	}
+	for i := range s.Media {
+		if s.Media[i].Label() == label {
+			return &s.Media[i]
+		}
+	}
+	return nil
+}
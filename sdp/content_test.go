@@ -0,0 +1,101 @@
+package sdp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContent(t *testing.T) {
+	raw := "v=0\r\n" +
+		"o=- 1 1 IN IP4 192.0.2.1\r\n" +
+		"s=Conference\r\n" +
+		"t=0 0\r\n" +
+		"m=video 49170 RTP/AVP 96\r\n" +
+		"a=rtpmap:96 H264/90000\r\n" +
+		"a=content:main\r\n" +
+		"a=extmap:3 urn:ietf:params:rtp-hdrext:framemarking\r\n" +
+		"m=video 49172 RTP/AVP 96\r\n" +
+		"a=rtpmap:96 H264/90000\r\n" +
+		"a=content:slides\r\n" +
+		"m=video 49174 RTP/AVP 96\r\n" +
+		"a=rtpmap:96 H264/90000\r\n" +
+		"a=content:speaker,x-gallery\r\n"
+	session, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{ContentMain, ContentSlides, "speaker,x-gallery"}
+	for i, m := range session.Media {
+		if m.Content() != want[i] {
+			t.Errorf("media %d: got content %q, want %q", i, m.Content(), want[i])
+		}
+	}
+	if !session.Media[0].FrameMarking() {
+		t.Errorf("media 0: frame marking extension not found")
+	}
+	if session.Media[1].FrameMarking() {
+		t.Errorf("media 1: unexpected frame marking extension")
+	}
+	if c := (Media{}).Content(); c != "" {
+		t.Errorf("media without content attribute: got %q", c)
+	}
+
+	for _, bad := range []string{"", "main,", "main speaker"} {
+		session.Media[1].Attributes[1].Value = bad
+		if err := session.Validate(); err == nil {
+			t.Errorf("nil error validating content %q", bad)
+		}
+	}
+}
+
+func TestLabel(t *testing.T) {
+	raw := "v=0\r\n" +
+		"o=- 1 1 IN IP4 192.0.2.1\r\n" +
+		"s=Presentation\r\n" +
+		"t=0 0\r\n" +
+		"m=video 49170 RTP/AVP 96\r\n" +
+		"a=rtpmap:96 H264/90000\r\n" +
+		"a=content:main\r\n" +
+		"a=label:1\r\n" +
+		"m=video 49172 RTP/AVP 96\r\n" +
+		"a=rtpmap:96 H264/90000\r\n" +
+		"a=content:slides\r\n" +
+		"a=label:screen\r\n"
+	session, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &strings.Builder{}
+	if err := WriteSession(buf, session); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != raw {
+		t.Errorf("labels not preserved")
+		t.Log("got:", buf.String())
+	}
+	session, err = ReadSession(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := session.MediaByLabel("screen")
+	if m == nil {
+		t.Fatal("no media with label screen")
+	}
+	if m.Index != 1 || m.Content() != ContentSlides {
+		t.Errorf("label screen: got media %d with content %q", m.Index, m.Content())
+	}
+	if m := session.MediaByLabel("1"); m == nil || m.Index != 0 {
+		t.Errorf("label 1: got media %v, want media 0", m)
+	}
+	if m := session.MediaByLabel("2"); m != nil {
+		t.Errorf("label 2: got media %d, want none", m.Index)
+	}
+	unlabelled := &Session{Media: []Media{{Type: "audio"}}}
+	if m := unlabelled.MediaByLabel(""); m != nil {
+		t.Errorf("empty label: got media %d, want none", m.Index)
+	}
+}
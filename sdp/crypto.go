@@ -0,0 +1,166 @@
+package sdp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Crypto represents the "a=crypto" media attribute specified in
+// RFC 4568, which keys SRTP in the session description itself
+// (SDES). Media may have several, each offering a different
+// crypto suite. For example:
+//
+//	a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:PS1uQCVeeCFCanVmcjkpPywjNWhcYD0mXXtxaVBR|2^20|1:4
+type Crypto struct {
+	// Tag identifies the attribute among those of the media, so
+	// that the answer may refer to the offered suite.
+	Tag   int
+	Suite string // for example "AES_CM_128_HMAC_SHA1_80"
+	Keys  []CryptoKey
+	// SessionParams holds any parameters of the suite following
+	// the keys, such as "KDR=1" or "UNENCRYPTED_SRTCP".
+	SessionParams []string
+}
+
+// CryptoKey is a key parameter of a Crypto attribute.
+type CryptoKey struct {
+	// KeySalt is the master key concatenated with the master salt,
+	// decoded from base64.
+	KeySalt []byte
+	// Lifetime is the maximum number of packets protected by the key.
+	// Zero indicates the default of the crypto suite.
+	Lifetime uint64
+	// MKI is the value of the master key identifier, and MKILength
+	// its length in bytes in each packet. MKILength is zero if the
+	// key has no MKI.
+	MKI       uint64
+	MKILength int
+}
+
+func (c Crypto) String() string {
+	keys := make([]string, len(c.Keys))
+	for i := range c.Keys {
+		keys[i] = c.Keys[i].String()
+	}
+	fields := []string{strconv.Itoa(c.Tag), c.Suite, strings.Join(keys, ";")}
+	return strings.Join(append(fields, c.SessionParams...), " ")
+}
+
+func (k CryptoKey) String() string {
+	s := "inline:" + base64.StdEncoding.EncodeToString(k.KeySalt)
+	if k.Lifetime > 0 {
+		if bits.OnesCount64(k.Lifetime) == 1 {
+			s += "|2^" + strconv.Itoa(bits.TrailingZeros64(k.Lifetime))
+		} else {
+			s += "|" + strconv.FormatUint(k.Lifetime, 10)
+		}
+	}
+	if k.MKILength > 0 {
+		s += fmt.Sprintf("|%d:%d", k.MKI, k.MKILength)
+	}
+	return s
+}
+
+func parseCrypto(s string) (Crypto, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 3 {
+		return Crypto{}, fmt.Errorf("need at least %d fields, have %d", 3, len(fields))
+	}
+	if len(fields[0]) > 9 {
+		return Crypto{}, fmt.Errorf("tag %s: longer than 9 digits", fields[0])
+	}
+	tag, err := strconv.Atoi(fields[0])
+	if err != nil || tag < 0 {
+		return Crypto{}, fmt.Errorf("bad tag %q", fields[0])
+	}
+	c := Crypto{Tag: tag, Suite: fields[1], SessionParams: fields[3:]}
+	for _, param := range strings.Split(fields[2], ";") {
+		k, err := parseCryptoKey(param)
+		if err != nil {
+			return Crypto{}, fmt.Errorf("key %q: %w", param, err)
+		}
+		c.Keys = append(c.Keys, k)
+	}
+	return c, nil
+}
+
+func parseCryptoKey(s string) (CryptoKey, error) {
+	method, info, ok := strings.Cut(s, ":")
+	if !ok {
+		return CryptoKey{}, fmt.Errorf("missing key method")
+	} else if method != "inline" {
+		return CryptoKey{}, fmt.Errorf("unknown key method %q", method)
+	}
+	parts := strings.Split(info, "|")
+	if len(parts) > 3 {
+		return CryptoKey{}, fmt.Errorf("too many key info fields")
+	}
+	// The key and salt are usually unpadded, as their length is
+	// fixed by the crypto suite.
+	enc := base64.StdEncoding
+	if len(parts[0])%4 != 0 {
+		enc = base64.RawStdEncoding
+	}
+	var k CryptoKey
+	var err error
+	k.KeySalt, err = enc.DecodeString(parts[0])
+	if err != nil {
+		return CryptoKey{}, fmt.Errorf("decode key and salt: %w", err)
+	}
+	for _, p := range parts[1:] {
+		if mki, length, ok := strings.Cut(p, ":"); ok {
+			// the MKI is always last.
+			if k.MKILength > 0 {
+				return CryptoKey{}, fmt.Errorf("duplicate mki")
+			}
+			k.MKI, err = strconv.ParseUint(mki, 10, 64)
+			if err != nil {
+				return CryptoKey{}, fmt.Errorf("parse mki: %w", err)
+			}
+			k.MKILength, err = strconv.Atoi(length)
+			if err != nil {
+				return CryptoKey{}, fmt.Errorf("parse mki length: %w", err)
+			} else if k.MKILength < 1 || k.MKILength > 128 {
+				return CryptoKey{}, fmt.Errorf("mki length %d outside 1 to 128", k.MKILength)
+			}
+			continue
+		}
+		if k.MKILength > 0 || k.Lifetime > 0 {
+			return CryptoKey{}, fmt.Errorf("unexpected lifetime %q", p)
+		}
+		if strings.HasPrefix(p, "2^") {
+			n, err := strconv.ParseUint(strings.TrimPrefix(p, "2^"), 10, 6)
+			if err != nil {
+				return CryptoKey{}, fmt.Errorf("parse lifetime: %w", err)
+			}
+			k.Lifetime = 1 << n
+			continue
+		}
+		k.Lifetime, err = strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return CryptoKey{}, fmt.Errorf("parse lifetime: %w", err)
+		}
+	}
+	return k, nil
+}
+
+// Crypto returns the SDES keys offered by the media's "a=crypto"
+// attributes in order of preference, as specified in RFC 4568.
+// An error is returned if any attribute is malformed.
+func (m Media) Crypto() ([]Crypto, error) {
+	var cryptos []Crypto
+	for _, a := range m.Attributes {
+		if a.Name != "crypto" {
+			continue
+		}
+		c, err := parseCrypto(a.Value)
+		if err != nil {
+			return nil, fmt.Errorf("parse crypto %q: %w", a.Value, err)
+		}
+		cryptos = append(cryptos, c)
+	}
+	return cryptos, nil
+}
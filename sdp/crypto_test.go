@@ -0,0 +1,76 @@
+package sdp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestCrypto(t *testing.T) {
+	key80 := "PS1uQCVeeCFCanVmcjkpPywjNWhcYD0mXXtxaVBR"
+	key32 := "d0RmdmcmVCspeEc3QGZiNWpVLFJhQX1cfHAwJSoj"
+	raw := "v=0\r\n" +
+		"o=alice 2891092738 2891092738 IN IP4 192.0.2.1\r\n" +
+		"s=-\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 49000 RTP/SAVP 0\r\n" +
+		"a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:" + key80 + "|2^20|1:32\r\n" +
+		"a=crypto:2 AES_CM_128_HMAC_SHA1_32 inline:" + key32 + "|2^20 KDR=1 UNENCRYPTED_SRTCP\r\n"
+	session, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cryptos, err := session.Media[0].Crypto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cryptos) != 2 {
+		t.Fatalf("got %d crypto attributes, want 2", len(cryptos))
+	}
+
+	c := cryptos[0]
+	if c.Tag != 1 || c.Suite != "AES_CM_128_HMAC_SHA1_80" || len(c.Keys) != 1 {
+		t.Errorf("got %+v", c)
+	}
+	want, _ := base64.RawStdEncoding.DecodeString(key80)
+	k := c.Keys[0]
+	if !bytes.Equal(k.KeySalt, want) || len(k.KeySalt) != 30 {
+		t.Errorf("got key and salt %x, want %x", k.KeySalt, want)
+	}
+	if k.Lifetime != 1<<20 || k.MKI != 1 || k.MKILength != 32 {
+		t.Errorf("got lifetime %d, mki %d:%d", k.Lifetime, k.MKI, k.MKILength)
+	}
+
+	c = cryptos[1]
+	if c.Tag != 2 || c.Suite != "AES_CM_128_HMAC_SHA1_32" {
+		t.Errorf("got tag %d suite %s", c.Tag, c.Suite)
+	}
+	if c.Keys[0].MKILength != 0 || c.Keys[0].Lifetime != 1<<20 {
+		t.Errorf("got key %+v", c.Keys[0])
+	}
+	if len(c.SessionParams) != 2 || c.SessionParams[0] != "KDR=1" || c.SessionParams[1] != "UNENCRYPTED_SRTCP" {
+		t.Errorf("got session params %q", c.SessionParams)
+	}
+
+	for i, a := range session.Media[0].Attributes {
+		if got := cryptos[i].String(); got != a.Value {
+			t.Errorf("crypto %d: got string %q, want %q", i, got, a.Value)
+		}
+	}
+
+	for _, bad := range []string{
+		"1 AES_CM_128_HMAC_SHA1_80",
+		"x AES_CM_128_HMAC_SHA1_80 inline:" + key80,
+		"1 AES_CM_128_HMAC_SHA1_80 uri:" + key80,
+		"1 AES_CM_128_HMAC_SHA1_80 inline:not!base64",
+		"1 AES_CM_128_HMAC_SHA1_80 inline:" + key80 + "|1:4|2^20",
+		"1 AES_CM_128_HMAC_SHA1_80 inline:" + key80 + "|2^20|1:0",
+		"1 AES_CM_128_HMAC_SHA1_80 inline:" + key80 + "|2^64",
+	} {
+		m := Media{Attributes: []Attribute{{Name: "crypto", Value: bad}}}
+		if _, err := m.Crypto(); err == nil {
+			t.Errorf("nil error for crypto %q", bad)
+		}
+	}
+}
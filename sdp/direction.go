@@ -0,0 +1,81 @@
+package sdp
+
+// Direction represents the media direction attributes specified in
+// RFC 8866 section 6.7. The zero value is SendRecv, the default
+// when no direction attribute is present.
+type Direction uint8
+
+const (
+	SendRecv Direction = iota
+	SendOnly
+	RecvOnly
+	Inactive
+)
+
+func (d Direction) String() string {
+	switch d {
+	case SendRecv:
+		return "sendrecv"
+	case SendOnly:
+		return "sendonly"
+	case RecvOnly:
+		return "recvonly"
+	case Inactive:
+		return "inactive"
+	}
+	return "invalid"
+}
+
+func (d Direction) sends() bool    { return d == SendRecv || d == SendOnly }
+func (d Direction) receives() bool { return d == SendRecv || d == RecvOnly }
+
+func direction(attrs []Attribute) (Direction, bool) {
+	for _, a := range attrs {
+		for d := SendRecv; d <= Inactive; d++ {
+			if a.Name == d.String() && a.Value == "" {
+				return d, true
+			}
+		}
+	}
+	return SendRecv, false
+}
+
+// Direction returns the direction of the media. Media without a
+// direction attribute inherit the session's direction; see
+// Session.MediaDirection.
+func (m Media) Direction() Direction {
+	d, _ := direction(m.Attributes)
+	return d
+}
+
+// MediaDirection returns the direction of the media at index i,
+// falling back to the session-level direction attribute if the
+// media has none.
+func (s *Session) MediaDirection(i int) Direction {
+	if d, ok := direction(s.Media[i].Attributes); ok {
+		return d
+	}
+	d, _ := direction(s.Attributes)
+	return d
+}
+
+// AnswerDirection returns the direction an answerer should use for
+// media offered with direction offer, given the direction the
+// answerer would like. The answerer may only send media the offerer
+// is willing to receive, and only receive media the offerer is willing
+// to send, as specified in RFC 3264 section 6.1.
+// For example, if the offer is SendOnly and desired is SendRecv,
+// the answer is RecvOnly.
+func AnswerDirection(offer, desired Direction) Direction {
+	send := desired.sends() && offer.receives()
+	recv := desired.receives() && offer.sends()
+	switch {
+	case send && recv:
+		return SendRecv
+	case send:
+		return SendOnly
+	case recv:
+		return RecvOnly
+	}
+	return Inactive
+}
@@ -0,0 +1,110 @@
+package sdp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TelephoneEvent describes the "telephone-event" payload format of
+// RFC 4733 offered by media, through which SIP endpoints and gateways
+// exchange DTMF digits and other telephony events. For example:
+//
+//	m=audio 49170 RTP/AVP 0 101
+//	a=rtpmap:101 telephone-event/8000
+//	a=fmtp:101 0-15
+type TelephoneEvent struct {
+	PayloadType int
+	ClockRate   int
+	// Events holds the event codes the endpoint can receive, from the
+	// payload type's "a=fmtp" attribute. Without one, the DTMF events
+	// 0 to 15 are supported as specified in RFC 4733 section 7.1.1.
+	Events []EventRange
+}
+
+// EventRange is an inclusive range of telephone event codes, such as
+// 0 to 15 for the DTMF digits 0-9, *, #, and A-D. A single code has
+// equal First and Last.
+type EventRange struct {
+	First, Last int
+}
+
+func (r EventRange) String() string {
+	if r.First == r.Last {
+		return strconv.Itoa(r.First)
+	}
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// Supports reports whether te includes the event code.
+func (te TelephoneEvent) Supports(code int) bool {
+	for _, r := range te.Events {
+		if code >= r.First && code <= r.Last {
+			return true
+		}
+	}
+	return false
+}
+
+// parseEvents parses a comma-separated list of event codes and ranges
+// of codes, such as "0-15,66,70", as specified in RFC 4733 section 7.1.1.
+func parseEvents(s string) ([]EventRange, error) {
+	var events []EventRange
+	for _, ev := range strings.Split(s, ",") {
+		first, last, isRange := strings.Cut(ev, "-")
+		var r EventRange
+		var err error
+		r.First, err = parseEventCode(first)
+		if err != nil {
+			return nil, err
+		}
+		r.Last = r.First
+		if isRange {
+			r.Last, err = parseEventCode(last)
+			if err != nil {
+				return nil, err
+			}
+			if r.Last < r.First {
+				return nil, fmt.Errorf("range %s: end before start", ev)
+			}
+		}
+		events = append(events, r)
+	}
+	return events, nil
+}
+
+func parseEventCode(s string) (int, error) {
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("parse event code %q: %w", s, err)
+	}
+	return int(n), nil
+}
+
+// TelephoneEvent returns the telephone-event payload format of the
+// media, or nil if the media does not offer one. If several are
+// offered, such as at the clock rates of different audio codecs, the
+// first in the media's format list is returned. An error is returned
+// if the events in its "a=fmtp" attribute are malformed.
+func (m Media) TelephoneEvent() (*TelephoneEvent, error) {
+	maps := make(map[string]RTPMap)
+	for _, rm := range m.RTPMaps() {
+		maps[strconv.Itoa(rm.PayloadType)] = rm
+	}
+	for _, f := range m.Format {
+		rm, ok := maps[f]
+		if !ok || !strings.EqualFold(rm.Encoding, "telephone-event") {
+			continue
+		}
+		te := &TelephoneEvent{PayloadType: rm.PayloadType, ClockRate: rm.ClockRate, Events: []EventRange{{0, 15}}}
+		if params, ok := m.fmtp(rm.PayloadType); ok && params != "" {
+			events, err := parseEvents(params)
+			if err != nil {
+				return nil, fmt.Errorf("payload type %d: %w", rm.PayloadType, err)
+			}
+			te.Events = events
+		}
+		return te, nil
+	}
+	return nil, nil
+}
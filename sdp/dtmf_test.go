@@ -0,0 +1,66 @@
+package sdp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTelephoneEvent(t *testing.T) {
+	raw := "v=0\r\n" +
+		"o=gateway 2890844526 2890844526 IN IP4 192.0.2.10\r\n" +
+		"s=-\r\n" +
+		"c=IN IP4 192.0.2.10\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 49170 RTP/AVP 0 8 101\r\n" +
+		"a=rtpmap:0 PCMU/8000\r\n" +
+		"a=rtpmap:8 PCMA/8000\r\n" +
+		"a=rtpmap:101 telephone-event/8000\r\n" +
+		"a=fmtp:101 0-16,66\r\n" +
+		"a=ptime:20\r\n"
+	session, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	te, err := session.Media[0].TelephoneEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if te == nil {
+		t.Fatal("no telephone-event found")
+	}
+	want := &TelephoneEvent{PayloadType: 101, ClockRate: 8000, Events: []EventRange{{0, 16}, {66, 66}}}
+	if !reflect.DeepEqual(te, want) {
+		t.Errorf("got %+v, want %+v", te, want)
+	}
+	for code, ok := range map[int]bool{0: true, 11: true, 16: true, 17: false, 66: true, 70: false} {
+		if te.Supports(code) != ok {
+			t.Errorf("supports event %d = %t, want %t", code, !ok, ok)
+		}
+	}
+
+	m := session.Media[0]
+	m.Attributes = []Attribute{{Name: "rtpmap", Value: "101 telephone-event/8000"}}
+	te, err = m.TelephoneEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(te.Events) != 1 || te.Events[0] != (EventRange{0, 15}) {
+		t.Errorf("got default events %v, want 0-15", te.Events)
+	}
+
+	m.Attributes = m.Attributes[:0:0]
+	if te, err := m.TelephoneEvent(); te != nil || err != nil {
+		t.Errorf("got %+v, %v for media without telephone-event", te, err)
+	}
+
+	for _, events := range []string{"0-", "16-0", "0-256", "a-b", "0,,1", "0 - 15"} {
+		m.Attributes = []Attribute{
+			{Name: "rtpmap", Value: "101 telephone-event/8000"},
+			{Name: "fmtp", Value: "101 " + events},
+		}
+		if _, err := m.TelephoneEvent(); err == nil {
+			t.Errorf("nil error for events %q", events)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package sdp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RTCPFeedback represents the "a=rtcp-fb" attribute specified in
+// RFC 4585 section 4.2, such as "a=rtcp-fb:96 nack pli".
+type RTCPFeedback struct {
+	// PayloadType is the format to which the feedback applies,
+	// or "*" for all formats of the media.
+	PayloadType string
+	Type        string // for example "nack", "ccm", "transport-cc"
+	Param       string // optional, for example "pli" or "fir"
+}
+
+func (fb RTCPFeedback) String() string {
+	s := fb.PayloadType + " " + fb.Type
+	if fb.Param != "" {
+		s += " " + fb.Param
+	}
+	return s
+}
+
+func parseRTCPFeedback(s string) (RTCPFeedback, error) {
+	fields := strings.SplitN(s, " ", 3)
+	if len(fields) < 2 {
+		return RTCPFeedback{}, fmt.Errorf("need payload type and feedback type")
+	}
+	fb := RTCPFeedback{PayloadType: fields[0], Type: fields[1]}
+	if len(fields) == 3 {
+		fb.Param = fields[2]
+	}
+	return fb, nil
+}
+
+// RTCPFeedback returns the media's "a=rtcp-fb" attributes.
+// Malformed attributes are skipped.
+func (m Media) RTCPFeedback() []RTCPFeedback {
+	var fbs []RTCPFeedback
+	for _, a := range m.Attributes {
+		if a.Name != "rtcp-fb" {
+			continue
+		}
+		if fb, err := parseRTCPFeedback(a.Value); err == nil {
+			fbs = append(fbs, fb)
+		}
+	}
+	return fbs
+}
+
+// SupportsTransportCC reports whether the media declares transport-wide
+// congestion control feedback, as in "a=rtcp-fb:* transport-cc",
+// for all or any of its formats.
+func (m Media) SupportsTransportCC() bool {
+	for _, fb := range m.RTCPFeedback() {
+		if fb.Type == "transport-cc" {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtmapTransportCC is the URI of the RTP header extension carrying
+// transport-wide sequence numbers used by transport-cc feedback.
+const ExtmapTransportCC = "http://www.ietf.org/id/draft-holmer-rmcat-transport-wide-cc-extensions-01"
+
+// ExtmapFrameMarking is the URI of the RTP header extension carrying
+// frame marking information for layered video, as specified in RFC 9626.
+const ExtmapFrameMarking = "urn:ietf:params:rtp-hdrext:framemarking"
+
+// Extmap represents the "a=extmap" attribute specified in RFC 8285
+// section 8, mapping an RTP header extension to a local identifier.
+// For example "a=extmap:3 http://example.com/ext".
+type Extmap struct {
+	ID int
+	// Direction is the optional direction of the extension.
+	// It is only meaningful if HasDirection is true.
+	Direction    Direction
+	HasDirection bool
+	URI          string
+	// Attributes holds any extension attributes following the URI.
+	Attributes string
+}
+
+func (e Extmap) String() string {
+	s := strconv.Itoa(e.ID)
+	if e.HasDirection {
+		s += "/" + e.Direction.String()
+	}
+	s += " " + e.URI
+	if e.Attributes != "" {
+		s += " " + e.Attributes
+	}
+	return s
+}
+
+func parseExtmap(s string) (Extmap, error) {
+	fields := strings.SplitN(s, " ", 3)
+	if len(fields) < 2 {
+		return Extmap{}, fmt.Errorf("need identifier and URI")
+	}
+	var e Extmap
+	id, dir, found := strings.Cut(fields[0], "/")
+	if found {
+		d, ok := direction([]Attribute{{Name: dir}})
+		if !ok {
+			return Extmap{}, fmt.Errorf("unknown direction %q", dir)
+		}
+		e.Direction, e.HasDirection = d, true
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return Extmap{}, fmt.Errorf("parse identifier: %w", err)
+	}
+	// RFC 8285 section 5: 0 and 15 are reserved in the one-byte form,
+	// but values up to 255 are valid in the two-byte form.
+	if n < 1 || n > 255 {
+		return Extmap{}, fmt.Errorf("identifier %d out of range", n)
+	}
+	e.ID = n
+	e.URI = fields[1]
+	if len(fields) == 3 {
+		e.Attributes = fields[2]
+	}
+	return e, nil
+}
+
+// Extmaps returns the media's "a=extmap" attributes.
+// Malformed attributes are skipped.
+func (m Media) Extmaps() []Extmap {
+	var exts []Extmap
+	for _, a := range m.Attributes {
+		if a.Name != "extmap" {
+			continue
+		}
+		if e, err := parseExtmap(a.Value); err == nil {
+			exts = append(exts, e)
+		}
+	}
+	return exts
+}
+
+// AnswerExtmaps returns the extmaps an answer should declare for
+// those offered, keeping only extensions whose URI is in supported.
+// The offered IDs are kept, as RFC 8285 section 6 requires an answer
+// to use the identifiers of the offer; renumbering them breaks
+// endpoints which parse header extensions by offered ID. Directions
+// are reversed as in AnswerDirection, so an extension the offerer
+// only sends is answered as received only.
+func AnswerExtmaps(offer []Extmap, supported []string) []Extmap {
+	ok := make(map[string]bool)
+	for _, uri := range supported {
+		ok[uri] = true
+	}
+	var answer []Extmap
+	for _, e := range offer {
+		if !ok[e.URI] {
+			continue
+		}
+		if e.HasDirection {
+			e.Direction = AnswerDirection(e.Direction, SendRecv)
+		}
+		answer = append(answer, e)
+	}
+	return answer
+}
+
+// ValidateExtmapAnswer reports an error if answer, the extmaps of an
+// answer, maps an extension not offered, uses an identifier other
+// than the offered one, or a direction the offer does not permit.
+func ValidateExtmapAnswer(offer, answer []Extmap) error {
+	for _, a := range answer {
+		var offered *Extmap
+		for i := range offer {
+			if offer[i].URI == a.URI && offer[i].ID == a.ID {
+				offered = &offer[i]
+				break
+			}
+		}
+		if offered == nil {
+			for _, o := range offer {
+				if o.URI == a.URI {
+					return fmt.Errorf("extmap %s: renumbered from offered identifier %d", a, o.ID)
+				}
+			}
+			return fmt.Errorf("extmap %s: not offered", a)
+		}
+		// Without a direction an extension is sendrecv.
+		if AnswerDirection(offered.Direction, a.Direction) != a.Direction {
+			return fmt.Errorf("extmap %s: direction %s not permitted by offered %s", a, a.Direction, offered.Direction)
+		}
+	}
+	return nil
+}
+
+// TransportCC reports whether transport-wide congestion control is
+// fully negotiated for the media: transport-cc feedback is declared
+// and the transport-wide sequence number header extension is mapped.
+// Both are needed for congestion control in WebRTC.
+func (m Media) TransportCC() bool {
+	if !m.SupportsTransportCC() {
+		return false
+	}
+	for _, e := range m.Extmaps() {
+		if e.URI == ExtmapTransportCC {
+			return true
+		}
+	}
+	return false
+}
+
+// FrameMarking reports whether the frame marking header extension
+// is mapped for the media, letting middleboxes such as SFUs forward
+// layers of scalable video without decoding the payload.
+func (m Media) FrameMarking() bool {
+	for _, e := range m.Extmaps() {
+		if e.URI == ExtmapFrameMarking {
+			return true
+		}
+	}
+	return false
+}
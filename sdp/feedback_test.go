@@ -0,0 +1,96 @@
+package sdp
+
+import "testing"
+
+func TestTransportCC(t *testing.T) {
+	m := Media{
+		Type:     "video",
+		Port:     9,
+		Protocol: ProtoTLSRTPSecureFeedback,
+		Format:   []string{"96", "97"},
+		Attributes: []Attribute{
+			{Name: "rtpmap", Value: "96 VP8/90000"},
+			{Name: "rtcp-fb", Value: "96 nack pli"},
+			{Name: "rtcp-fb", Value: "* transport-cc"},
+			{Name: "rtpmap", Value: "97 rtx/90000"},
+			{Name: "extmap", Value: "3 " + ExtmapTransportCC},
+			{Name: "extmap", Value: "4/recvonly urn:ietf:params:rtp-hdrext:sdes:mid"},
+		},
+	}
+	fbs := m.RTCPFeedback()
+	if len(fbs) != 2 {
+		t.Fatalf("got %d feedback attributes, want 2", len(fbs))
+	}
+	if want := (RTCPFeedback{"96", "nack", "pli"}); fbs[0] != want {
+		t.Errorf("feedback = %+v, want %+v", fbs[0], want)
+	}
+	if !m.SupportsTransportCC() {
+		t.Errorf("transport-cc feedback not detected")
+	}
+	exts := m.Extmaps()
+	if len(exts) != 2 {
+		t.Fatalf("got %d extmaps, want 2", len(exts))
+	}
+	if exts[1].ID != 4 || !exts[1].HasDirection || exts[1].Direction != RecvOnly {
+		t.Errorf("unexpected extmap %+v", exts[1])
+	}
+	if exts[1].String() != "4/recvonly urn:ietf:params:rtp-hdrext:sdes:mid" {
+		t.Errorf("extmap string = %q", exts[1])
+	}
+	if !m.TransportCC() {
+		t.Errorf("transport-cc not negotiated")
+	}
+
+	m.Attributes = m.Attributes[:4]
+	if m.TransportCC() {
+		t.Errorf("transport-cc negotiated without header extension")
+	}
+}
+
+func TestAnswerExtmaps(t *testing.T) {
+	m := Media{Attributes: []Attribute{
+		{Name: "extmap", Value: "1 urn:ietf:params:rtp-hdrext:ssrc-audio-level"},
+		{Name: "extmap", Value: "2/sendonly urn:ietf:params:rtp-hdrext:toffset"},
+		{Name: "extmap", Value: "3 " + ExtmapTransportCC},
+		{Name: "extmap", Value: "4/recvonly urn:ietf:params:rtp-hdrext:sdes:mid"},
+		{Name: "extmap", Value: "9 " + ExtmapFrameMarking},
+	}}
+	offer := m.Extmaps()
+	supported := []string{
+		"urn:ietf:params:rtp-hdrext:sdes:mid",
+		ExtmapTransportCC,
+		"urn:ietf:params:rtp-hdrext:toffset",
+		"urn:example:unoffered",
+	}
+	answer := AnswerExtmaps(offer, supported)
+	want := []string{
+		"2/recvonly urn:ietf:params:rtp-hdrext:toffset",
+		"3 " + ExtmapTransportCC,
+		"4/sendonly urn:ietf:params:rtp-hdrext:sdes:mid",
+	}
+	if len(answer) != len(want) {
+		t.Fatalf("got %d extmaps, want %d: %v", len(answer), len(want), answer)
+	}
+	for i := range answer {
+		if answer[i].String() != want[i] {
+			t.Errorf("extmap %d = %q, want %q", i, answer[i], want[i])
+		}
+	}
+	if err := ValidateExtmapAnswer(offer, answer); err != nil {
+		t.Errorf("validate own answer: %v", err)
+	}
+
+	renumbered := []Extmap{{ID: 1, URI: ExtmapTransportCC}}
+	if err := ValidateExtmapAnswer(offer, renumbered); err == nil {
+		t.Errorf("nil error for renumbered extmap")
+	}
+	unoffered := []Extmap{{ID: 5, URI: "urn:example:unoffered"}}
+	if err := ValidateExtmapAnswer(offer, unoffered); err == nil {
+		t.Errorf("nil error for unoffered extmap")
+	}
+	// the offerer only sends toffset, so the answerer cannot send it.
+	sending := []Extmap{{ID: 2, URI: "urn:ietf:params:rtp-hdrext:toffset"}}
+	if err := ValidateExtmapAnswer(offer, sending); err == nil {
+		t.Errorf("nil error for sendrecv answer to sendonly extmap")
+	}
+}
@@ -0,0 +1,269 @@
+package sdp
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// ICECandidate represents the "a=candidate" media attribute
+// specified in RFC 8839 section 5.1.
+type ICECandidate struct {
+	Foundation string
+	// Component identifies the component of the media stream,
+	// e.g. 1 for RTP and 2 for RTCP.
+	Component int
+	Transport string // usually "UDP" or "TCP"
+	Priority  uint32
+	// Address is an IP address or a hostname, such as the mDNS
+	// names used by browsers to hide local addresses; see IsMDNS.
+	Address string
+	Port    int
+	Type    string // "host", "srflx", "prflx" or "relay"
+	// RelatedAddress and RelatedPort are from the optional raddr and
+	// rport fields, used for debugging and diagnostics.
+	RelatedAddress string
+	RelatedPort    int
+	// TCPType is the role of a TCP candidate from the tcptype
+	// field specified in RFC 6544 section 4.5: TCPTypeActive,
+	// TCPTypePassive or TCPTypeSimultaneousOpen.
+	// It is empty for UDP candidates.
+	TCPType string
+	// Extensions holds any extension attributes following the
+	// fixed fields, such as "generation 0" or "network-cost 10", in
+	// the order in which they appeared.
+	Extensions []CandidateExtension
+
+	// tcpTypeAt is the number of Extensions preceding the tcptype
+	// field as read, so that String writes it in place.
+	tcpTypeAt int
+}
+
+// Values of ICECandidate.TCPType.
+const (
+	// An active candidate opens an outbound connection but does
+	// not accept incoming ones. Its port is always 9, the discard port.
+	TCPTypeActive = "active"
+	// A passive candidate accepts incoming connections.
+	TCPTypePassive = "passive"
+	// A simultaneous-open candidate attempts to open a connection
+	// simultaneously with its peer.
+	TCPTypeSimultaneousOpen = "so"
+)
+
+// CandidateExtension is an extension attribute of an ICECandidate.
+type CandidateExtension struct {
+	Name  string
+	Value string
+}
+
+func (c ICECandidate) String() string {
+	fields := []string{
+		c.Foundation,
+		strconv.Itoa(c.Component),
+		c.Transport,
+		strconv.FormatUint(uint64(c.Priority), 10),
+		c.Address,
+		strconv.Itoa(c.Port),
+		"typ",
+		c.Type,
+	}
+	if c.RelatedAddress != "" {
+		fields = append(fields, "raddr", c.RelatedAddress)
+	}
+	// Browsers hiding local addresses write "raddr 0.0.0.0 rport 0",
+	// so a zero port is written if there is a related address.
+	if c.RelatedAddress != "" || c.RelatedPort > 0 {
+		fields = append(fields, "rport", strconv.Itoa(c.RelatedPort))
+	}
+	for i, ext := range c.Extensions {
+		if i == c.tcpTypeAt && c.TCPType != "" {
+			fields = append(fields, "tcptype", c.TCPType)
+		}
+		fields = append(fields, ext.Name, ext.Value)
+	}
+	if c.tcpTypeAt >= len(c.Extensions) && c.TCPType != "" {
+		fields = append(fields, "tcptype", c.TCPType)
+	}
+	return strings.Join(fields, " ")
+}
+
+// IsMDNS reports whether the candidate's address is a multicast DNS
+// hostname, such as "1f4712db-ea17-4bcf-a596-105139dfd8bf.local",
+// which browsers use to avoid revealing local IP addresses.
+func (c ICECandidate) IsMDNS() bool {
+	addr := strings.TrimSuffix(strings.ToLower(c.Address), ".")
+	return strings.HasSuffix(addr, ".local")
+}
+
+// ResolveMDNS replaces the address of an mDNS candidate with the
+// address returned by calling resolve with the hostname.
+// Candidates with other addresses are left unchanged.
+// This package does not implement multicast DNS itself.
+func (c *ICECandidate) ResolveMDNS(ctx context.Context, resolve func(ctx context.Context, name string) (netip.Addr, error)) error {
+	if !c.IsMDNS() {
+		return nil
+	}
+	addr, err := resolve(ctx, c.Address)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", c.Address, err)
+	}
+	c.Address = addr.String()
+	return nil
+}
+
+// PairPriority returns the priority of the candidate pair formed by
+// the local and remote candidates, as specified in RFC 8445 section
+// 6.1.2.3. Controlling reports whether the local agent has the
+// controlling role. Connectivity checks are performed on pairs in
+// descending order of priority.
+func PairPriority(local, remote ICECandidate, controlling bool) uint64 {
+	g, d := uint64(local.Priority), uint64(remote.Priority)
+	if !controlling {
+		g, d = d, g
+	}
+	lo, hi := g, d
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	p := lo<<32 + 2*hi
+	if g > d {
+		p++
+	}
+	return p
+}
+
+// IsICELite reports whether the session has the "a=ice-lite"
+// attribute specified in RFC 8839 section 5.3, indicating the agent
+// implements only ICE lite, as is common for media servers with
+// public addresses. A lite agent is always controlled; see ICEControlling.
+func (s *Session) IsICELite() bool {
+	return hasFlag(s.Attributes, "ice-lite")
+}
+
+// ICEControlling reports whether the local agent takes the
+// controlling role, as specified in RFC 8445 section 6.1.1, given
+// the local and remote session descriptions. Offerer reports whether
+// the local agent sent the offer. If exactly one agent is lite, the
+// full agent is controlling; otherwise the offerer is.
+func ICEControlling(local, remote *Session, offerer bool) bool {
+	if local.IsICELite() != remote.IsICELite() {
+		return remote.IsICELite()
+	}
+	return offerer
+}
+
+func parseCandidate(s string) (ICECandidate, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 8 {
+		return ICECandidate{}, fmt.Errorf("need at least %d fields, have %d", 8, len(fields))
+	}
+	c := ICECandidate{
+		Foundation: fields[0],
+		Transport:  fields[2],
+		Address:    fields[4],
+	}
+	var err error
+	c.Component, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return c, fmt.Errorf("parse component id: %w", err)
+	} else if c.Component < 1 {
+		return c, fmt.Errorf("component id %d: must be a positive integer", c.Component)
+	}
+	priority, err := strconv.ParseUint(fields[3], 10, 32)
+	if err != nil {
+		return c, fmt.Errorf("parse priority: %w", err)
+	}
+	c.Priority = uint32(priority)
+	c.Port, err = strconv.Atoi(fields[5])
+	if err != nil {
+		return c, fmt.Errorf("parse port: %w", err)
+	}
+	if fields[6] != "typ" {
+		return c, fmt.Errorf("expected %q, found %q", "typ", fields[6])
+	}
+	c.Type = fields[7]
+
+	rest := fields[8:]
+	if len(rest)%2 != 0 {
+		return c, fmt.Errorf("extension %q: missing value", rest[len(rest)-1])
+	}
+	for i := 0; i < len(rest); i += 2 {
+		name, value := rest[i], rest[i+1]
+		switch name {
+		case "raddr":
+			c.RelatedAddress = value
+		case "rport":
+			c.RelatedPort, err = strconv.Atoi(value)
+			if err != nil {
+				return c, fmt.Errorf("parse related port: %w", err)
+			}
+		case "tcptype":
+			c.TCPType = value
+			c.tcpTypeAt = len(c.Extensions)
+		default:
+			c.Extensions = append(c.Extensions, CandidateExtension{name, value})
+		}
+	}
+	return c, nil
+}
+
+// validateTCPType checks a TCP candidate has a known tcptype, as
+// required by RFC 6544 section 4.5, and that other candidates have none.
+// It is checked by Session.Validate rather than while parsing, so one
+// non-conforming candidate does not hide the others of its media.
+func validateTCPType(c ICECandidate) error {
+	if !strings.EqualFold(c.Transport, "TCP") {
+		if c.TCPType != "" {
+			return fmt.Errorf("tcptype %s on %s candidate", c.TCPType, c.Transport)
+		}
+		return nil
+	}
+	switch c.TCPType {
+	case "":
+		return fmt.Errorf("tcp candidate without tcptype")
+	case TCPTypeActive:
+		// Active candidates do not listen, so RFC 6544
+		// section 4.5 requires the discard port.
+		if c.Port != 9 {
+			return fmt.Errorf("active tcp candidate port %d: must be 9", c.Port)
+		}
+	case TCPTypePassive, TCPTypeSimultaneousOpen:
+	default:
+		return fmt.Errorf("unknown tcptype %q", c.TCPType)
+	}
+	return nil
+}
+
+// Candidates returns the ICE candidates from the media's "a=candidate" attributes.
+func (m Media) Candidates() ([]ICECandidate, error) {
+	var candidates []ICECandidate
+	for _, a := range m.Attributes {
+		if a.Name != "candidate" {
+			continue
+		}
+		c, err := parseCandidate(a.Value)
+		if err != nil {
+			return nil, fmt.Errorf("parse candidate %q: %w", a.Value, err)
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+// CandidatesByComponent returns the media's ICE candidates keyed by
+// component ID. Without RTCP multiplexing, RTP candidates have
+// component ID 1 and RTCP candidates component ID 2.
+func (m Media) CandidatesByComponent() (map[int][]ICECandidate, error) {
+	candidates, err := m.Candidates()
+	if err != nil {
+		return nil, err
+	}
+	components := make(map[int][]ICECandidate)
+	for _, c := range candidates {
+		components[c.Component] = append(components[c.Component], c)
+	}
+	return components, nil
+}
@@ -0,0 +1,279 @@
+package sdp
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCandidateExtensions(t *testing.T) {
+	line := "1467250027 1 udp 2122260223 192.0.2.1 46243 typ host generation 0 network-id 1 network-cost 50"
+	media := Media{
+		Type:       "audio",
+		Port:       9,
+		Protocol:   ProtoTLSRTPSecureFeedback,
+		Format:     []string{"111"},
+		Attributes: []Attribute{{Name: "candidate", Value: line}},
+	}
+	candidates, err := media.Candidates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ICECandidate{
+		Foundation: "1467250027",
+		Component:  1,
+		Transport:  "udp",
+		Priority:   2122260223,
+		Address:    "192.0.2.1",
+		Port:       46243,
+		Type:       "host",
+		Extensions: []CandidateExtension{
+			{"generation", "0"},
+			{"network-id", "1"},
+			{"network-cost", "50"},
+		},
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("got %d candidates, want 1", len(candidates))
+	}
+	if !reflect.DeepEqual(candidates[0], want) {
+		t.Errorf("got candidate %+v, want %+v", candidates[0], want)
+	}
+	if candidates[0].String() != line {
+		t.Errorf("candidate text changed")
+		t.Log("got:", candidates[0].String())
+		t.Log("want:", line)
+	}
+
+	session := &Session{Origin: Origin{"-", 1, 1, "IP4", "192.0.2.1"}, Name: "-", Media: []Media{media}}
+	buf := &strings.Builder{}
+	if err := WriteSession(buf, session); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "a=candidate:"+line+"\r\n") {
+		t.Errorf("written session missing candidate line")
+		t.Log(buf.String())
+	}
+}
+
+func TestCandidateRelated(t *testing.T) {
+	line := "842163049 1 udp 1677729535 198.51.100.7 50833 typ srflx raddr 10.0.0.5 rport 50833 generation 0"
+	c, err := parseCandidate(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.RelatedAddress != "10.0.0.5" || c.RelatedPort != 50833 {
+		t.Errorf("unexpected related address %s port %d", c.RelatedAddress, c.RelatedPort)
+	}
+	if c.String() != line {
+		t.Errorf("candidate text changed: got %q, want %q", c.String(), line)
+	}
+	// Chrome omits the related address of relay candidates.
+	chrome := "3471623853 1 udp 41885439 203.0.113.9 61555 typ relay raddr 0.0.0.0 rport 0 generation 0 network-id 1"
+	c, err = parseCandidate(chrome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.String() != chrome {
+		t.Errorf("candidate text changed: got %q, want %q", c.String(), chrome)
+	}
+	if _, err := parseCandidate("842163049 1 udp 1677729535 198.51.100.7 50833 typ srflx raddr"); err == nil {
+		t.Errorf("nil error parsing extension without value")
+	}
+}
+
+func TestCandidatesByComponent(t *testing.T) {
+	media := Media{
+		Attributes: []Attribute{
+			{Name: "candidate", Value: "1 1 UDP 2130706431 192.0.2.1 5000 typ host"},
+			{Name: "candidate", Value: "1 2 UDP 2130706430 192.0.2.1 5001 typ host"},
+			{Name: "candidate", Value: "2 1 UDP 1694498815 198.51.100.7 5000 typ srflx raddr 192.0.2.1 rport 5000"},
+		},
+	}
+	components, err := media.CandidatesByComponent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(components[1]) != 2 {
+		t.Errorf("got %d RTP candidates, want 2", len(components[1]))
+	}
+	if len(components[2]) != 1 || components[2][0].Port != 5001 {
+		t.Errorf("unexpected RTCP candidates %v", components[2])
+	}
+
+	media.Attributes = append(media.Attributes, Attribute{Name: "candidate", Value: "3 0 UDP 1 192.0.2.1 5002 typ host"})
+	if _, err := media.CandidatesByComponent(); err == nil {
+		t.Errorf("nil error for candidate with component id 0")
+	}
+}
+
+func TestMDNSCandidate(t *testing.T) {
+	line := "1 1 udp 2122262783 1f4712db-ea17-4bcf-a596-105139dfd8bf.local 54321 typ host generation 0"
+	c, err := parseCandidate(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.IsMDNS() {
+		t.Errorf("candidate address %s not reported as mDNS", c.Address)
+	}
+	if c.String() != line {
+		t.Errorf("candidate string = %q, want %q", c.String(), line)
+	}
+	resolve := func(ctx context.Context, name string) (netip.Addr, error) {
+		if name != c.Address {
+			return netip.Addr{}, fmt.Errorf("no such host %s", name)
+		}
+		return netip.MustParseAddr("192.168.1.7"), nil
+	}
+	if err := c.ResolveMDNS(context.Background(), resolve); err != nil {
+		t.Fatal(err)
+	}
+	if c.Address != "192.168.1.7" || c.IsMDNS() {
+		t.Errorf("address after resolving = %s, want 192.168.1.7", c.Address)
+	}
+	// not mDNS, so already resolved.
+	if err := c.ResolveMDNS(context.Background(), resolve); err != nil {
+		t.Errorf("resolve non-mDNS candidate: %v", err)
+	}
+}
+
+func TestPairPriority(t *testing.T) {
+	// Priorities from the formula in RFC 8445 section 5.1.2.1 with
+	// the recommended type preferences and a local preference of 65535.
+	host := ICECandidate{Type: "host", Priority: 126<<24 | 65535<<8 | 255}
+	srflx := ICECandidate{Type: "srflx", Priority: 100<<24 | 65535<<8 | 255}
+	var tests = []struct {
+		local, remote ICECandidate
+		controlling   bool
+		want          uint64
+	}{
+		{host, srflx, true, 1694498815<<32 + 2*2130706431 + 1},
+		{host, srflx, false, 1694498815<<32 + 2*2130706431},
+		{srflx, host, true, 1694498815<<32 + 2*2130706431},
+		{srflx, host, false, 1694498815<<32 + 2*2130706431 + 1},
+		{host, host, true, 2130706431<<32 + 2*2130706431},
+	}
+	for _, tt := range tests {
+		got := PairPriority(tt.local, tt.remote, tt.controlling)
+		if got != tt.want {
+			t.Errorf("PairPriority(%s, %s, %t) = %d, want %d", tt.local.Type, tt.remote.Type, tt.controlling, got, tt.want)
+		}
+	}
+	// The two agents must agree on the priority of the same pair.
+	if PairPriority(host, srflx, true) != PairPriority(srflx, host, false) {
+		t.Errorf("controlling and controlled agents disagree on pair priority")
+	}
+}
+
+func TestICELite(t *testing.T) {
+	raw := "v=0\r\n" +
+		"o=- 1 1 IN IP4 192.0.2.1\r\n" +
+		"s=-\r\n" +
+		"t=0 0\r\n" +
+		"a=ice-lite\r\n" +
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\n" +
+		"a=ice-ufrag:8hhY\r\n" +
+		"a=ice-pwd:asd88fgpdd777uzjYhagZg\r\n"
+	server, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !server.IsICELite() {
+		t.Fatal("ice-lite not detected")
+	}
+	buf := &strings.Builder{}
+	if err := WriteSession(buf, server); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != raw {
+		t.Errorf("ice-lite not preserved")
+		t.Log("got:", buf.String())
+		t.Log("want:", raw)
+	}
+
+	client := &Session{}
+	if client.IsICELite() {
+		t.Error("full agent reported as lite")
+	}
+	var cases = []struct {
+		local, remote *Session
+		offerer       bool
+		want          bool
+	}{
+		{client, server, true, true},
+		{client, server, false, true},
+		{server, client, true, false},
+		{server, client, false, false},
+		{client, client, true, true},
+		{client, client, false, false},
+		{server, server, true, true},
+	}
+	for _, tt := range cases {
+		got := ICEControlling(tt.local, tt.remote, tt.offerer)
+		if got != tt.want {
+			t.Errorf("local lite %t, remote lite %t, offerer %t: controlling %t, want %t",
+				tt.local.IsICELite(), tt.remote.IsICELite(), tt.offerer, got, tt.want)
+		}
+	}
+}
+
+func TestCandidateTCPType(t *testing.T) {
+	var cases = []struct {
+		line string
+		want string
+	}{
+		{"1052353102 1 tcp 1518280447 192.0.2.1 9 typ host tcptype active generation 0", TCPTypeActive},
+		{"2199032595 1 TCP 1518214911 192.0.2.1 443 typ host tcptype passive", TCPTypePassive},
+		{"2199032596 1 tcp 1518214910 192.0.2.1 50000 typ srflx raddr 10.0.0.5 rport 50000 tcptype so", TCPTypeSimultaneousOpen},
+		{"2199032597 1 tcp 1518214909 192.0.2.1 443 typ host generation 0 tcptype passive network-id 1", TCPTypePassive},
+	}
+	for _, tt := range cases {
+		c, err := parseCandidate(tt.line)
+		if err != nil {
+			t.Errorf("parse %q: %v", tt.line, err)
+			continue
+		}
+		if c.TCPType != tt.want {
+			t.Errorf("%q: got tcptype %q, want %q", tt.line, c.TCPType, tt.want)
+		}
+		for _, ext := range c.Extensions {
+			if ext.Name == "tcptype" {
+				t.Errorf("%q: tcptype kept in extensions %v", tt.line, c.Extensions)
+			}
+		}
+		if c.String() != tt.line {
+			t.Errorf("candidate text changed: got %q, want %q", c.String(), tt.line)
+		}
+	}
+
+	good := "2199032595 1 TCP 1518214911 192.0.2.1 443 typ host tcptype passive"
+	for _, bad := range []string{
+		"1052353102 1 tcp 1518280447 192.0.2.1 50000 typ host tcptype active",
+		"1052353102 1 tcp 1518280447 192.0.2.1 9 typ host",
+		"1052353102 1 tcp 1518280447 192.0.2.1 9 typ host tcptype sideways",
+		"1052353102 1 udp 2122260223 192.0.2.1 9 typ host tcptype active",
+	} {
+		m := Media{
+			Type:     "audio",
+			Port:     9,
+			Protocol: ProtoTLSRTPSecureFeedback,
+			Format:   []string{"111"},
+			Attributes: []Attribute{
+				{Name: "candidate", Value: good},
+				{Name: "candidate", Value: bad},
+			},
+		}
+		// a non-conforming candidate does not hide the others.
+		candidates, err := m.Candidates()
+		if err != nil || len(candidates) != 2 {
+			t.Errorf("%q: got %d candidates, error %v; want 2, nil", bad, len(candidates), err)
+		}
+		session := &Session{Origin: Origin{"-", 1, 1, "IP4", "192.0.2.1"}, Name: "-", Media: []Media{m}}
+		if err := session.Validate(); err == nil {
+			t.Errorf("nil error validating %q", bad)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package sdp
+
+import (
+	"net/netip"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Normalize rewrites s in place into a canonical form, so that
+// descriptions differing only cosmetically are identical.
+// Keywords such as address and bandwidth types are upper-cased,
+// media types lower-cased, and bandwidth lines sorted by type.
+// IPv6 addresses are rewritten in their canonical compressed form,
+// such as "2001:db8::1"; parsing keeps them as written.
+// Attributes are left in order, as the order of many, such as
+// "a=rtpmap" and "a=candidate", is significant.
+func (s *Session) Normalize() {
+	s.Origin.AddressType = strings.ToUpper(s.Origin.AddressType)
+	s.Origin.Address = normalizeAddress(s.Origin.AddressType, s.Origin.Address)
+	normalizeConnInfo(s.Connection)
+	normalizeBandwidth(s.Bandwidth)
+	for i := range s.Media {
+		m := &s.Media[i]
+		m.Type = strings.ToLower(m.Type)
+		normalizeConnInfo(m.Connection)
+		normalizeBandwidth(m.Bandwidth)
+	}
+}
+
+func normalizeConnInfo(c *ConnInfo) {
+	if c != nil {
+		c.Type = strings.ToUpper(c.Type)
+		c.Address = normalizeAddress(c.Type, c.Address)
+	}
+}
+
+// normalizeAddress returns the canonical form of addr if it is an
+// IPv6 address, as specified in RFC 5952. Other addresses and
+// hostnames are returned unchanged.
+func normalizeAddress(typ, addr string) string {
+	if typ != "IP6" {
+		return addr
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil || !ip.Is6() {
+		return addr
+	}
+	return ip.String()
+}
+
+func normalizeBandwidth(bw []Bandwidth) {
+	for i := range bw {
+		bw[i].Type = strings.ToUpper(bw[i].Type)
+	}
+	sort.SliceStable(bw, func(i, j int) bool {
+		if bw[i].Type != bw[j].Type {
+			return bw[i].Type < bw[j].Type
+		}
+		return bw[i].Bitrate < bw[j].Bitrate
+	})
+}
+
+// Equal reports whether s and t are equal once normalized.
+// Neither s nor t is modified.
+func (s *Session) Equal(t *Session) bool {
+	a, b := s.clone(), t.clone()
+	a.Normalize()
+	b.Normalize()
+	return reflect.DeepEqual(a, b)
+}
+
+// clone returns a copy of s sharing no memory modified by Normalize.
+func (s *Session) clone() *Session {
+	c := *s
+	c.Connection = cloneConnInfo(s.Connection)
+	c.Bandwidth = append([]Bandwidth(nil), s.Bandwidth...)
+	c.Media = make([]Media, len(s.Media))
+	for i, m := range s.Media {
+		m.Connection = cloneConnInfo(m.Connection)
+		m.Bandwidth = append([]Bandwidth(nil), m.Bandwidth...)
+		c.Media[i] = m
+	}
+	return &c
+}
+
+func cloneConnInfo(c *ConnInfo) *ConnInfo {
+	if c == nil {
+		return nil
+	}
+	cc := *c
+	return &cc
+}
@@ -0,0 +1,70 @@
+package sdp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeEqual(t *testing.T) {
+	const base = "v=0\r\n" +
+		"o=alice 2890844526 2890844526 IN IP4 192.0.2.1\r\n" +
+		"s=-\r\n" +
+		"c=IN IP4 192.0.2.1\r\n" +
+		"%s" +
+		"t=0 0\r\n" +
+		"m=audio 49170 RTP/AVP 0\r\n" +
+		"a=rtpmap:0 PCMU/8000\r\n"
+	one := strings.Replace(base, "%s", "b=AS:256\r\nb=CT:512\r\n", 1)
+	two := strings.Replace(base, "%s", "b=CT:512\r\nb=as:256\r\n", 1)
+	s1, err := ReadSession(strings.NewReader(one))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := ReadSession(strings.NewReader(two))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s1.Equal(s2) {
+		t.Errorf("sessions differing only in bandwidth order are not equal")
+	}
+	if s2.Bandwidth[0].Type != "CT" {
+		t.Errorf("Equal modified its argument")
+	}
+
+	s2.Media[0].Attributes = append(s2.Media[0].Attributes, Attribute{Name: "sendonly"})
+	if s1.Equal(s2) {
+		t.Errorf("sessions with different attributes are equal")
+	}
+
+	s2.Normalize()
+	if s2.Bandwidth[0].Type != "AS" || s2.Bandwidth[1].Type != "CT" {
+		t.Errorf("bandwidth not sorted: %v", s2.Bandwidth)
+	}
+}
+
+func TestNormalizeIPv6(t *testing.T) {
+	raw := "v=0\r\n" +
+		"o=- 1 1 IN IP6 2001:DB8:0:0:0:0:0:1\r\n" +
+		"s=-\r\n" +
+		"c=IN IP6 2001:db8:0000::2\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 49170 RTP/AVP 0\r\n" +
+		"c=IN IP6 media.example.com\r\n"
+	s, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Connection.Address != "2001:db8:0000::2" {
+		t.Errorf("address not kept as written: got %s", s.Connection.Address)
+	}
+	s.Normalize()
+	if s.Origin.Address != "2001:db8::1" {
+		t.Errorf("normalized origin address = %s, want %s", s.Origin.Address, "2001:db8::1")
+	}
+	if s.Connection.Address != "2001:db8::2" {
+		t.Errorf("normalized connection address = %s, want %s", s.Connection.Address, "2001:db8::2")
+	}
+	if s.Media[0].Connection.Address != "media.example.com" {
+		t.Errorf("hostname changed by normalizing: %s", s.Media[0].Connection.Address)
+	}
+}
@@ -0,0 +1,99 @@
+package sdp
+
+import (
+	"strconv"
+	"time"
+)
+
+// OfferOptions holds the parameters used by BuildOffer.
+type OfferOptions struct {
+	// Origin identifies the offerer. If Username is empty, "-" is
+	// used. If Address is empty, the loopback address 127.0.0.1 is
+	// used. If ID is zero, the current time is used.
+	Origin Origin
+
+	// ICE credentials shared by all media sections, from RFC 8839.
+	ICEUfrag    string
+	ICEPassword string
+	// Fingerprint of the DTLS certificate, such as "sha-256 4A:AD:...",
+	// from RFC 8122.
+	Fingerprint string
+
+	// Codecs offered in each media section, in order of preference.
+	// A media section is only created if it has at least one codec.
+	Audio []Codec
+	Video []Codec
+}
+
+// Codec describes an RTP payload format to offer.
+type Codec struct {
+	RTPMap
+	// Params holds format-specific parameters written in the
+	// "a=fmtp" attribute, such as "minptime=10;useinbandfec=1".
+	Params string
+}
+
+// BuildOffer returns a minimal unified-plan WebRTC offer with bundled
+// audio and video media sections described by opts.
+func BuildOffer(opts OfferOptions) *Session {
+	origin := opts.Origin
+	if origin.Username == "" {
+		origin.Username = "-"
+	}
+	if origin.ID == 0 {
+		origin.ID = int(time.Now().Unix() + sinceTimeZero)
+	}
+	if origin.Address == "" {
+		origin.AddressType = "IP4"
+		origin.Address = "127.0.0.1"
+	}
+	s := &Session{
+		Origin:           origin,
+		Name:             "-",
+		TimeDescriptions: []TimeDescription{{}},
+	}
+
+	bundle := Group{Semantics: "BUNDLE"}
+	for _, kind := range []struct {
+		typ    string
+		codecs []Codec
+	}{
+		{"audio", opts.Audio},
+		{"video", opts.Video},
+	} {
+		if len(kind.codecs) == 0 {
+			continue
+		}
+		mid := strconv.Itoa(len(s.Media))
+		m := Media{
+			Type:       kind.typ,
+			Port:       9, // discard port; ICE provides the real address.
+			Protocol:   ProtoTLSRTPSecureFeedback,
+			Connection: &ConnInfo{Type: "IP4", Address: "0.0.0.0"},
+			Attributes: []Attribute{
+				{Name: "mid", Value: mid},
+				{Name: "ice-ufrag", Value: opts.ICEUfrag},
+				{Name: "ice-pwd", Value: opts.ICEPassword},
+				{Name: "fingerprint", Value: opts.Fingerprint},
+				{Name: "setup", Value: "actpass"},
+				{Name: "sendrecv"},
+				{Name: "rtcp-mux"},
+			},
+		}
+		for _, c := range kind.codecs {
+			pt := strconv.Itoa(c.PayloadType)
+			m.Format = append(m.Format, pt)
+			m.Attributes = append(m.Attributes, Attribute{Name: "rtpmap", Value: c.RTPMap.String()})
+			if c.Params != "" {
+				m.Attributes = append(m.Attributes, Attribute{Name: "fmtp", Value: pt + " " + c.Params})
+			}
+		}
+		m.Index = len(s.Media)
+		s.Media = append(s.Media, m)
+		bundle.IDs = append(bundle.IDs, mid)
+	}
+	if len(bundle.IDs) > 0 {
+		s.Attributes = append(s.Attributes, Attribute{Name: "group", Value: bundle.String()})
+	}
+	return s
+}
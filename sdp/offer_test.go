@@ -0,0 +1,59 @@
+package sdp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildOffer(t *testing.T) {
+	opts := OfferOptions{
+		Origin:      Origin{Username: "-", ID: 4611731400430051336, Version: 2, AddressType: "IP4", Address: "127.0.0.1"},
+		ICEUfrag:    "EsAw",
+		ICEPassword: "P2uYro0UCOQ4zxjKXaWCBui1",
+		Fingerprint: "sha-256 D1:2C:BE:AD:C4:F6:64:5C:25:16:11:9C:AF:E7:0F:73:79:36:4E:9C:1E:15:54:39:0C:06:8B:ED:96:86:00:39",
+		Audio: []Codec{
+			{RTPMap{111, "opus", 48000, 2}, "minptime=10;useinbandfec=1"},
+		},
+		Video: []Codec{
+			{RTPMap{102, "H264", 90000, 0}, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42001f"},
+		},
+	}
+	offer := BuildOffer(opts)
+	if err := offer.Validate(); err != nil {
+		t.Fatalf("invalid offer: %v", err)
+	}
+	groups, err := offer.Groups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Group{{"BUNDLE", []string{"0", "1"}}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("offer groups = %v, want %v", groups, want)
+	}
+
+	buf := &strings.Builder{}
+	if err := WriteSession(buf, offer); err != nil {
+		t.Fatalf("write offer: %v", err)
+	}
+	for _, line := range []string{
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111",
+		"a=rtpmap:111 opus/48000/2",
+		"m=video 9 UDP/TLS/RTP/SAVPF 102",
+		"a=rtpmap:102 H264/90000",
+		"a=setup:actpass",
+	} {
+		if !strings.Contains(buf.String(), line+"\r\n") {
+			t.Errorf("offer missing line %q", line)
+		}
+	}
+	got, err := ReadSession(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("read offer: %v", err)
+	}
+	if !reflect.DeepEqual(got, offer) {
+		t.Errorf("offer changed after round trip")
+		t.Log("got:", got)
+		t.Log("want:", offer)
+	}
+}
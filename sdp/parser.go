@@ -2,6 +2,7 @@ package sdp
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -16,17 +17,79 @@ type parser struct {
 	// TODO(otl): rename? key, value is very non-specific...
 	key, value string
 	next       []string // expected next field names
+	strict     bool     // error on unknown field names
+	// pending is true if the current line has been read but not
+	// yet handled, so scan should not read another.
+	pending bool
+	line    int // number of lines read
+	maxLine int // maximum line length in bytes
 
 	session Session
 }
 
+// known holds every field name handled by the parser.
+var known = map[string]bool{
+	"v": true, "o": true, "s": true, "i": true, "u": true, "e": true, "p": true,
+	"c": true, "b": true, "t": true, "r": true, "z": true, "a": true, "m": true,
+}
+
 var ftab = [...]string{"i", "u", "e", "p", "c", "b", "t", "r", "z", "a", "m", "a"}
 
 var mtab = [...]string{"i", "c", "b", "a", "m"}
 
 func (p *parser) scan() bool {
+	if p.pending {
+		p.pending = false
+		return p.expected()
+	}
+	for p.scanLine() {
+		if known[p.key] {
+			break
+		}
+		if p.strict {
+			p.err = fmt.Errorf("unknown field %q", p.key)
+			return false
+		}
+		raw := RawLine{p.key, p.value}
+		if n := len(p.session.Media); n > 0 {
+			p.session.Media[n-1].Unknown = append(p.session.Media[n-1].Unknown, raw)
+		} else {
+			p.session.Unknown = append(p.session.Unknown, raw)
+		}
+	}
+	if p.err != nil || p.key == "" {
+		return false
+	}
+	return p.expected()
+}
+
+// expected reports whether the current field is one of those expected next.
+func (p *parser) expected() bool {
+	if p.next != nil {
+		for i := range p.next {
+			if p.next[i] == p.key {
+				return true
+			}
+		}
+		p.err = fmt.Errorf("unexpected field %q: expected one of %q", p.key, p.next)
+		return false
+	}
+	return true
+}
+
+// scanLine reads the next line into key and value.
+func (p *parser) scanLine() bool {
+	p.key, p.value = "", ""
 	if !p.Scan() {
 		p.err = p.Err()
+		if errors.Is(p.err, bufio.ErrTooLong) {
+			p.err = fmt.Errorf("line %d: longer than %d bytes: %w", p.line+1, p.maxLine, p.err)
+		}
+		return false
+	}
+	p.line++
+	if len(p.Text()) > p.maxLine {
+		p.err = fmt.Errorf("line %d: longer than %d bytes: %w", p.line, p.maxLine, bufio.ErrTooLong)
 		return false
 	}
 	line := strings.TrimSpace(p.Text())
@@ -41,22 +104,18 @@ func (p *parser) scan() bool {
 	}
 	p.key = k
 	p.value = v
-
-	if p.next != nil {
-		for i := range p.next {
-			if p.next[i] == p.key {
-				return true
-			}
-		}
-		p.err = fmt.Errorf("unexpected field %q: expected one of %q", k, p.next)
-		return false
-	}
 	return true
 }
 
 func (p *parser) parse() error {
 	next := "v"
 	for p.scan() {
+		if next == "s" && p.key != "s" {
+			// missing name; reported by Session.Validate.
+			p.session.noName = true
+			p.pending = true
+			return p.parseOptional()
+		}
 		if p.key != next {
 			return fmt.Errorf("expected key %q, found %q", next, p.key)
 		}
@@ -73,15 +132,14 @@ func (p *parser) parse() error {
 		case "o":
 			o, err := parseOrigin(p.value)
 			if err != nil {
-				return fmt.Errorf("parse origin: %w", err)
+				return fmt.Errorf("line %d: parse origin: %w", p.line, err)
 			}
 			p.session.Origin = o
 			next = "s"
 		case "s":
-			if p.value == "" {
-				return fmt.Errorf("empty name")
-			}
-			p.session.Name = p.value
+			// RFC 8866 section 5.3 recommends a single space if
+			// there is no name, but some omit even that.
+			p.session.Name = strings.TrimSpace(p.value)
 			return p.parseOptional()
 		}
 	}
@@ -115,7 +173,7 @@ func (p *parser) parseOptional() error {
 		case "c":
 			conn, err := parseConnInfo(p.value)
 			if err != nil {
-				return fmt.Errorf("parse connection info: %w", err)
+				return fmt.Errorf("line %d: parse connection info: %w", p.line, err)
 			}
 			p.session.Connection = &conn
 			p.next = ftab[5:]
@@ -124,32 +182,43 @@ func (p *parser) parseOptional() error {
 			if err != nil {
 				return fmt.Errorf("parse bandwidth line %q: %w", p.value, err)
 			}
-			p.session.Bandwidth = &bw
-			p.next = ftab[6:]
+			p.session.Bandwidth = append(p.session.Bandwidth, bw)
+			p.next = ftab[5:]
 		case "t":
 			when, err := parseTimes(p.value)
 			if err != nil {
 				return fmt.Errorf("parse time description: %w", err)
 			}
-			p.session.Time = when
-			p.next = ftab[7:]
+			p.session.TimeDescriptions = append(p.session.TimeDescriptions, TimeDescription{Timing: when})
+			p.next = ftab[6:]
 		case "r":
+			if len(p.session.TimeDescriptions) == 0 {
+				return fmt.Errorf("repeat line %q without preceding time description", p.value)
+			}
 			repeat, err := parseRepeat(p.value)
 			if err != nil {
 				return fmt.Errorf("parse repeat: %w", err)
 			}
-			p.session.Repeat = &repeat
-			p.next = ftab[8:]
+			// repeats belong to the most recent time description.
+			td := &p.session.TimeDescriptions[len(p.session.TimeDescriptions)-1]
+			td.Repeats = append(td.Repeats, repeat)
+			p.next = ftab[6:]
 		case "z":
-			return fmt.Errorf("parse time desc %s not yet implemented", p.value)
+			zones, err := parseTimeZones(p.value)
+			if err != nil {
+				return fmt.Errorf("parse time zone adjustments: %w", err)
+			}
+			p.session.TimeZones = zones
+			p.next = ftab[9:]
 		case "a":
-			p.session.Attributes = strings.Fields(p.value)
+			p.session.Attributes = append(p.session.Attributes, parseAttribute(p.value))
 			p.next = ftab[9:]
 		case "m":
 			m, err := parseMedia(p.value)
 			if err != nil {
 				return fmt.Errorf("parse media info from %q: %w", p.value, err)
 			}
+			m.Index = len(p.session.Media)
 			p.session.Media = append(p.session.Media, m)
 			p.next = mtab[:]
 			return p.parseMedia()
@@ -158,6 +227,34 @@ func (p *parser) parseOptional() error {
 	return p.err
 }
 
+// parseFragment parses a lone media description, beginning with
+// its "m=" line, into the parser's session.
+func (p *parser) parseFragment() error {
+	p.next = []string{"m"}
+	if !p.scan() {
+		if p.err != nil {
+			return p.err
+		}
+		return fmt.Errorf("no media description")
+	}
+	if len(p.session.Unknown) > 0 {
+		return fmt.Errorf("unexpected field %q before media description", p.session.Unknown[0].Type)
+	}
+	m, err := parseMedia(p.value)
+	if err != nil {
+		return fmt.Errorf("parse media description: %w", err)
+	}
+	p.session.Media = append(p.session.Media, m)
+	p.next = mtab[:]
+	if err := p.parseMedia(); err != nil {
+		return err
+	}
+	if len(p.session.Media) > 1 {
+		return fmt.Errorf("found %d media descriptions, want 1", len(p.session.Media))
+	}
+	return nil
+}
+
 func (p *parser) parseMedia() error {
 	var media *Media
 	if len(p.session.Media) > 0 {
@@ -171,7 +268,7 @@ func (p *parser) parseMedia() error {
 		case "c":
 			conn, err := parseConnInfo(p.value)
 			if err != nil {
-				return fmt.Errorf("parse connection info: %w", err)
+				return fmt.Errorf("line %d: parse connection info: %w", p.line, err)
 			}
 			media.Connection = &conn
 			p.next = mtab[2:]
@@ -180,16 +277,17 @@ func (p *parser) parseMedia() error {
 			if err != nil {
 				return fmt.Errorf("parse bandwidth: %w", err)
 			}
-			media.Bandwidth = &bw
-			p.next = mtab[3:]
+			media.Bandwidth = append(media.Bandwidth, bw)
+			p.next = mtab[2:]
 		case "a":
-			media.Attributes = strings.Fields(p.value)
-			p.next = mtab[4:]
+			media.Attributes = append(media.Attributes, parseAttribute(p.value))
+			p.next = mtab[3:]
 		case "m":
 			m, err := parseMedia(p.value)
 			if err != nil {
 				return fmt.Errorf("parse media description: %w", err)
 			}
+			m.Index = len(p.session.Media)
 			p.session.Media = append(p.session.Media, m)
 			media = &p.session.Media[len(p.session.Media)-1]
 			p.next = mtab[:]
@@ -264,6 +362,38 @@ func parseRepeat(s string) (Repeat, error) {
 	return repeat, nil
 }
 
+// TimeZoneAdjustment represents one adjustment time and offset pair
+// from a "z=" line as specified in RFC 8866 section 5.11.
+// Adjustments let repeated sessions span a daylight saving time change.
+type TimeZoneAdjustment struct {
+	// AdjustmentTime is the time, in seconds since the SDP zero
+	// time, at which the Offset starts to apply.
+	AdjustmentTime uint64
+	Offset         time.Duration
+}
+
+func parseTimeZones(s string) ([]TimeZoneAdjustment, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no adjustments")
+	} else if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("odd number of fields %d: need adjustment time and offset pairs", len(fields))
+	}
+	zones := make([]TimeZoneAdjustment, len(fields)/2)
+	for i := range zones {
+		var err error
+		zones[i].AdjustmentTime, err = strconv.ParseUint(fields[2*i], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse adjustment time: %w", err)
+		}
+		zones[i].Offset, err = parseDuration(fields[2*i+1])
+		if err != nil {
+			return nil, fmt.Errorf("parse offset %s: %w", fields[2*i+1], err)
+		}
+	}
+	return zones, nil
+}
+
 func parseDuration(s string) (time.Duration, error) {
 	// a bare int, like 86400
 	i, err := strconv.Atoi(s)
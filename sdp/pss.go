@@ -0,0 +1,83 @@
+package sdp
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// PSS holds the media attributes of the 3GPP Packet-switched
+// Streaming Service specified in 3GPP TS 26.234 section 5.3.3,
+// used by legacy mobile streaming and MBMS equipment to size client
+// buffers. For example:
+//
+//	a=X-predecbufsize:51200
+//	a=X-initpredecbufperiod:180000
+//	a=X-initpostdecbufperiod:90000
+//	a=3GPP-Adaptation-Support:2
+//
+// Fields are zero if the corresponding attribute is absent.
+type PSS struct {
+	// PredecoderBufferSize is the size in bytes of the hypothetical
+	// pre-decoder buffer, from the "a=X-predecbufsize" attribute.
+	PredecoderBufferSize int
+	// InitialPredecoderPeriod is how long the pre-decoder buffer is
+	// filled before decoding starts, from the "a=X-initpredecbufperiod"
+	// attribute.
+	InitialPredecoderPeriod time.Duration
+	// InitialPostdecoderPeriod is how long the post-decoder buffer is
+	// filled before playback starts, from the "a=X-initpostdecbufperiod"
+	// attribute.
+	InitialPostdecoderPeriod time.Duration
+	// DecodeByteRate is the peak decoding rate in bytes per second,
+	// from the "a=X-decbyterate" attribute.
+	DecodeByteRate int
+	// AdaptationSupport is the number of RTCP reports between each
+	// report of the client's buffer status, from the
+	// "a=3GPP-Adaptation-Support" attribute.
+	AdaptationSupport int
+}
+
+// pssClockRate is the rate of the clock in which the buffering
+// periods of PSS attributes are measured.
+const pssClockRate = 90000
+
+// PSS returns the 3GPP PSS attributes of the media, or nil if it has
+// none. The attributes themselves remain in Attributes, so are
+// written unchanged by WriteSession.
+func (m Media) PSS() (*PSS, error) {
+	var pss PSS
+	var found bool
+	for _, a := range m.Attributes {
+		var n *int
+		var period *time.Duration
+		switch a.Name {
+		case "X-predecbufsize":
+			n = &pss.PredecoderBufferSize
+		case "X-initpredecbufperiod":
+			period = &pss.InitialPredecoderPeriod
+		case "X-initpostdecbufperiod":
+			period = &pss.InitialPostdecoderPeriod
+		case "X-decbyterate":
+			n = &pss.DecodeByteRate
+		case "3GPP-Adaptation-Support":
+			n = &pss.AdaptationSupport
+		default:
+			continue
+		}
+		v, err := strconv.ParseUint(a.Value, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", a.Name, err)
+		}
+		if period != nil {
+			*period = time.Duration(v) * time.Second / pssClockRate
+		} else {
+			*n = int(v)
+		}
+		found = true
+	}
+	if !found {
+		return nil, nil
+	}
+	return &pss, nil
+}
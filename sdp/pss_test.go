@@ -0,0 +1,59 @@
+package sdp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPSS(t *testing.T) {
+	raw := "v=0\r\n" +
+		"o=- 1 1 IN IP4 192.0.2.1\r\n" +
+		"s=Mobile stream\r\n" +
+		"t=0 0\r\n" +
+		"m=video 0 RTP/AVP 96\r\n" +
+		"b=AS:128\r\n" +
+		"a=rtpmap:96 H264/90000\r\n" +
+		"a=X-predecbufsize:51200\r\n" +
+		"a=X-initpredecbufperiod:180000\r\n" +
+		"a=X-initpostdecbufperiod:45000\r\n" +
+		"a=X-decbyterate:16000\r\n" +
+		"a=3GPP-Adaptation-Support:2\r\n" +
+		"m=audio 0 RTP/AVP 97\r\n" +
+		"a=rtpmap:97 AMR/8000\r\n"
+	session, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pss, err := session.Media[0].PSS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PSS{
+		PredecoderBufferSize:     51200,
+		InitialPredecoderPeriod:  2 * time.Second,
+		InitialPostdecoderPeriod: 500 * time.Millisecond,
+		DecodeByteRate:           16000,
+		AdaptationSupport:        2,
+	}
+	if pss == nil || *pss != want {
+		t.Errorf("got %+v, want %+v", pss, want)
+	}
+	if pss, err := session.Media[1].PSS(); pss != nil || err != nil {
+		t.Errorf("media without PSS attributes: got %+v, %v", pss, err)
+	}
+
+	buf := &strings.Builder{}
+	if err := WriteSession(buf, session); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != raw {
+		t.Errorf("PSS attributes not preserved")
+		t.Log("got:", buf.String())
+	}
+
+	session.Media[0].Attributes[1].Value = "-1"
+	if _, err := session.Media[0].PSS(); err == nil {
+		t.Errorf("nil error parsing negative buffer size")
+	}
+}
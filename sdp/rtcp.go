@@ -0,0 +1,96 @@
+package sdp
+
+import (
+	"strconv"
+	"time"
+)
+
+// RTCPMinInterval returns the minimum interval between regular RTCP
+// reports from the "trr-int" parameter of the media's
+// "a=rtcp-fb:* trr-int" attribute specified in RFC 4585 section 3.4.
+// The boolean is false if the media has no such attribute.
+func (m Media) RTCPMinInterval() (time.Duration, bool) {
+	for _, fb := range m.RTCPFeedback() {
+		if fb.Type != "trr-int" {
+			continue
+		}
+		ms, err := strconv.ParseUint(fb.Param, 10, 32)
+		if err != nil {
+			continue
+		}
+		return time.Duration(ms) * time.Millisecond, true
+	}
+	return 0, false
+}
+
+// RTCPMembers describes the participants of an RTP session, from
+// which to compute the interval between RTCP reports.
+type RTCPMembers struct {
+	// Members is the number of participants, including the local one.
+	Members int
+	// Senders is the number of participants which recently sent RTP.
+	Senders int
+	// WeSent reports whether the local participant is a sender.
+	WeSent bool
+	// AvgPacketSize is the average size in bytes of the compound
+	// RTCP packets sent and received, including lower-layer headers.
+	AvgPacketSize int
+	// Initial reports whether no RTCP packet has been sent yet,
+	// which halves the minimum interval.
+	Initial bool
+}
+
+// RTCPInterval returns the deterministic interval between RTCP
+// reports computed by the algorithm in RFC 3550 section 6.3.1, before
+// it is randomised. The bandwidth for RTCP is from the media's "b=RS"
+// and "b=RR" lines, as specified in RFC 3556, with any omitted
+// defaulting to 1.25% and 3.75% respectively of the media's "b=AS"
+// bandwidth. The boolean is false if the media has no RTCP
+// bandwidth, either because it declares none or because RTCP is
+// disabled by zero RS and RR bandwidths.
+func (m Media) RTCPInterval(members RTCPMembers) (time.Duration, bool) {
+	as, hasAS := bitrate(m.Bandwidth, "AS")
+	rs, hasRS := m.RTCPSenderBandwidth()
+	rr, hasRR := m.RTCPReceiverBandwidth()
+	if !hasRS && !hasRR && !hasAS {
+		return 0, false
+	}
+	// Bandwidths in bits per second.
+	senderBW, receiverBW := float64(rs), float64(rr)
+	if !hasRS {
+		senderBW = float64(as) * 0.0125
+	}
+	if !hasRR {
+		receiverBW = float64(as) * 0.0375
+	}
+	bw := senderBW + receiverBW
+	if bw <= 0 {
+		return 0, false
+	}
+
+	tmin := 5 * time.Second
+	if members.Initial {
+		tmin /= 2
+	}
+	// If there are few senders, they share the sender bandwidth and
+	// the remaining members the receiver bandwidth.
+	n := float64(members.Members)
+	if float64(members.Senders) <= n*senderBW/bw {
+		if members.WeSent {
+			bw = senderBW
+			n = float64(members.Senders)
+		} else {
+			bw = receiverBW
+			n = float64(members.Members - members.Senders)
+		}
+	}
+	if bw <= 0 {
+		return tmin, true
+	}
+	seconds := float64(members.AvgPacketSize*8) * n / bw
+	t := time.Duration(seconds * float64(time.Second))
+	if t < tmin {
+		t = tmin
+	}
+	return t, true
+}
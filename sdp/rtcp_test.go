@@ -0,0 +1,67 @@
+package sdp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRTCPInterval(t *testing.T) {
+	raw := "v=0\r\n" +
+		"o=- 1 1 IN IP4 192.0.2.1\r\n" +
+		"s=Conference\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 49170 RTP/SAVPF 0\r\n" +
+		"b=RS:800\r\n" +
+		"b=RR:2400\r\n" +
+		"a=rtcp-fb:* trr-int 100\r\n" +
+		"m=audio 49172 RTP/AVP 0\r\n" +
+		"b=AS:64\r\n" +
+		"m=audio 49174 RTP/AVP 0\r\n" +
+		"b=RS:0\r\n" +
+		"b=RR:0\r\n"
+	session, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// RS and RR above are the defaults for b=AS:64, so the first
+	// two media have the same intervals.
+	var cases = []struct {
+		name    string
+		members RTCPMembers
+		want    time.Duration
+	}{
+		// 49 receivers share 2400 bits per second.
+		{"receiver", RTCPMembers{Members: 50, Senders: 1, AvgPacketSize: 100}, 16333333333},
+		{"sender", RTCPMembers{Members: 50, Senders: 1, WeSent: true, AvgPacketSize: 100}, 5 * time.Second},
+		{"initial sender", RTCPMembers{Members: 50, Senders: 1, WeSent: true, AvgPacketSize: 100, Initial: true}, 2500 * time.Millisecond},
+		// Over a quarter of members send, so all share 3200 bits per second.
+		{"many senders", RTCPMembers{Members: 100, Senders: 50, AvgPacketSize: 100}, 25 * time.Second},
+		{"two party", RTCPMembers{Members: 2, Senders: 2, WeSent: true, AvgPacketSize: 100}, 5 * time.Second},
+	}
+	for _, m := range session.Media[:2] {
+		for _, tt := range cases {
+			got, ok := m.RTCPInterval(tt.members)
+			if !ok {
+				t.Errorf("media %d %s: no interval", m.Index, tt.name)
+				continue
+			}
+			if d := got - tt.want; d < -time.Microsecond || d > time.Microsecond {
+				t.Errorf("media %d %s: got interval %s, want %s", m.Index, tt.name, got, tt.want)
+			}
+		}
+	}
+	if _, ok := session.Media[2].RTCPInterval(cases[0].members); ok {
+		t.Errorf("got interval for media with RTCP disabled")
+	}
+	if _, ok := (Media{}).RTCPInterval(cases[0].members); ok {
+		t.Errorf("got interval for media without bandwidth")
+	}
+
+	if d, ok := session.Media[0].RTCPMinInterval(); !ok || d != 100*time.Millisecond {
+		t.Errorf("got minimum interval %s, %t, want 100ms", d, ok)
+	}
+	if _, ok := session.Media[1].RTCPMinInterval(); ok {
+		t.Errorf("got minimum interval for media without trr-int")
+	}
+}
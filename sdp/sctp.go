@@ -0,0 +1,105 @@
+package sdp
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// FormatDataChannel is the media format of WebRTC data channels,
+// carried over SCTP as specified in RFC 8841 and RFC 8831.
+// For example:
+//
+//	m=application 9 UDP/DTLS/SCTP webrtc-datachannel
+//	a=sctp-port:5000
+//	a=max-message-size:262144
+const FormatDataChannel = "webrtc-datachannel"
+
+// Defaults for SCTP media omitting the "a=sctp-port" and
+// "a=max-message-size" attributes, from RFC 8841 sections 5 and 6.
+const (
+	DefaultSCTPPort       = 5000
+	DefaultMaxMessageSize = 65536
+)
+
+// isSCTP reports whether proto carries SCTP over DTLS.
+func isSCTP(proto uint8) bool {
+	return proto == ProtoDTLSSCTP || proto == ProtoTCPDTLSSCTP
+}
+
+// DataChannel reports whether the media carries WebRTC data channels:
+// an "application" media with the webrtc-datachannel format over
+// DTLS/SCTP.
+func (m Media) DataChannel() bool {
+	if m.Type != "application" || !isSCTP(m.Protocol) {
+		return false
+	}
+	for _, f := range m.Format {
+		if f == FormatDataChannel {
+			return true
+		}
+	}
+	return false
+}
+
+// SCTPPort returns the SCTP port of the media from its "a=sctp-port"
+// attribute, or DefaultSCTPPort if it has none or it is malformed.
+// The SCTP port identifies the association within the DTLS
+// connection, and is unrelated to the port of the "m=" line.
+func (m Media) SCTPPort() int {
+	v, ok := attrValue(m.Attributes, "sctp-port")
+	if !ok {
+		return DefaultSCTPPort
+	}
+	port, err := strconv.ParseUint(v, 10, 16)
+	if err != nil {
+		return DefaultSCTPPort
+	}
+	return int(port)
+}
+
+// MaxMessageSize returns the largest SCTP message in bytes the
+// endpoint can receive, from the media's "a=max-message-size"
+// attribute. Zero indicates there is no limit. MaxMessageSize returns
+// DefaultMaxMessageSize if the media has no such attribute or it is
+// malformed.
+func (m Media) MaxMessageSize() int {
+	v, ok := attrValue(m.Attributes, "max-message-size")
+	if !ok {
+		return DefaultMaxMessageSize
+	}
+	n, err := strconv.ParseUint(v, 10, 31)
+	if err != nil {
+		return DefaultMaxMessageSize
+	}
+	return int(n)
+}
+
+// validateSCTP checks media using the webrtc-datachannel format are
+// "application" media over DTLS/SCTP, and that any "a=sctp-port" and
+// "a=max-message-size" attributes are well-formed.
+func validateSCTP(m Media) error {
+	for _, f := range m.Format {
+		if f != FormatDataChannel {
+			continue
+		}
+		if m.Type != "application" {
+			return fmt.Errorf("format %s in %s media", FormatDataChannel, m.Type)
+		} else if !isSCTP(m.Protocol) {
+			return fmt.Errorf("format %s over protocol %s", FormatDataChannel, protocolName(m.Protocol))
+		}
+	}
+	if v, ok := attrValue(m.Attributes, "sctp-port"); ok {
+		if !isSCTP(m.Protocol) {
+			return fmt.Errorf("sctp-port with protocol %s", protocolName(m.Protocol))
+		}
+		if _, err := strconv.ParseUint(v, 10, 16); err != nil {
+			return fmt.Errorf("parse sctp-port: %w", err)
+		}
+	}
+	if v, ok := attrValue(m.Attributes, "max-message-size"); ok {
+		if _, err := strconv.ParseUint(v, 10, 31); err != nil {
+			return fmt.Errorf("parse max-message-size: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package sdp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDataChannel(t *testing.T) {
+	raw := "v=0\r\n" +
+		"o=- 4611731400430051336 2 IN IP4 127.0.0.1\r\n" +
+		"s=-\r\n" +
+		"t=0 0\r\n" +
+		"a=group:BUNDLE 0\r\n" +
+		"m=application 9 UDP/DTLS/SCTP webrtc-datachannel\r\n" +
+		"c=IN IP4 0.0.0.0\r\n" +
+		"a=ice-ufrag:EsAw\r\n" +
+		"a=ice-pwd:bP+XJMM09aR8AiX1jdukzR6Y\r\n" +
+		"a=fingerprint:sha-256 19:E2:1C:3B:4B:9F:81:E6:B8:5C:F4:A5:A8:D8:73:04:BB:05:2F:70:9F:04:A9:0E:05:E9:26:33:E8:70:88:A2\r\n" +
+		"a=setup:actpass\r\n" +
+		"a=mid:0\r\n" +
+		"a=sctp-port:5001\r\n" +
+		"a=max-message-size:262144\r\n"
+	session, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	m := session.Media[0]
+	if m.Protocol != ProtoDTLSSCTP {
+		t.Errorf("protocol = %s, want %s", protocols[m.Protocol], protocols[ProtoDTLSSCTP])
+	}
+	if !m.DataChannel() {
+		t.Errorf("media not recognised as data channel")
+	}
+	if m.SCTPPort() != 5001 {
+		t.Errorf("sctp port = %d, want %d", m.SCTPPort(), 5001)
+	}
+	if m.MaxMessageSize() != 262144 {
+		t.Errorf("max message size = %d, want %d", m.MaxMessageSize(), 262144)
+	}
+	buf := &strings.Builder{}
+	if err := WriteSession(buf, session); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != raw {
+		t.Errorf("data channel session not reproduced")
+		t.Log("got:", buf.String())
+	}
+
+	m.Attributes = nil
+	if m.SCTPPort() != DefaultSCTPPort || m.MaxMessageSize() != DefaultMaxMessageSize {
+		t.Errorf("got port %d, max message size %d; want defaults", m.SCTPPort(), m.MaxMessageSize())
+	}
+
+	var bad = []struct {
+		name  string
+		media string
+		attrs []Attribute
+	}{
+		{"rtp protocol", "application 9 UDP/TLS/RTP/SAVPF webrtc-datachannel", nil},
+		{"audio type", "audio 9 UDP/DTLS/SCTP webrtc-datachannel", nil},
+		{"port range", "application 9 UDP/DTLS/SCTP webrtc-datachannel", []Attribute{{Name: "sctp-port", Value: "70000"}}},
+		{"message size", "application 9 UDP/DTLS/SCTP webrtc-datachannel", []Attribute{{Name: "max-message-size", Value: "-1"}}},
+		{"sctp-port over rtp", "audio 9 UDP/TLS/RTP/SAVPF 111", []Attribute{{Name: "sctp-port", Value: "5000"}}},
+	}
+	for _, tt := range bad {
+		m, err := parseMedia(tt.media)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		m.Attributes = tt.attrs
+		if err := validateSCTP(m); err == nil {
+			t.Errorf("%s: nil error validating media", tt.name)
+		}
+	}
+
+	unknown := Media{Type: "application", Protocol: 200, Format: []string{FormatDataChannel}}
+	if err := validateSCTP(unknown); err == nil {
+		t.Errorf("nil error validating data channel over unknown protocol")
+	}
+	unknown = Media{Type: "audio", Protocol: 200, Format: []string{"0"}, Attributes: []Attribute{{Name: "sctp-port", Value: "5000"}}}
+	if err := validateSCTP(unknown); err == nil {
+		t.Errorf("nil error validating sctp-port over unknown protocol")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/mail"
+	"net/netip"
 	"net/url"
 	"strconv"
 	"strings"
@@ -23,13 +24,37 @@ type Session struct {
 	Email      *mail.Address
 	Phone      string
 	Connection *ConnInfo
-	Bandwidth  *Bandwidth
-	// Time holds the start time and stop time of the Session, at
-	// the first and second index respectively.
-	Time       [2]time.Time
-	Repeat     *Repeat
-	Attributes []string
-	Media      []Media
+	Bandwidth  []Bandwidth
+	// TimeDescriptions holds when the Session is active, in the
+	// order of the "t=" lines in which they were described.
+	TimeDescriptions []TimeDescription
+	TimeZones        []TimeZoneAdjustment
+	Attributes       []Attribute
+	Media            []Media
+	// Unknown holds session-level lines of types not otherwise
+	// handled by this package, such as the obsolete "k=" line.
+	Unknown []RawLine
+
+	// noName is true if the "s=" line was missing when read.
+	noName bool
+}
+
+// RawLine is a line of SDP of a type not handled by this package.
+type RawLine struct {
+	Type  string
+	Value string
+}
+
+func (l RawLine) String() string { return l.Type + "=" + l.Value }
+
+// TimeDescription represents a "t=" line and any following "r="
+// lines as specified in RFC 8866 sections 5.9 and 5.10.
+type TimeDescription struct {
+	// Timing holds the start time and stop time, at the first and
+	// second index respectively. A zero time.Time means the
+	// session is unbounded.
+	Timing  [2]time.Time
+	Repeats []Repeat
 }
 
 type Origin struct {
@@ -40,14 +65,154 @@ type Origin struct {
 	Address     string // IPv4, IPv6 literal or a hostname
 }
 
+// ReadSession reads a session description from rd.
+// Lines of unknown type are stored in the Unknown field of the
+// Session or Media in which they appear.
+// ReadSession is equivalent to calling ReadSession on a zero Reader.
 func ReadSession(rd io.Reader) (*Session, error) {
-	parser := &parser{Scanner: bufio.NewScanner(rd)}
+	return Reader{}.ReadSession(rd)
+}
+
+// Reader reads session descriptions with configurable behaviour.
+type Reader struct {
+	// Strict, if true, causes lines of unknown type to be an
+	// error rather than being preserved.
+	Strict bool
+
+	// MaxLineLength is the maximum length in bytes of a line,
+	// excluding its line terminator. Longer lines are an error,
+	// limiting the memory used when reading from untrusted sources.
+	// Input is read a line at a time, so a session with many lines,
+	// such as one with many SSRC or simulcast attributes, needs no
+	// more memory than its longest line for reading.
+	// Zero indicates DefaultMaxLineLength.
+	MaxLineLength int
+}
+
+// DefaultMaxLineLength is the maximum line length used by a Reader
+// with no MaxLineLength set.
+const DefaultMaxLineLength = bufio.MaxScanTokenSize - len("\r\n")
+
+func (r Reader) newParser(rd io.Reader) *parser {
+	max := r.MaxLineLength
+	if max <= 0 {
+		max = DefaultMaxLineLength
+	}
+	sc := bufio.NewScanner(rd)
+	// room for the largest line plus its CRLF terminator.
+	size := max + len("\r\n")
+	initial := 4096
+	if size < initial {
+		initial = size
+	}
+	sc.Buffer(make([]byte, initial), size)
+	return &parser{Scanner: sc, strict: r.Strict, maxLine: max}
+}
+
+// ReadSession reads a session description from rd.
+func (r Reader) ReadSession(rd io.Reader) (*Session, error) {
+	parser := r.newParser(rd)
 	if err := parser.parse(); err != nil {
 		return nil, fmt.Errorf("parse session: %w", err)
 	}
 	return &parser.session, nil
 }
 
+// ReadMedia reads a lone media description from rd, beginning with
+// its "m=" line and with no session-level fields, as exchanged by
+// signalling protocols which renegotiate one media at a time.
+// ReadMedia is equivalent to calling ReadMedia on a zero Reader.
+func ReadMedia(rd io.Reader) (*Media, error) {
+	return Reader{}.ReadMedia(rd)
+}
+
+// ReadMedia reads a lone media description from rd.
+func (r Reader) ReadMedia(rd io.Reader) (*Media, error) {
+	parser := r.newParser(rd)
+	if err := parser.parseFragment(); err != nil {
+		return nil, fmt.Errorf("parse media: %w", err)
+	}
+	return &parser.session.Media[0], nil
+}
+
+// MediaByMID returns the media identified by the "a=mid" attribute
+// value mid, or nil if there is no such media. Media without a mid
+// are never returned, even for an empty mid.
+func (s *Session) MediaByMID(mid string) *Media {
+	if mid == "" {
+		return nil
+	}
+	for i := range s.Media {
+		if s.Media[i].MID() == mid {
+			return &s.Media[i]
+		}
+	}
+	return nil
+}
+
+// MediaAt returns the media at index i, or nil if i is out of range.
+// See Media.Index.
+func (s *Session) MediaAt(i int) *Media {
+	if i < 0 || i >= len(s.Media) {
+		return nil
+	}
+	return &s.Media[i]
+}
+
+// MediaByType returns the media of the given type, such as "audio" or "video".
+func (s *Session) MediaByType(typ string) []*Media {
+	var media []*Media
+	for i := range s.Media {
+		if s.Media[i].Type == typ {
+			media = append(media, &s.Media[i])
+		}
+	}
+	return media
+}
+
+// SelectMedia returns a copy of s holding only the media for which
+// pred returns true. References to the MIDs of dropped media are
+// removed from the session's groups, and groups left empty are removed.
+func (s *Session) SelectMedia(pred func(Media) bool) *Session {
+	selected := *s
+	selected.Media = nil
+	dropped := make(map[string]bool)
+	for _, m := range s.Media {
+		if pred(m) {
+			m.Index = len(selected.Media)
+			selected.Media = append(selected.Media, m)
+		} else if mid := m.MID(); mid != "" {
+			dropped[mid] = true
+		}
+	}
+
+	selected.Attributes = nil
+	for _, a := range s.Attributes {
+		if a.Name != "group" {
+			selected.Attributes = append(selected.Attributes, a)
+			continue
+		}
+		g, err := parseGroup(a.Value)
+		if err != nil {
+			// not ours to judge; keep it as is.
+			selected.Attributes = append(selected.Attributes, a)
+			continue
+		}
+		var ids []string
+		for _, id := range g.IDs {
+			if !dropped[id] {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 && len(g.IDs) > 0 {
+			continue
+		}
+		g.IDs = ids
+		selected.Attributes = append(selected.Attributes, Attribute{Name: "group", Value: g.String()})
+	}
+	return &selected
+}
+
 // cleanPhone returns the phone number in s stripped of "-" and space
 // characters. Since "+1 617 555-6011" is semantically equal to
 // "+16175556011", storing the number in the latter form lets us test for
@@ -77,9 +242,26 @@ func parseOrigin(line string) (Origin, error) {
 	}
 	o.AddressType = fields[4]
 	o.Address = fields[5]
+	if err := checkAddress(o.AddressType, o.Address); err != nil {
+		return o, err
+	}
 	return o, nil
 }
 
+// checkAddress returns an error if addr, of address type typ,
+// is a malformed IPv6 address. Hostnames are permitted in place
+// of addresses, but cannot contain colons, so are not checked.
+func checkAddress(typ, addr string) error {
+	if typ != "IP6" || !strings.Contains(addr, ":") {
+		return nil
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil || !ip.Is6() {
+		return fmt.Errorf("invalid IPv6 address %q", addr)
+	}
+	return nil
+}
+
 // parseEmail returns the parsed email address from s.
 // Addresses should be in RFC 5322 form, for instance
 // "Oliver Lowe <o@olowe.co>" or just "o@olowe.co".
@@ -98,9 +280,56 @@ type Bandwidth struct {
 	Bitrate int // bits per second
 }
 
+// bitrate returns the bitrate of the first bandwidth of type typ in bw.
+// The boolean is false if there is no such bandwidth.
+func bitrate(bw []Bandwidth, typ string) (int, bool) {
+	for _, b := range bw {
+		if strings.EqualFold(b.Type, typ) {
+			return b.Bitrate, true
+		}
+	}
+	return 0, false
+}
+
+// TotalBandwidth returns the sum, in bits per second, of the
+// bandwidth of type typ (such as "AS") of each media in s, such as
+// to estimate the bandwidth needed to admit a call.
+// Media declaring no such bandwidth add nothing, and rejected media
+// are skipped. If no media declares the bandwidth, the session-level
+// bandwidth of that type is returned, or zero if there is none.
+func (s *Session) TotalBandwidth(typ string) int {
+	var total int
+	var found bool
+	for _, m := range s.Media {
+		if m.Rejected() {
+			continue
+		}
+		if n, ok := bitrate(m.Bandwidth, typ); ok {
+			total += n
+			found = true
+		}
+	}
+	if found {
+		return total
+	}
+	n, _ := bitrate(s.Bandwidth, typ)
+	return n
+}
+
 func (b Bandwidth) String() string {
-	// need kilobits per second as per section 5.8.
-	return fmt.Sprintf("%s:%d", b.Type, b.Bitrate/1e3)
+	return fmt.Sprintf("%s:%d", b.Type, b.Bitrate/bandwidthUnit(b.Type))
+}
+
+// bandwidthUnit returns the number of bits per second in one unit of
+// the value of a bandwidth line of type typ. Values are in kilobits
+// per second as per section 5.8, except for types defined in bits
+// per second: RS and RR (RFC 3556), and TIAS (RFC 3890).
+func bandwidthUnit(typ string) int {
+	switch strings.ToUpper(typ) {
+	case "RS", "RR", "TIAS":
+		return 1
+	}
+	return 1e3
 }
 
 func parseBandwidth(s string) (Bandwidth, error) {
@@ -112,12 +341,28 @@ func parseBandwidth(s string) (Bandwidth, error) {
 	if t == "" {
 		return Bandwidth{}, fmt.Errorf("missing bandwidth type")
 	}
-	kbps, err := strconv.Atoi(b)
+	n, err := strconv.Atoi(b)
 	if err != nil {
 		return Bandwidth{}, fmt.Errorf("parse bitrate: %w", err)
 	}
 	// convert to bits per second
-	return Bandwidth{t, kbps * 1e3}, nil
+	return Bandwidth{t, n * bandwidthUnit(t)}, nil
+}
+
+// RTCPSenderBandwidth returns the bandwidth, in bits per second,
+// allocated to RTCP reports from active data senders by the media's
+// "b=RS" line specified in RFC 3556. The boolean is false if the
+// media has no such line.
+func (m Media) RTCPSenderBandwidth() (int, bool) {
+	return bitrate(m.Bandwidth, "RS")
+}
+
+// RTCPReceiverBandwidth returns the bandwidth, in bits per second,
+// allocated to RTCP reports from other participants by the media's
+// "b=RR" line specified in RFC 3556. The boolean is false if the
+// media has no such line.
+func (m Media) RTCPReceiverBandwidth() (int, bool) {
+	return bitrate(m.Bandwidth, "RR")
 }
 
 type Media struct {
@@ -129,9 +374,24 @@ type Media struct {
 	// Optional fields
 	Title      string
 	Connection *ConnInfo
-	Bandwidth  *Bandwidth
-	// TODO(otl): store as k, v pairs
-	Attributes []string
+	Bandwidth  []Bandwidth
+	Attributes []Attribute
+	// Unknown holds lines of types not otherwise handled by this package.
+	Unknown []RawLine
+	// Index is the position of the media's "m=" line in the session,
+	// starting from 0. Offer/answer (RFC 3264 section 6) and BUNDLE
+	// correlate media by this position. ReadSession sets Index.
+	Index int
+}
+
+// Rejected reports whether the media has been rejected or disabled
+// by setting its port to zero, as specified in RFC 8866 section 5.14
+// and RFC 3264 section 6. Rejected media keep their position in the
+// session so that media indexes remain aligned between an offer and
+// answer. Media with the "a=bundle-only" attribute also have a zero
+// port, but are not rejected; see RFC 8843 section 6.
+func (m Media) Rejected() bool {
+	return m.Port == 0 && !m.BundleOnly()
 }
 
 const (
@@ -139,8 +399,45 @@ const (
 	ProtoRTP
 	ProtoRTPSecure
 	ProtoRTPSecureFeedback
+	ProtoTLSRTPSecureFeedback
+	ProtoTCP
+	ProtoTCPMSRP
+	ProtoDTLSSCTP
+	ProtoTCPDTLSSCTP
 )
 
+var protocols = [...]string{
+	ProtoUDP:               "udp",
+	ProtoRTP:               "RTP/AVP",
+	ProtoRTPSecure:         "RTP/SAVP",
+	ProtoRTPSecureFeedback: "RTP/SAVPF",
+	// RFC 5764 section 8, as used by WebRTC.
+	ProtoTLSRTPSecureFeedback: "UDP/TLS/RTP/SAVPF",
+	ProtoTCP:                  "TCP",      // RFC 4145
+	ProtoTCPMSRP:              "TCP/MSRP", // RFC 4975
+	// RFC 8841 section 4, as used by WebRTC data channels.
+	ProtoDTLSSCTP:    "UDP/DTLS/SCTP",
+	ProtoTCPDTLSSCTP: "TCP/DTLS/SCTP",
+}
+
+// protocolName returns the name of the media transport protocol p,
+// or a placeholder naming its number if p is unknown.
+func protocolName(p uint8) string {
+	if int(p) >= len(protocols) {
+		return fmt.Sprintf("%d (unknown)", p)
+	}
+	return protocols[p]
+}
+
+func parseProtocol(s string) (uint8, error) {
+	for i := range protocols {
+		if protocols[i] == s {
+			return uint8(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown protocol %s", s)
+}
+
 func parseMedia(s string) (Media, error) {
 	fields := strings.Fields(s)
 	if len(fields) < 4 {
@@ -161,17 +458,9 @@ func parseMedia(s string) (Media, error) {
 		}
 	}
 
-	switch fields[2] {
-	case "udp":
-		m.Protocol = ProtoUDP
-	case "RTP/AVP":
-		m.Protocol = ProtoRTP
-	case "RTP/SAVP":
-		m.Protocol = ProtoRTPSecure
-	case "RTP/SAVPF":
-		m.Protocol = ProtoRTPSecureFeedback
-	default:
-		return Media{}, fmt.Errorf("unknown protocol %s", fields[2])
+	m.Protocol, err = parseProtocol(fields[2])
+	if err != nil {
+		return Media{}, err
 	}
 
 	m.Format = fields[3:]
@@ -201,6 +490,9 @@ func parseConnInfo(s string) (ConnInfo, error) {
 	conn.Type = fields[1]
 	addr := strings.Split(fields[2], "/")
 	conn.Address = addr[0]
+	if err := checkAddress(conn.Type, conn.Address); err != nil {
+		return conn, err
+	}
 	if len(addr) == 1 {
 		return conn, nil
 	}
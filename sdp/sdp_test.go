@@ -1,10 +1,14 @@
 package sdp
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
 	"net/mail"
 	"net/url"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -26,12 +30,13 @@ func TestReadSession(t *testing.T) {
 					Host:   "www.jdoe.example.com",
 					Path:   "/home.html",
 				},
-				Email: &mail.Address{"Jane Doe", "jane@jdoe.example.com"},
+				Email: &mail.Address{Name: "Jane Doe", Address: "jane@jdoe.example.com"},
 				Phone: "+16175556011",
 				Connection: &ConnInfo{
 					Type:    "IP4",
 					Address: "198.51.100.1",
 				},
+				TimeDescriptions: []TimeDescription{{}},
 				Media: []Media{
 					Media{
 						Type:     "audio",
@@ -44,6 +49,7 @@ func TestReadSession(t *testing.T) {
 						Port:     49180,
 						Protocol: ProtoRTP,
 						Format:   []string{"0"},
+						Index:    1,
 					},
 					Media{
 						Type:       "video",
@@ -51,7 +57,8 @@ func TestReadSession(t *testing.T) {
 						Protocol:   ProtoRTP,
 						Format:     []string{"99"},
 						Connection: &ConnInfo{"IP6", "2001:db8::2", 0, 0},
-						Attributes: []string{"rtpmap:99", "h263-1998/90000"},
+						Attributes: []Attribute{{Name: "rtpmap", Value: "99 h263-1998/90000"}},
+						Index:      2,
 					},
 				},
 			},
@@ -61,7 +68,7 @@ func TestReadSession(t *testing.T) {
 			want: Session{
 				Origin: Origin{"jdoe", 3724394400, 3724394405, "IP4", "198.51.100.1"},
 				Name:   "Call to John Smith",
-				Email:  &mail.Address{"Jane Doe", "jane@jdoe.example.com"},
+				Email:  &mail.Address{Name: "Jane Doe", Address: "jane@jdoe.example.com"},
 			},
 		},
 		{
@@ -97,6 +104,253 @@ func TestReadSession(t *testing.T) {
 	}
 }
 
+func TestMediaIndex(t *testing.T) {
+	f, err := os.Open("testdata/good.sdp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	session, err := ReadSession(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, typ := range []string{"audio", "audio", "video"} {
+		m := session.MediaAt(i)
+		if m == nil {
+			t.Fatalf("no media at index %d", i)
+		}
+		if m.Type != typ || m.Index != i {
+			t.Errorf("media at %d: got type %s index %d, want type %s index %d", i, m.Type, m.Index, typ, i)
+		}
+	}
+	if m := session.MediaAt(len(session.Media)); m != nil {
+		t.Errorf("got media %+v at out of range index", m)
+	}
+
+	video := session.SelectMedia(func(m Media) bool { return m.Type == "video" })
+	if m := video.MediaAt(0); m == nil || m.Index != 0 {
+		t.Errorf("selected media not reindexed: %+v", m)
+	}
+}
+
+func TestReadMedia(t *testing.T) {
+	raw := "m=video 9 UDP/TLS/RTP/SAVPF 96 97\r\n" +
+		"c=IN IP4 0.0.0.0\r\n" +
+		"b=AS:2000\r\n" +
+		"a=mid:1\r\n" +
+		"a=rtpmap:96 VP8/90000\r\n" +
+		"a=rtpmap:97 rtx/90000\r\n" +
+		"a=fmtp:97 apt=96\r\n" +
+		"a=rtcp-mux\r\n"
+	m, err := ReadMedia(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Type != "video" || m.Port != 9 || m.Protocol != ProtoTLSRTPSecureFeedback {
+		t.Errorf("unexpected media line: %+v", m)
+	}
+	if !reflect.DeepEqual(m.Format, []string{"96", "97"}) {
+		t.Errorf("got formats %q", m.Format)
+	}
+	if m.Connection == nil || m.Connection.Address != "0.0.0.0" {
+		t.Errorf("got connection %v", m.Connection)
+	}
+	if len(m.Attributes) != 5 || m.MID() != "1" || !m.RTCPMux() {
+		t.Errorf("attributes not attached to media: %v", m.Attributes)
+	}
+
+	for _, bad := range []string{
+		"",
+		"v=0\r\nm=video 9 RTP/AVP 96\r\n",
+		"a=mid:1\r\nm=video 9 RTP/AVP 96\r\n",
+		"m=video 9 RTP/AVP\r\n",
+		"m=video 9 RTP/AVP 96\r\nm=audio 9 RTP/AVP 0\r\n",
+		"m=video 9 RTP/AVP 96\r\na=mid:1\r\nm=audio 9 RTP/AVP 0\r\n",
+		"m=video 9 RTP/AVP 96\r\na=mid:1\r\nc=IN IP4 0.0.0.0\r\n",
+	} {
+		if m, err := ReadMedia(strings.NewReader(bad)); err == nil {
+			t.Errorf("%q: nil error, got %+v", bad, m)
+		}
+	}
+}
+
+func TestMaxLineLength(t *testing.T) {
+	head := "v=0\r\n" +
+		"o=- 1 1 IN IP4 192.0.2.1\r\n" +
+		"s=-\r\n" +
+		"t=0 0\r\n" +
+		"m=video 9 UDP/TLS/RTP/SAVPF 96\r\n"
+	// many short lines are fine, however long the session.
+	buf := &strings.Builder{}
+	buf.WriteString(head)
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(buf, "a=ssrc:%d cname:conference\r\n", i)
+	}
+	rd := Reader{MaxLineLength: 64}
+	session, err := rd.ReadSession(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(session.Media[0].Attributes); n != 10000 {
+		t.Errorf("got %d attributes, want 10000", n)
+	}
+
+	long := "a=ssrc-group:SIM " + strings.Repeat("1234 ", 20) + "\r\n"
+	_, err = rd.ReadSession(strings.NewReader(head + long))
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Fatalf("got error %v, want %v", err, bufio.ErrTooLong)
+	}
+	if !strings.Contains(err.Error(), "line 6") {
+		t.Errorf("error %q does not locate line", err)
+	}
+	// exactly at the limit.
+	exact := "a=" + strings.Repeat("x", 62) + "\r\n"
+	if _, err := rd.ReadSession(strings.NewReader(head + exact)); err != nil {
+		t.Errorf("line of maximum length: %v", err)
+	}
+
+	huge := "a=ssrc-group:SIM " + strings.Repeat("1234 ", DefaultMaxLineLength/5) + "\r\n"
+	if _, err := ReadSession(strings.NewReader(head + huge)); !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("got error %v reading line over default limit, want %v", err, bufio.ErrTooLong)
+	}
+}
+
+func TestRTCPReducedSize(t *testing.T) {
+	raw := `v=0
+o=- 1 1 IN IP4 192.0.2.1
+s=-
+t=0 0
+m=audio 49170 RTP/AVP 0
+m=video 51372 RTP/AVP 99
+a=rtpmap:99 h263-1998/90000
+a=rtcp-rsize
+`
+	session, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Media[0].RTCPReducedSize() {
+		t.Errorf("reduced-size RTCP reported on audio media without rtcp-rsize")
+	}
+	if !session.Media[1].RTCPReducedSize() {
+		t.Errorf("reduced-size RTCP not reported on video media")
+	}
+
+	buf := &strings.Builder{}
+	if err := WriteSession(buf, session); err != nil {
+		t.Fatal(err)
+	}
+	_, video, _ := strings.Cut(buf.String(), "m=video")
+	if !strings.Contains(video, "a=rtcp-rsize\r\n") {
+		t.Errorf("rtcp-rsize not written in video media scope")
+		t.Log(buf.String())
+	}
+}
+
+func TestExtmapAllowMixed(t *testing.T) {
+	raw := "v=0\r\n" +
+		"o=- 1 1 IN IP4 192.0.2.1\r\n" +
+		"s=-\r\n" +
+		"t=0 0\r\n" +
+		"a=extmap-allow-mixed\r\n" +
+		"m=video 9 UDP/TLS/RTP/SAVPF 96\r\n" +
+		"a=rtpmap:96 VP8/90000\r\n"
+	session, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !session.ExtmapAllowMixed() {
+		t.Errorf("extmap-allow-mixed not detected at session level")
+	}
+	if session.Media[0].ExtmapAllowMixed() {
+		t.Errorf("extmap-allow-mixed detected at media level")
+	}
+	buf := &strings.Builder{}
+	if err := WriteSession(buf, session); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != raw {
+		t.Errorf("session not preserved")
+		t.Log("got:", buf.String())
+		t.Log("want:", raw)
+	}
+}
+
+func TestSelectMedia(t *testing.T) {
+	session := &Session{
+		Attributes: []Attribute{
+			{Name: "group", Value: "BUNDLE 0 1"},
+			{Name: "group", Value: "LS 0"},
+			{Name: "ice-lite"},
+		},
+		Media: []Media{
+			{Type: "audio", Format: []string{"111"}, Attributes: []Attribute{{Name: "mid", Value: "0"}}},
+			{Type: "video", Format: []string{"96"}, Attributes: []Attribute{{Name: "mid", Value: "1"}}},
+		},
+	}
+	video := session.SelectMedia(func(m Media) bool { return m.Type == "video" })
+	if len(video.Media) != 1 || video.Media[0].MID() != "1" {
+		t.Fatalf("expected only video media, got %v", video.Media)
+	}
+	want := []Attribute{{Name: "group", Value: "BUNDLE 1"}, {Name: "ice-lite"}}
+	if !reflect.DeepEqual(video.Attributes, want) {
+		t.Errorf("selected session attributes = %v, want %v", video.Attributes, want)
+	}
+	if len(session.Media) != 2 || len(session.Attributes) != 3 {
+		t.Errorf("original session modified")
+	}
+}
+
+func TestAnswerDirection(t *testing.T) {
+	var cases = []struct {
+		offer   Direction
+		desired Direction
+		want    Direction
+	}{
+		{SendRecv, SendRecv, SendRecv},
+		{SendRecv, SendOnly, SendOnly},
+		{SendRecv, RecvOnly, RecvOnly},
+		{SendRecv, Inactive, Inactive},
+		{SendOnly, SendRecv, RecvOnly},
+		{SendOnly, SendOnly, Inactive},
+		{SendOnly, RecvOnly, RecvOnly},
+		{SendOnly, Inactive, Inactive},
+		{RecvOnly, SendRecv, SendOnly},
+		{RecvOnly, SendOnly, SendOnly},
+		{RecvOnly, RecvOnly, Inactive},
+		{RecvOnly, Inactive, Inactive},
+		{Inactive, SendRecv, Inactive},
+		{Inactive, SendOnly, Inactive},
+		{Inactive, RecvOnly, Inactive},
+		{Inactive, Inactive, Inactive},
+	}
+	for _, tt := range cases {
+		got := AnswerDirection(tt.offer, tt.desired)
+		if got != tt.want {
+			t.Errorf("AnswerDirection(%s, %s) = %s, want %s", tt.offer, tt.desired, got, tt.want)
+		}
+	}
+}
+
+func TestMediaDirection(t *testing.T) {
+	session := &Session{
+		Attributes: []Attribute{{Name: "recvonly"}},
+		Media: []Media{
+			{Type: "audio", Attributes: []Attribute{{Name: "inactive"}}},
+			{Type: "video"},
+		},
+	}
+	if d := session.MediaDirection(0); d != Inactive {
+		t.Errorf("media 0 direction = %s, want %s", d, Inactive)
+	}
+	if d := session.MediaDirection(1); d != RecvOnly {
+		t.Errorf("media 1 direction = %s, want session direction %s", d, RecvOnly)
+	}
+	if d := session.Media[1].Direction(); d != SendRecv {
+		t.Errorf("media 1 own direction = %s, want default %s", d, SendRecv)
+	}
+}
+
 func TestBandwidth(t *testing.T) {
 	var cases = []struct {
 		name    string
@@ -123,6 +377,74 @@ func TestBandwidth(t *testing.T) {
 	}
 }
 
+func TestRTCPBandwidth(t *testing.T) {
+	raw := "v=0\r\n" +
+		"o=- 1 1 IN IP4 192.0.2.1\r\n" +
+		"s=-\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 49170 RTP/AVP 0\r\n" +
+		"b=AS:64\r\n" +
+		"b=RS:800\r\n" +
+		"b=RR:2400\r\n" +
+		"m=video 51372 RTP/AVP 99\r\n"
+	s, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio := s.Media[0]
+	if n, ok := audio.RTCPSenderBandwidth(); !ok || n != 800 {
+		t.Errorf("RTCP sender bandwidth = %d, %t; want 800, true", n, ok)
+	}
+	if n, ok := audio.RTCPReceiverBandwidth(); !ok || n != 2400 {
+		t.Errorf("RTCP receiver bandwidth = %d, %t; want 2400, true", n, ok)
+	}
+	if n, _ := bitrate(audio.Bandwidth, "AS"); n != 64000 {
+		t.Errorf("AS bandwidth = %d, want 64000", n)
+	}
+	if _, ok := s.Media[1].RTCPSenderBandwidth(); ok {
+		t.Errorf("RTCP sender bandwidth reported for media with no RS line")
+	}
+	buf := &strings.Builder{}
+	if err := WriteSession(buf, s); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "b=AS:64\r\nb=RS:800\r\nb=RR:2400\r\n") {
+		t.Errorf("bandwidth lines not written in original units")
+		t.Log(buf.String())
+	}
+}
+
+func TestTotalBandwidth(t *testing.T) {
+	raw := "v=0\r\n" +
+		"o=- 1 1 IN IP4 192.0.2.1\r\n" +
+		"s=-\r\n" +
+		"b=AS:1000\r\n" +
+		"b=CT:2000\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 49170 RTP/AVP 0\r\n" +
+		"b=AS:64\r\n" +
+		"m=video 51372 RTP/AVP 99\r\n" +
+		"b=AS:512\r\n" +
+		"m=video 0 RTP/AVP 99\r\n" +
+		"b=AS:512\r\n" +
+		"m=application 0 RTP/AVP 100\r\n" +
+		"a=bundle-only\r\n"
+	s, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.TotalBandwidth("AS"); got != 576000 {
+		t.Errorf("total AS bandwidth = %d, want %d", got, 576000)
+	}
+	// only declared at the session level.
+	if got := s.TotalBandwidth("CT"); got != 2000000 {
+		t.Errorf("total CT bandwidth = %d, want %d", got, 2000000)
+	}
+	if got := s.TotalBandwidth("TIAS"); got != 0 {
+		t.Errorf("total undeclared bandwidth = %d, want 0", got)
+	}
+}
+
 func TestConnInfo(t *testing.T) {
 	var cases = []struct {
 		name string
@@ -149,6 +471,55 @@ func TestConnInfo(t *testing.T) {
 	}
 }
 
+func TestBadIPv6Address(t *testing.T) {
+	for _, line := range []string{
+		"IN IP6 2001:db8::zz",
+		"IN IP6 2001:db8:::1",
+		"IN IP6 2001:db8::1::2/3",
+		"IN IP6 1:2:3:4:5:6:7:8:9",
+	} {
+		if _, err := parseConnInfo(line); err == nil {
+			t.Errorf("parseConnInfo(%q): nil error for invalid address", line)
+		}
+	}
+	if _, err := parseOrigin("- 1 1 IN IP6 2001:db8::g"); err == nil {
+		t.Errorf("parseOrigin: nil error for invalid address")
+	}
+	// hostnames are permitted in place of addresses.
+	if _, err := parseConnInfo("IN IP6 media.example.com"); err != nil {
+		t.Errorf("parse connection info with hostname: %v", err)
+	}
+
+	// Errors reading a session point at the offending line.
+	const good = "v=0\r\n" +
+		"o=- 1 1 IN IP6 2001:db8::1\r\n" +
+		"s=-\r\n" +
+		"c=IN IP6 2001:db8::1\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 49170 RTP/AVP 0\r\n" +
+		"c=IN IP6 2001:db8::2\r\n"
+	var sessions = []struct {
+		bad  string
+		line int
+	}{
+		{"o=- 1 1 IN IP6 2001:db8::g", 2},
+		{"c=IN IP6 2001:db8:::1", 4},
+		{"c=IN IP6 2001:db8::zz", 7},
+	}
+	for _, tt := range sessions {
+		lines := strings.Split(good, "\r\n")
+		lines[tt.line-1] = tt.bad
+		_, err := ReadSession(strings.NewReader(strings.Join(lines, "\r\n")))
+		if err == nil {
+			t.Errorf("%s: nil error reading session", tt.bad)
+			continue
+		}
+		if want := fmt.Sprintf("line %d: ", tt.line); !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error %q does not contain %q", tt.bad, err, want)
+		}
+	}
+}
+
 func TestParseTimes(t *testing.T) {
 	var cases = []struct {
 		when string
@@ -191,6 +562,68 @@ func TestParseTimes(t *testing.T) {
 	}
 }
 
+func TestTimeDescriptions(t *testing.T) {
+	raw := `v=0
+o=- 1 1 IN IP4 192.0.2.1
+s=Seminar
+t=3724394400 3724398000
+t=3724484400 3724488000
+r=604800 3600 0 90000
+m=audio 49170 RTP/AVP 0
+`
+	session, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TimeDescription{
+		{
+			Timing: [2]time.Time{
+				time.Date(2018, time.January, 8, 10, 0, 0, 0, time.UTC),
+				time.Date(2018, time.January, 8, 11, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			Timing: [2]time.Time{
+				time.Date(2018, time.January, 9, 11, 0, 0, 0, time.UTC),
+				time.Date(2018, time.January, 9, 12, 0, 0, 0, time.UTC),
+			},
+			Repeats: []Repeat{
+				{7 * 24 * time.Hour, time.Hour, []time.Duration{0, 25 * time.Hour}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(session.TimeDescriptions, want) {
+		t.Errorf("got time descriptions %v, want %v", session.TimeDescriptions, want)
+	}
+
+	raw = `v=0
+o=- 1 1 IN IP4 192.0.2.1
+s=Seminar
+r=604800 3600 0 90000
+`
+	if _, err := ReadSession(strings.NewReader(raw)); err == nil {
+		t.Error("nil error reading repeat without time description")
+	}
+}
+
+func TestParseTimeZones(t *testing.T) {
+	line := "2882844526 -1h 2898848070 0"
+	want := []TimeZoneAdjustment{
+		{2882844526, -time.Hour},
+		{2898848070, 0},
+	}
+	got, err := parseTimeZones(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTimeZones(%q) = %v, want %v", line, got, want)
+	}
+	if _, err := parseTimeZones("2882844526 -1h 2898848070"); err == nil {
+		t.Errorf("nil error parsing odd number of fields")
+	}
+}
+
 // TODO(otl): tests for invalid repeat lines, e.g. missing fields, negative values
 
 func TestParseRepeat(t *testing.T) {
@@ -229,3 +662,135 @@ func TestDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAttribute(t *testing.T) {
+	var cases = []struct {
+		line string
+		want Attribute
+	}{
+		{
+			"fingerprint:sha-256 19:E2:1C:3B:4B:9F:81:E6:B8:5C:F4:A5:A8:D8:73:04:BB:05:2F:70:9F:04:A9:0E:05:E9:26:33:E8:70:88:A2",
+			Attribute{Name: "fingerprint", Value: "sha-256 19:E2:1C:3B:4B:9F:81:E6:B8:5C:F4:A5:A8:D8:73:04:BB:05:2F:70:9F:04:A9:0E:05:E9:26:33:E8:70:88:A2"},
+		},
+		{
+			"candidate:1 1 UDP 2130706431 2001:db8::1 5000 typ host",
+			Attribute{Name: "candidate", Value: "1 1 UDP 2130706431 2001:db8::1 5000 typ host"},
+		},
+		{
+			"control:rtsp://[2001:db8::1]:554/live.sdp/trackID=1?token=a%3Ab&x=1",
+			Attribute{Name: "control", Value: "rtsp://[2001:db8::1]:554/live.sdp/trackID=1?token=a%3Ab&x=1"},
+		},
+		{"recvonly", Attribute{Name: "recvonly"}},
+		{"x-empty:", Attribute{Name: "x-empty", EmptyValue: true}},
+	}
+	for _, tt := range cases {
+		got := parseAttribute(tt.line)
+		if got != tt.want {
+			t.Errorf("parseAttribute(%q) = %#v, want %#v", tt.line, got, tt.want)
+		}
+		if got.String() != tt.line {
+			t.Errorf("attribute string = %q, want %q", got.String(), tt.line)
+		}
+	}
+	if !parseAttribute("recvonly").Property() {
+		t.Errorf("recvonly should be a property attribute")
+	}
+	if parseAttribute("x-empty:").Property() {
+		t.Errorf("x-empty: should be a value attribute")
+	}
+}
+
+func TestAllAttributes(t *testing.T) {
+	raw := "v=0\r\n" +
+		"o=- 1 1 IN IP4 192.0.2.1\r\n" +
+		"s=-\r\n" +
+		"t=0 0\r\n" +
+		"a=group:BUNDLE 0 1\r\n" +
+		"a=extmap-allow-mixed\r\n" +
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\n" +
+		"a=mid:0\r\n" +
+		"a=rtpmap:111 opus/48000/2\r\n" +
+		"a=rtcp-mux\r\n" +
+		"m=video 9 UDP/TLS/RTP/SAVPF 96\r\n" +
+		"a=mid:1\r\n" +
+		"a=rtpmap:96 VP8/90000\r\n"
+	session, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var indexes []int
+	var names []string
+	session.AllAttributes()(func(i int, a Attribute) bool {
+		indexes = append(indexes, i)
+		names = append(names, a.Name)
+		return true
+	})
+	wantIndexes := []int{-1, -1, 0, 0, 0, 1, 1}
+	wantNames := []string{"group", "extmap-allow-mixed", "mid", "rtpmap", "rtcp-mux", "mid", "rtpmap"}
+	if !reflect.DeepEqual(indexes, wantIndexes) || !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("got attributes %v at %v, want %v at %v", names, indexes, wantNames, wantIndexes)
+	}
+
+	var n int
+	session.AllAttributes()(func(i int, a Attribute) bool {
+		n++
+		return a.Name != "rtcp-mux"
+	})
+	if n != 5 {
+		t.Errorf("iteration continued after yield returned false: visited %d attributes", n)
+	}
+}
+
+func TestMediaLookup(t *testing.T) {
+	session := &Session{
+		Media: []Media{
+			{Type: "audio", Attributes: []Attribute{{Name: "mid", Value: "a0"}}},
+			{Type: "video", Attributes: []Attribute{{Name: "mid", Value: "v0"}}},
+			{Type: "video", Attributes: []Attribute{{Name: "mid", Value: "v1"}}},
+			{Type: "application"},
+		},
+	}
+	m := session.MediaByMID("v1")
+	if m != &session.Media[2] {
+		t.Errorf("MediaByMID(%q) = %v, want media 2", "v1", m)
+	}
+	if m := session.MediaByMID("missing"); m != nil {
+		t.Errorf("MediaByMID(%q) = %v, want nil", "missing", m)
+	}
+	if m := session.MediaByMID(""); m != nil {
+		t.Errorf("MediaByMID(%q) = %v, want nil", "", m)
+	}
+	video := session.MediaByType("video")
+	if len(video) != 2 || video[0].MID() != "v0" || video[1].MID() != "v1" {
+		t.Errorf("MediaByType(%q) returned unexpected media %v", "video", video)
+	}
+}
+
+func TestSessionNameLine(t *testing.T) {
+	const rest = "t=0 0\r\nm=audio 49170 RTP/AVP 0\r\n"
+	const head = "v=0\r\no=- 1 1 IN IP4 192.0.2.1\r\n"
+	for _, name := range []string{"s= \r\n", "s=\r\n"} {
+		session, err := ReadSession(strings.NewReader(head + name + rest))
+		if err != nil {
+			t.Errorf("read session with name line %q: %v", name, err)
+			continue
+		}
+		if session.Name != "" {
+			t.Errorf("name = %q, want empty string", session.Name)
+		}
+		if err := session.Validate(); err != nil {
+			t.Errorf("validate session with name line %q: %v", name, err)
+		}
+	}
+
+	session, err := ReadSession(strings.NewReader(head + rest))
+	if err != nil {
+		t.Fatalf("read session without name line: %v", err)
+	}
+	if len(session.Media) != 1 {
+		t.Errorf("got %d media, want 1", len(session.Media))
+	}
+	if err := session.Validate(); err == nil {
+		t.Errorf("nil error validating session without name line")
+	}
+}
@@ -0,0 +1,178 @@
+package sdp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// Setup represents the "a=setup" attribute specified in RFC 4145
+// section 4, indicating which endpoint initiates a connection-oriented
+// transport such as TCP or DTLS. The zero value is SetupActive,
+// the default when no setup attribute is present.
+type Setup uint8
+
+const (
+	SetupActive Setup = iota
+	SetupPassive
+	SetupActPass
+	SetupHoldConn
+)
+
+func (s Setup) String() string {
+	switch s {
+	case SetupActive:
+		return "active"
+	case SetupPassive:
+		return "passive"
+	case SetupActPass:
+		return "actpass"
+	case SetupHoldConn:
+		return "holdconn"
+	}
+	return "invalid"
+}
+
+// Setup returns the connection setup role of the media.
+// An error is returned if the attribute has an unknown value.
+func (m Media) Setup() (Setup, error) {
+	v, ok := attrValue(m.Attributes, "setup")
+	if !ok {
+		return SetupActive, nil
+	}
+	for s := SetupActive; s <= SetupHoldConn; s++ {
+		if v == s.String() {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown setup role %q", v)
+}
+
+// TCPConnection represents the "a=connection" attribute specified in
+// RFC 4145 section 5, not to be confused with the "c=" line held in
+// ConnInfo. It indicates whether a new connection should be
+// established or an existing one reused. The zero value is
+// ConnectionNew, the default when no connection attribute is present.
+type TCPConnection uint8
+
+const (
+	ConnectionNew TCPConnection = iota
+	ConnectionExisting
+)
+
+func (c TCPConnection) String() string {
+	switch c {
+	case ConnectionNew:
+		return "new"
+	case ConnectionExisting:
+		return "existing"
+	}
+	return "invalid"
+}
+
+// TCPConnection returns the value of the media's "a=connection" attribute.
+// An error is returned if the attribute has an unknown value.
+func (m Media) TCPConnection() (TCPConnection, error) {
+	v, ok := attrValue(m.Attributes, "connection")
+	if !ok {
+		return ConnectionNew, nil
+	}
+	switch v {
+	case ConnectionNew.String():
+		return ConnectionNew, nil
+	case ConnectionExisting.String():
+		return ConnectionExisting, nil
+	}
+	return 0, fmt.Errorf("unknown connection value %q", v)
+}
+
+// TLSID returns the value of the media's "a=tls-id" attribute
+// specified in RFC 8842 section 4, an opaque token identifying a DTLS
+// or TLS association. If a re-offer or answer carries the same ID,
+// the existing association is kept rather than renegotiated.
+// The empty string is returned if there is no such attribute.
+// An error is returned if the value has characters or a length
+// not permitted by the attribute's syntax.
+func (m Media) TLSID() (string, error) {
+	return tlsID(m.Attributes)
+}
+
+// TLSID returns the value of the session's "a=tls-id" attribute.
+// RFC 8842 specifies it at the media level, but some endpoints
+// place it at the session level. See Media.TLSID.
+func (s *Session) TLSID() (string, error) {
+	return tlsID(s.Attributes)
+}
+
+func tlsID(attrs []Attribute) (string, error) {
+	v, ok := attrValue(attrs, "tls-id")
+	if !ok {
+		return "", nil
+	}
+	if len(v) < 20 || len(v) > 255 {
+		return "", fmt.Errorf("tls-id %q: length %d outside 20 to 255", v, len(v))
+	}
+	for _, c := range v {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '+', c == '/', c == '-', c == '_':
+		default:
+			return "", fmt.Errorf("tls-id %q: illegal character %q", v, c)
+		}
+	}
+	return v, nil
+}
+
+// KeyManagement represents the "a=key-mgmt" attribute specified in
+// RFC 4567 section 3, carrying a key management protocol message
+// such as MIKEY (RFC 3830) used to key SRTP. This is an alternative
+// to keying SRTP with DTLS, identified by an "a=fingerprint" attribute.
+type KeyManagement struct {
+	Protocol string // for example "mikey"
+	Data     []byte // the protocol message, decoded from base64
+}
+
+func (k KeyManagement) String() string {
+	return k.Protocol + " " + base64.StdEncoding.EncodeToString(k.Data)
+}
+
+func parseKeyManagement(s string) (KeyManagement, error) {
+	protocol, data, ok := strings.Cut(s, " ")
+	if !ok || protocol == "" {
+		return KeyManagement{}, fmt.Errorf("need protocol and data")
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	if err != nil {
+		return KeyManagement{}, fmt.Errorf("decode data: %w", err)
+	}
+	return KeyManagement{Protocol: protocol, Data: b}, nil
+}
+
+// KeyManagement returns the media's "a=key-mgmt" attributes, each
+// offering a different key management protocol. Media without the
+// attribute use the session's; see Session.KeyManagement.
+// An error is returned if an attribute's data is not valid base64.
+func (m Media) KeyManagement() ([]KeyManagement, error) {
+	return keyManagement(m.Attributes)
+}
+
+// KeyManagement returns the session's "a=key-mgmt" attributes.
+// See Media.KeyManagement.
+func (s *Session) KeyManagement() ([]KeyManagement, error) {
+	return keyManagement(s.Attributes)
+}
+
+func keyManagement(attrs []Attribute) ([]KeyManagement, error) {
+	var keys []KeyManagement
+	for _, a := range attrs {
+		if a.Name != "key-mgmt" {
+			continue
+		}
+		k, err := parseKeyManagement(a.Value)
+		if err != nil {
+			return nil, fmt.Errorf("parse key-mgmt %q: %w", a.Value, err)
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
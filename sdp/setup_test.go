@@ -0,0 +1,135 @@
+package sdp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestSetupTCPConnection(t *testing.T) {
+	m, err := parseMedia("message 7394 TCP/MSRP *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Attributes = []Attribute{
+		{Name: "accept-types", Value: "text/plain"},
+		{Name: "path", Value: "msrp://192.0.2.1:7394/2s93i93idj;tcp"},
+		{Name: "setup", Value: "active"},
+		{Name: "connection", Value: "new"},
+	}
+	if m.Protocol != ProtoTCPMSRP {
+		t.Errorf("protocol = %s, want %s", protocols[m.Protocol], protocols[ProtoTCPMSRP])
+	}
+	setup, err := m.Setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setup != SetupActive {
+		t.Errorf("setup = %s, want %s", setup, SetupActive)
+	}
+	conn, err := m.TCPConnection()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn != ConnectionNew {
+		t.Errorf("connection = %s, want %s", conn, ConnectionNew)
+	}
+
+	m.Attributes = []Attribute{{Name: "setup", Value: "holdconn"}, {Name: "connection", Value: "existing"}}
+	if setup, _ := m.Setup(); setup != SetupHoldConn {
+		t.Errorf("setup = %s, want %s", setup, SetupHoldConn)
+	}
+	if conn, _ := m.TCPConnection(); conn != ConnectionExisting {
+		t.Errorf("connection = %s, want %s", conn, ConnectionExisting)
+	}
+
+	m.Attributes = []Attribute{{Name: "setup", Value: "sideways"}, {Name: "connection", Value: "old"}}
+	if _, err := m.Setup(); err == nil {
+		t.Errorf("nil error for unknown setup role")
+	}
+	if _, err := m.TCPConnection(); err == nil {
+		t.Errorf("nil error for unknown connection value")
+	}
+}
+
+func TestTLSID(t *testing.T) {
+	const id = "abc3de65cddef001be82"
+	raw := "v=0\r\n" +
+		"o=- 1 1 IN IP4 192.0.2.1\r\n" +
+		"s=-\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\n" +
+		"a=setup:actpass\r\n" +
+		"a=tls-id:" + id + "\r\n"
+	session, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := session.Media[0].TLSID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != id {
+		t.Errorf("tls-id = %q, want %q", got, id)
+	}
+	if got, _ := session.TLSID(); got != "" {
+		t.Errorf("session tls-id = %q, want none", got)
+	}
+	buf := &strings.Builder{}
+	if err := WriteSession(buf, session); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != raw {
+		t.Errorf("tls-id not written")
+		t.Log("got:", buf.String())
+		t.Log("want:", raw)
+	}
+
+	for _, bad := range []string{"tooshort", id + "!"} {
+		m := Media{Attributes: []Attribute{{Name: "tls-id", Value: bad}}}
+		if _, err := m.TLSID(); err == nil {
+			t.Errorf("nil error for tls-id %q", bad)
+		}
+	}
+}
+
+func TestKeyManagement(t *testing.T) {
+	// the start of a MIKEY message: version 1, pre-shared key.
+	mikey := []byte{0x01, 0x00, 0x05, 0x00, 0x12, 0x34, 0x56, 0x78}
+	data := base64.StdEncoding.EncodeToString(mikey)
+	raw := "v=0\r\n" +
+		"o=alice 2891092738 2891092738 IN IP4 192.0.2.1\r\n" +
+		"s=-\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 49000 RTP/SAVP 98\r\n" +
+		"a=rtpmap:98 AMR/8000\r\n" +
+		"a=key-mgmt:mikey " + data + "\r\n"
+	session, err := ReadSession(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := session.Media[0].KeyManagement()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("got %d key-mgmt attributes, want 1", len(keys))
+	}
+	if keys[0].Protocol != "mikey" || !bytes.Equal(keys[0].Data, mikey) {
+		t.Errorf("got %+v, want mikey protocol with data %x", keys[0], mikey)
+	}
+	if keys[0].String() != "mikey "+data {
+		t.Errorf("got string %q", keys[0].String())
+	}
+	if keys, _ := session.KeyManagement(); len(keys) != 0 {
+		t.Errorf("got session key-mgmt %v, want none", keys)
+	}
+
+	for _, bad := range []string{"mikey", "mikey not!base64", " " + data} {
+		m := Media{Attributes: []Attribute{{Name: "key-mgmt", Value: bad}}}
+		if _, err := m.KeyManagement(); err == nil {
+			t.Errorf("nil error for key-mgmt %q", bad)
+		}
+	}
+}
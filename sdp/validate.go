@@ -0,0 +1,219 @@
+package sdp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Validate reports whether s is a semantically valid session
+// description. It checks constraints which ReadSession does not
+// enforce, such as references between media sections.
+func (s *Session) Validate() error {
+	if s.noName {
+		return fmt.Errorf("missing session name line")
+	}
+	if s.Origin.Username == "" {
+		return fmt.Errorf("origin: empty username")
+	} else if s.Origin.Address == "" {
+		return fmt.Errorf("origin: empty address")
+	}
+	mids := make(map[string]bool)
+	for i, m := range s.Media {
+		if m.Type == "" {
+			return fmt.Errorf("media %d: empty type", i)
+		} else if len(m.Format) == 0 {
+			return fmt.Errorf("media %d: no formats", i)
+		}
+		if err := validateRTCPMux(m); err != nil {
+			return fmt.Errorf("media %d: %w", i, err)
+		}
+		if err := validateContent(m); err != nil {
+			return fmt.Errorf("media %d: %w", i, err)
+		}
+		if err := validateSCTP(m); err != nil {
+			return fmt.Errorf("media %d: %w", i, err)
+		}
+		if err := validateCandidates(m); err != nil {
+			return fmt.Errorf("media %d: %w", i, err)
+		}
+		mid := m.MID()
+		if mid == "" {
+			continue
+		}
+		if mids[mid] {
+			return fmt.Errorf("media %d: duplicate mid %q", i, mid)
+		}
+		mids[mid] = true
+	}
+	groups, err := s.Groups()
+	if err != nil {
+		return err
+	}
+	bundled := make(map[string]bool)
+	for _, g := range groups {
+		for _, id := range g.IDs {
+			if !mids[id] {
+				return fmt.Errorf("group %s: no media with mid %q", g.Semantics, id)
+			}
+			if g.Semantics == "BUNDLE" {
+				bundled[id] = true
+			}
+		}
+	}
+	for i, m := range s.Media {
+		if !m.BundleOnly() {
+			continue
+		}
+		if m.Port != 0 {
+			return fmt.Errorf("media %d: bundle-only with non-zero port %d", i, m.Port)
+		}
+		if !bundled[m.MID()] {
+			return fmt.Errorf("media %d: bundle-only but not in a BUNDLE group", i)
+		}
+	}
+	for _, g := range groups {
+		if g.Semantics == "BUNDLE" && len(g.IDs) > 0 {
+			if err := s.validateBundle(g); err != nil {
+				return fmt.Errorf("group %s: %w", g, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateBundle checks the tagged media of the BUNDLE group g,
+// whose transport is used by the whole group, may carry media
+// and holds the transport parameters of any media in the group.
+func (s *Session) validateBundle(g Group) error {
+	tagged := s.MediaByMID(g.IDs[0])
+	if tagged.Rejected() {
+		return fmt.Errorf("tagged media %q is rejected", g.IDs[0])
+	} else if tagged.BundleOnly() {
+		return fmt.Errorf("tagged media %q is bundle-only", g.IDs[0])
+	}
+	if hasTransport(s.Attributes) || hasTransport(tagged.Attributes) {
+		return nil
+	}
+	for _, id := range g.IDs[1:] {
+		if hasTransport(s.MediaByMID(id).Attributes) {
+			return fmt.Errorf("media %q has transport parameters but tagged media %q does not", id, g.IDs[0])
+		}
+	}
+	return nil
+}
+
+// validateCandidates checks the media's ICE candidates are
+// well-formed and each has a tcptype consistent with its transport.
+func validateCandidates(m Media) error {
+	candidates, err := m.Candidates()
+	if err != nil {
+		return err
+	}
+	for _, c := range candidates {
+		if err := validateTCPType(c); err != nil {
+			return fmt.Errorf("candidate %s: %w", c, err)
+		}
+	}
+	return nil
+}
+
+// validateRTCPMux checks media with the "a=rtcp-mux-only" attribute
+// also has "a=rtcp-mux", as required by RFC 8858 section 4.2,
+// and does not declare a separate RTCP port with "a=rtcp".
+func validateRTCPMux(m Media) error {
+	if !m.RTCPMuxOnly() {
+		return nil
+	}
+	if !m.RTCPMux() {
+		return fmt.Errorf("rtcp-mux-only without rtcp-mux")
+	}
+	v, ok := attrValue(m.Attributes, "rtcp")
+	if !ok {
+		return nil
+	}
+	fields := strings.Fields(v)
+	if len(fields) == 0 {
+		return fmt.Errorf("empty rtcp attribute")
+	}
+	port, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return fmt.Errorf("parse rtcp port: %w", err)
+	}
+	if port != m.Port {
+		return fmt.Errorf("rtcp-mux-only with separate rtcp port %d", port)
+	}
+	return nil
+}
+
+// PayloadTypeError describes an inconsistent reference to an RTP
+// payload type within a media description.
+type PayloadTypeError struct {
+	Media       int // index of the media in the session
+	PayloadType string
+	Reason      string
+}
+
+func (e *PayloadTypeError) Error() string {
+	return fmt.Sprintf("media %d: payload type %s: %s", e.Media, e.PayloadType, e.Reason)
+}
+
+// ValidatePayloadTypes cross-checks the payload types of each RTP media:
+// dynamic payload types (96 to 127) in the format list must have an
+// "a=rtpmap" attribute, and every "a=rtpmap", "a=fmtp" and "a=rtcp-fb"
+// attribute must refer to a payload type in the format list.
+// Unlike Validate, every inconsistency found is returned, in order.
+func (s *Session) ValidatePayloadTypes() []error {
+	var errs []error
+	for i, m := range s.Media {
+		if !isRTP(m.Protocol) {
+			continue
+		}
+		formats := make(map[string]bool)
+		for _, f := range m.Format {
+			formats[f] = true
+		}
+		mapped := make(map[string]bool)
+		for _, a := range m.Attributes {
+			switch a.Name {
+			case "rtpmap", "fmtp", "rtcp-fb":
+			default:
+				continue
+			}
+			fields := strings.Fields(a.Value)
+			if len(fields) == 0 {
+				errs = append(errs, &PayloadTypeError{i, "", a.Name + " missing payload type"})
+				continue
+			}
+			pt := fields[0]
+			if a.Name == "rtcp-fb" && pt == "*" {
+				continue // applies to all formats.
+			}
+			if !formats[pt] {
+				errs = append(errs, &PayloadTypeError{i, pt, a.Name + " references undeclared format"})
+			}
+			if a.Name == "rtpmap" {
+				mapped[pt] = true
+			}
+		}
+		for _, f := range m.Format {
+			n, err := strconv.Atoi(f)
+			if err != nil {
+				errs = append(errs, &PayloadTypeError{i, f, "not a number"})
+				continue
+			}
+			if n >= 96 && n <= 127 && !mapped[f] {
+				errs = append(errs, &PayloadTypeError{i, f, "dynamic payload type without rtpmap"})
+			}
+		}
+	}
+	return errs
+}
+
+func isRTP(proto uint8) bool {
+	switch proto {
+	case ProtoRTP, ProtoRTPSecure, ProtoRTPSecureFeedback, ProtoTLSRTPSecureFeedback:
+		return true
+	}
+	return false
+}
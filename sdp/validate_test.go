@@ -0,0 +1,185 @@
+package sdp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const bundleOnlyOffer = `v=0
+o=- 20518 0 IN IP4 203.0.113.1
+s=-
+t=0 0
+a=group:BUNDLE 0 1
+m=audio 10000 UDP/TLS/RTP/SAVPF 111
+c=IN IP4 203.0.113.1
+a=mid:0
+a=rtpmap:111 opus/48000/2
+m=video 0 UDP/TLS/RTP/SAVPF 96
+c=IN IP4 203.0.113.1
+a=bundle-only
+a=mid:1
+a=rtpmap:96 VP8/90000
+`
+
+func TestBundleOnly(t *testing.T) {
+	session, err := ReadSession(strings.NewReader(bundleOnlyOffer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Media[0].BundleOnly() {
+		t.Errorf("audio media reported as bundle-only")
+	}
+	if !session.Media[1].BundleOnly() {
+		t.Errorf("video media not reported as bundle-only")
+	}
+	if err := session.Validate(); err != nil {
+		t.Errorf("validate offer: %v", err)
+	}
+
+	unbundled := strings.Replace(bundleOnlyOffer, "a=group:BUNDLE 0 1", "a=group:BUNDLE 0", 1)
+	session, err = ReadSession(strings.NewReader(unbundled))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Validate(); err == nil {
+		t.Errorf("nil error validating bundle-only media outside of BUNDLE group")
+	}
+
+	session.Media[1].Port = 9
+	session.Attributes[0].Value = "BUNDLE 0 1"
+	if err := session.Validate(); err == nil {
+		t.Errorf("nil error validating bundle-only media with non-zero port")
+	}
+}
+
+func TestValidatePayloadTypes(t *testing.T) {
+	session, err := ReadSession(strings.NewReader(bundleOnlyOffer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := session.ValidatePayloadTypes(); len(errs) > 0 {
+		t.Errorf("consistent offer has payload type errors: %v", errs)
+	}
+
+	session.Media[1].Format = append(session.Media[1].Format, "97")
+	session.Media[1].Attributes = append(session.Media[1].Attributes,
+		Attribute{Name: "fmtp", Value: "98 apt=96"},
+		Attribute{Name: "rtcp-fb", Value: "* nack"},
+		Attribute{Name: "rtcp-fb", Value: "96 nack pli"},
+	)
+	errs := session.ValidatePayloadTypes()
+	want := []PayloadTypeError{
+		{1, "98", "fmtp references undeclared format"},
+		{1, "97", "dynamic payload type without rtpmap"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got errors %v, want %d errors", errs, len(want))
+	}
+	for i := range errs {
+		var perr *PayloadTypeError
+		if !errors.As(errs[i], &perr) {
+			t.Fatalf("error %d: %v is not a PayloadTypeError", i, errs[i])
+		}
+		if *perr != want[i] {
+			t.Errorf("error %d: got %v, want %v", i, perr, &want[i])
+		}
+	}
+}
+
+func TestBundleTagged(t *testing.T) {
+	const offer = `v=0
+o=- 20518 0 IN IP4 203.0.113.1
+s=-
+t=0 0
+a=group:BUNDLE 1 0
+m=audio 10000 UDP/TLS/RTP/SAVPF 111
+a=mid:0
+a=rtpmap:111 opus/48000/2
+m=video 10002 UDP/TLS/RTP/SAVPF 96
+a=mid:1
+a=ice-ufrag:8hhY
+a=ice-pwd:asd88fgpdd777uzjYhagZg
+a=fingerprint:sha-256 19:E2:1C:3B:4B:9F:81:E6:B8:5C:F4:A5:A8:D8:73:04:BB:05:2F:70:9F:04:A9:0E:05:E9:26:33:E8:70:88:A2
+a=rtpmap:96 VP8/90000
+`
+	session, err := ReadSession(strings.NewReader(offer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := session.BundleTagged(); m != &session.Media[1] {
+		t.Errorf("tagged media = %v, want media with mid 1", m)
+	}
+	if err := session.Validate(); err != nil {
+		t.Errorf("validate offer: %v", err)
+	}
+
+	untagged := strings.Replace(offer, "BUNDLE 1 0", "BUNDLE 0 1", 1)
+	session, err = ReadSession(strings.NewReader(untagged))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := session.BundleTagged(); m != &session.Media[0] {
+		t.Errorf("tagged media = %v, want media with mid 0", m)
+	}
+	if err := session.Validate(); err == nil {
+		t.Errorf("nil error validating bundle with transport parameters missing from tagged media")
+	}
+
+	if m := (&Session{}).BundleTagged(); m != nil {
+		t.Errorf("tagged media of session with no BUNDLE group = %v, want nil", m)
+	}
+}
+
+func TestRTCPMuxOnly(t *testing.T) {
+	const offer = `v=0
+o=- 20518 0 IN IP4 203.0.113.1
+s=-
+t=0 0
+m=audio 10000 UDP/TLS/RTP/SAVPF 111
+a=rtcp-mux
+a=rtcp-mux-only
+a=rtpmap:111 opus/48000/2
+`
+	session, err := ReadSession(strings.NewReader(offer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !session.Media[0].RTCPMuxOnly() {
+		t.Errorf("rtcp-mux-only not reported")
+	}
+	if err := session.Validate(); err != nil {
+		t.Errorf("validate offer: %v", err)
+	}
+
+	var tests = []struct {
+		name    string
+		attr    string
+		wantErr bool
+	}{
+		{"same port", "a=rtcp:10000", false},
+		{"separate port", "a=rtcp:10001 IN IP4 203.0.113.1", true},
+	}
+	for _, tt := range tests {
+		sdp := offer + tt.attr + "\n"
+		session, err := ReadSession(strings.NewReader(sdp))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = session.Validate()
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: nil error validating %s with rtcp-mux-only", tt.name, tt.attr)
+		} else if !tt.wantErr && err != nil {
+			t.Errorf("%s: validate: %v", tt.name, err)
+		}
+	}
+
+	nomux := strings.Replace(offer, "a=rtcp-mux\n", "", 1)
+	session, err = ReadSession(strings.NewReader(nomux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Validate(); err == nil {
+		t.Errorf("nil error validating rtcp-mux-only without rtcp-mux")
+	}
+}
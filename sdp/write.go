@@ -0,0 +1,154 @@
+package sdp
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteSession writes s to w in SDP format.
+// Lines are terminated with CRLF as required by RFC 8866 section 5.
+func WriteSession(w io.Writer, s *Session) error {
+	buf := &strings.Builder{}
+	writeField(buf, "v", "0")
+	writeField(buf, "o", s.Origin.String())
+	if s.Name == "" {
+		// RFC 8866 section 5.3: a single space if there is no name.
+		writeField(buf, "s", " ")
+	} else {
+		writeField(buf, "s", s.Name)
+	}
+	if s.Info != "" {
+		writeField(buf, "i", s.Info)
+	}
+	if s.URI != nil {
+		writeField(buf, "u", s.URI.String())
+	}
+	if s.Email != nil {
+		writeField(buf, "e", s.Email.String())
+	}
+	if s.Phone != "" {
+		writeField(buf, "p", s.Phone)
+	}
+	if s.Connection != nil {
+		writeField(buf, "c", s.Connection.String())
+	}
+	for _, bw := range s.Bandwidth {
+		writeField(buf, "b", bw.String())
+	}
+	// A time description is required, even if the session is unbounded.
+	if len(s.TimeDescriptions) == 0 {
+		writeField(buf, "t", formatTimes([2]time.Time{}))
+	}
+	for _, td := range s.TimeDescriptions {
+		writeField(buf, "t", formatTimes(td.Timing))
+		for _, r := range td.Repeats {
+			writeField(buf, "r", r.String())
+		}
+	}
+	if len(s.TimeZones) > 0 {
+		var fields []string
+		for _, z := range s.TimeZones {
+			fields = append(fields, strconv.FormatUint(z.AdjustmentTime, 10), formatSeconds(z.Offset))
+		}
+		writeField(buf, "z", strings.Join(fields, " "))
+	}
+	for _, l := range s.Unknown {
+		writeField(buf, l.Type, l.Value)
+	}
+	for _, a := range s.Attributes {
+		writeField(buf, "a", a.String())
+	}
+	for i, m := range s.Media {
+		if err := writeMedia(buf, &m); err != nil {
+			return fmt.Errorf("media %d: %w", i, err)
+		}
+	}
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+func writeField(buf *strings.Builder, key, value string) {
+	buf.WriteString(key)
+	buf.WriteString("=")
+	buf.WriteString(value)
+	buf.WriteString("\r\n")
+}
+
+func writeMedia(buf *strings.Builder, m *Media) error {
+	if m.Type == "" {
+		return fmt.Errorf("empty media type")
+	}
+	if int(m.Protocol) >= len(protocols) {
+		return fmt.Errorf("unknown protocol %d", m.Protocol)
+	}
+	if len(m.Format) == 0 {
+		return fmt.Errorf("no media formats")
+	}
+	port := strconv.Itoa(m.Port)
+	if m.PortCount > 0 {
+		port += "/" + strconv.Itoa(m.PortCount)
+	}
+	fields := []string{m.Type, port, protocols[m.Protocol]}
+	fields = append(fields, m.Format...)
+	writeField(buf, "m", strings.Join(fields, " "))
+	if m.Title != "" {
+		writeField(buf, "i", m.Title)
+	}
+	if m.Connection != nil {
+		writeField(buf, "c", m.Connection.String())
+	}
+	for _, bw := range m.Bandwidth {
+		writeField(buf, "b", bw.String())
+	}
+	for _, l := range m.Unknown {
+		writeField(buf, l.Type, l.Value)
+	}
+	for _, a := range m.Attributes {
+		writeField(buf, "a", a.String())
+	}
+	return nil
+}
+
+func (o Origin) String() string {
+	return fmt.Sprintf("%s %d %d IN %s %s", o.Username, o.ID, o.Version, o.AddressType, o.Address)
+}
+
+func (c ConnInfo) String() string {
+	addr := c.Address
+	if c.Type == "IP4" && c.TTL > 0 {
+		addr += "/" + strconv.Itoa(c.TTL)
+	}
+	if c.Count > 0 {
+		addr += "/" + strconv.Itoa(c.Count)
+	}
+	return fmt.Sprintf("IN %s %s", c.Type, addr)
+}
+
+// formatTimes returns the start and stop times as seconds since the
+// SDP zero time. A zero time.Time is written as 0, meaning unbounded.
+func formatTimes(times [2]time.Time) string {
+	var ss [2]string
+	for i, t := range times {
+		if t.IsZero() {
+			ss[i] = "0"
+			continue
+		}
+		ss[i] = strconv.FormatInt(t.Unix()+sinceTimeZero, 10)
+	}
+	return ss[0] + " " + ss[1]
+}
+
+func (r Repeat) String() string {
+	fields := []string{formatSeconds(r.Interval), formatSeconds(r.Active)}
+	for _, offset := range r.Offsets {
+		fields = append(fields, formatSeconds(offset))
+	}
+	return strings.Join(fields, " ")
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}